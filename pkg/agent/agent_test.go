@@ -0,0 +1,512 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"giai/pkg/prompt"
+	"giai/pkg/provider"
+	"giai/pkg/tool"
+	"giai/pkg/types"
+)
+
+// scriptedModel is a fake provider that replays canned responses and stream chunks.
+type scriptedModel struct {
+	mu        sync.Mutex
+	responses []*types.ChatResponse
+	streams   [][]provider.ChatChunk
+	calls     [][]types.Message
+	options   []*provider.ChatOptions
+}
+
+func (m *scriptedModel) Name() string { return "scripted" }
+
+func (m *scriptedModel) Chat(ctx context.Context, messages []types.Message, opts ...provider.Option) (*types.ChatResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, messages)
+	m.options = append(m.options, resolveOptions(opts))
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if len(m.responses) == 0 {
+		return nil, errors.New("scripted: no more responses")
+	}
+	resp := m.responses[0]
+	m.responses = m.responses[1:]
+	return resp, nil
+}
+
+func (m *scriptedModel) Stream(ctx context.Context, messages []types.Message, opts ...provider.Option) (<-chan provider.ChatChunk, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, messages)
+	m.options = append(m.options, resolveOptions(opts))
+	if len(m.streams) == 0 {
+		return nil, errors.New("scripted: no more streams")
+	}
+	chunks := m.streams[0]
+	m.streams = m.streams[1:]
+
+	ch := make(chan provider.ChatChunk, len(chunks))
+	for _, c := range chunks {
+		ch <- c
+	}
+	close(ch)
+	return ch, nil
+}
+
+func TestRun_CancelledContext(t *testing.T) {
+	ag, err := New(Config{Provider: &scriptedModel{responses: []*types.ChatResponse{
+		{Message: types.AssistantMessage("too late")},
+	}}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ag.Run(ctx, "hello"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Run() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestRunStream_StorePartialOnError(t *testing.T) {
+	streamErr := errors.New("connection reset")
+	model := &scriptedModel{
+		streams: [][]provider.ChatChunk{{
+			{Content: "Hello, "},
+			{Content: "wor"},
+			{Error: streamErr},
+		}},
+	}
+
+	ag, err := New(Config{Provider: model, StorePartialOnError: true})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	_, err = ag.RunStream(context.Background(), "hi", nil)
+	if !errors.Is(err, streamErr) {
+		t.Fatalf("RunStream() error = %v, want %v", err, streamErr)
+	}
+
+	history := ag.History()
+	if len(history) != 2 {
+		t.Fatalf("len(History()) = %d, want 2", len(history))
+	}
+	last := history[1]
+	if last.Role != types.RoleAssistant || last.Content != "Hello, wor" {
+		t.Errorf("last message = %+v, want partial assistant content %q", last, "Hello, wor")
+	}
+	if partial, _ := last.Metadata["partial"].(bool); !partial {
+		t.Errorf("Metadata[partial] = %v, want true", last.Metadata["partial"])
+	}
+}
+
+func TestRunStream_DiscardPartialByDefault(t *testing.T) {
+	model := &scriptedModel{
+		streams: [][]provider.ChatChunk{{
+			{Content: "Hello"},
+			{Error: errors.New("boom")},
+		}},
+	}
+
+	ag, err := New(Config{Provider: model})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if _, err := ag.RunStream(context.Background(), "hi", nil); err == nil {
+		t.Fatal("RunStream() expected error")
+	}
+	if got := len(ag.History()); got != 1 {
+		t.Errorf("len(History()) = %d, want 1 (user message only)", got)
+	}
+}
+
+func TestRun_RejectEmptyResponses(t *testing.T) {
+	tests := []struct {
+		name      string
+		responses []string
+		want      string
+		wantErr   error
+		wantCalls int
+	}{
+		{name: "Retry Succeeds", responses: []string{"  \n", "hello"}, want: "hello", wantCalls: 2},
+		{name: "Still Empty", responses: []string{"", " "}, wantErr: ErrEmptyResponse, wantCalls: 2},
+	}
+
+	for _, tt := range tests {
+		for _, stream := range []bool{false, true} {
+			name := tt.name
+			if stream {
+				name += " Stream"
+			}
+			t.Run(name, func(t *testing.T) {
+				model := &scriptedModel{}
+				for _, r := range tt.responses {
+					model.responses = append(model.responses, &types.ChatResponse{Message: types.AssistantMessage(r)})
+					model.streams = append(model.streams, []provider.ChatChunk{{Content: r, FinishReason: "stop"}})
+				}
+				ag, err := New(Config{Provider: model, RejectEmptyResponses: true})
+				if err != nil {
+					t.Fatalf("New() error = %v", err)
+				}
+
+				var got string
+				if stream {
+					got, err = ag.RunStream(context.Background(), "hi", nil)
+				} else {
+					got, err = ag.Run(context.Background(), "hi")
+				}
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Run() error = %v, want %v", err, tt.wantErr)
+				}
+				if got != tt.want {
+					t.Errorf("Run() = %q, want %q", got, tt.want)
+				}
+				if len(model.calls) != tt.wantCalls {
+					t.Errorf("provider calls = %d, want %d", len(model.calls), tt.wantCalls)
+				}
+				for _, msg := range ag.History() {
+					if msg.Role == types.RoleAssistant && strings.TrimSpace(msg.Content) == "" {
+						t.Errorf("empty assistant message stored in history")
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestNew_ValidatesToolNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		tools   []tool.Tool
+		wantErr bool
+	}{
+		{name: "Unique", tools: []tool.Tool{tool.NewFunc("a", "", nil), tool.NewFunc("b", "", nil)}},
+		{name: "Duplicate", tools: []tool.Tool{tool.NewFunc("dup", "", nil), tool.NewFunc("dup", "", nil)}, wantErr: true},
+		{name: "Empty", tools: []tool.Tool{tool.NewFunc("", "", nil)}, wantErr: true},
+		{name: "Invalid Character", tools: []tool.Tool{tool.NewFunc("read file", "", nil)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ag, err := New(Config{Provider: &scriptedModel{}, Tools: tt.tools})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				if err := ag.Validate(); err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestRun_Transforms(t *testing.T) {
+	email := regexp.MustCompile(`[\w.]+@[\w.]+`)
+	secret := regexp.MustCompile(`sk-\w+`)
+	mask := func(re *regexp.Regexp, repl string) func(types.Message) types.Message {
+		return func(m types.Message) types.Message {
+			m.Content = re.ReplaceAllString(m.Content, repl)
+			return m
+		}
+	}
+
+	model := &scriptedModel{responses: []*types.ChatResponse{
+		{Message: types.AssistantMessage("your key is sk-abc123")},
+	}}
+	ag, err := New(Config{
+		Provider:          model,
+		InboundTransform:  mask(email, "[email]"),
+		OutboundTransform: mask(secret, "[secret]"),
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	out, err := ag.Run(context.Background(), "I am bob@example.com")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if out != "your key is [secret]" {
+		t.Errorf("Run() = %q, want secret masked", out)
+	}
+
+	sent := model.calls[0]
+	if got := sent[len(sent)-1].Content; got != "I am [email]" {
+		t.Errorf("sent user content = %q, want email masked", got)
+	}
+	history := ag.History()
+	if history[0].Content != "I am [email]" || history[1].Content != "your key is [secret]" {
+		t.Errorf("history = %+v, want transformed messages", history)
+	}
+}
+
+func TestRun_OutputFilters(t *testing.T) {
+	filters := []*regexp.Regexp{
+		regexp.MustCompile(`(?i)^\s*let me think[^\n]*\n`),
+		regexp.MustCompile(`(?i)\n[^\n]*hope this helps[^\n]*$`),
+	}
+	answer := "Let me think about this...\nThe answer is 42.\nI hope this helps!"
+
+	model := &scriptedModel{responses: []*types.ChatResponse{{Message: types.AssistantMessage(answer)}}}
+	ag, err := New(Config{Provider: model, OutputFilters: filters})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	out, err := ag.Run(context.Background(), "question")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if out != "The answer is 42." {
+		t.Errorf("Run() = %q, want preamble and sign-off stripped", out)
+	}
+	if got := ag.History()[1].Content; got != out {
+		t.Errorf("stored content = %q, want %q", got, out)
+	}
+
+	// Streamed deltas are shown live, unfiltered.
+	chunks := []provider.ChatChunk{{Content: answer[:30]}, {Content: answer[30:]}, {FinishReason: "stop"}}
+	ag, err = New(Config{Provider: &scriptedModel{streams: [][]provider.ChatChunk{chunks}}, OutputFilters: filters})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	var streamed strings.Builder
+	out, err = ag.RunStream(context.Background(), "question", func(d string) { streamed.WriteString(d) })
+	if err != nil {
+		t.Fatalf("RunStream() error = %v", err)
+	}
+	if out != "The answer is 42." || streamed.String() != answer {
+		t.Errorf("RunStream() = %q with deltas %q, want filtered result and raw deltas", out, streamed.String())
+	}
+}
+
+func TestNew_SystemPromptFragments(t *testing.T) {
+	model := &scriptedModel{responses: []*types.ChatResponse{{Message: types.AssistantMessage("ok")}}}
+	ag, err := New(Config{
+		Provider: model,
+		SystemPromptFragments: []prompt.Template{
+			prompt.NewTemplate("You are a reviewer."),
+			prompt.NewTemplate("Reply in one sentence."),
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, err := ag.Run(context.Background(), "hi"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if got, want := model.calls[0][0].Content, "You are a reviewer.\n\nReply in one sentence."; got != want {
+		t.Errorf("system prompt = %q, want %q", got, want)
+	}
+}
+
+func TestRun_InjectCurrentTime(t *testing.T) {
+	model := &scriptedModel{responses: []*types.ChatResponse{{Message: types.AssistantMessage("ok")}}}
+	ag, err := New(Config{
+		Provider:          model,
+		InjectCurrentTime: true,
+		TimeZone:          "Asia/Tokyo",
+		Locale:            "ja-JP",
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ag.now = func() time.Time { return time.Date(2025, 3, 14, 23, 30, 0, 0, time.UTC) }
+
+	if _, err := ag.Run(context.Background(), "what day is it?"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	sent := model.calls[0]
+	if len(sent) != 3 {
+		t.Fatalf("sent %d messages, want 3", len(sent))
+	}
+	note := sent[1]
+	want := "Current date and time: Saturday, 2025-03-15 08:30 JST (Asia/Tokyo). User locale: ja-JP."
+	if note.Role != types.RoleSystem || note.Content != want {
+		t.Errorf("note = %+v, want system message %q", note, want)
+	}
+	if sent[2].Role != types.RoleUser {
+		t.Errorf("message after note has role %q, want user", sent[2].Role)
+	}
+
+	for _, msg := range ag.History() {
+		if msg.Role == types.RoleSystem {
+			t.Errorf("time note stored in history: %q", msg.Content)
+		}
+	}
+}
+
+func TestNew_InvalidTimeZone(t *testing.T) {
+	if _, err := New(Config{Provider: &scriptedModel{}, InjectCurrentTime: true, TimeZone: "Mars/Olympus"}); err == nil {
+		t.Error("New() expected error for an unknown time zone")
+	}
+}
+
+func TestRun_CompactMessages(t *testing.T) {
+	model := &scriptedModel{responses: []*types.ChatResponse{{Message: types.AssistantMessage("ok")}}}
+	ag, err := New(Config{Provider: model, CompactMessages: true})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	// A user message left behind by an earlier failed turn.
+	ag.memory.Add(types.UserMessage("first"))
+
+	if _, err := ag.Run(context.Background(), "second"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	sent := model.calls[0]
+	if len(sent) != 2 || sent[1].Content != "first\n\nsecond" {
+		t.Errorf("sent %+v, want system prompt and one merged user message", sent)
+	}
+	if got := len(ag.History()); got != 3 {
+		t.Errorf("len(History()) = %d, want 3: history stays uncompacted", got)
+	}
+}
+
+func TestRun_ContentFiltered(t *testing.T) {
+	filtered := func() *scriptedModel {
+		return &scriptedModel{responses: []*types.ChatResponse{
+			{Message: types.AssistantMessage(""), FinishReason: "content_filter"},
+		}}
+	}
+
+	t.Run("Error", func(t *testing.T) {
+		ag, err := New(Config{Provider: filtered()})
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		_, err = ag.Run(context.Background(), "hi")
+		var cfErr *ContentFilteredError
+		if !errors.As(err, &cfErr) {
+			t.Fatalf("Run() error = %v, want *ContentFilteredError", err)
+		}
+		if got := len(ag.History()); got != 1 {
+			t.Errorf("len(History()) = %d, want 1: filtered answer not stored", got)
+		}
+	})
+
+	t.Run("Allowed", func(t *testing.T) {
+		ag, err := New(Config{Provider: filtered(), AllowContentFiltered: true})
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		if _, err := ag.Run(context.Background(), "hi"); err != nil {
+			t.Errorf("Run() error = %v, want nil", err)
+		}
+	})
+
+	t.Run("Stream", func(t *testing.T) {
+		model := &scriptedModel{streams: [][]provider.ChatChunk{{{Content: "Here is"}, {FinishReason: "content_filter"}}}}
+		ag, err := New(Config{Provider: model})
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		_, err = ag.RunStream(context.Background(), "hi", nil)
+		var cfErr *ContentFilteredError
+		if !errors.As(err, &cfErr) || cfErr.Content != "Here is" {
+			t.Errorf("RunStream() error = %v, want *ContentFilteredError with partial content", err)
+		}
+	})
+}
+
+// flakyModel fails with errs, one per call, before answering from scriptedModel.
+type flakyModel struct {
+	scriptedModel
+	errs     []error
+	attempts int
+}
+
+func (m *flakyModel) Chat(ctx context.Context, messages []types.Message, opts ...provider.Option) (*types.ChatResponse, error) {
+	m.attempts++
+	if len(m.errs) > 0 {
+		err := m.errs[0]
+		m.errs = m.errs[1:]
+		return nil, err
+	}
+	return m.scriptedModel.Chat(ctx, messages, opts...)
+}
+
+func TestRun_MaxProviderRetries(t *testing.T) {
+	transient := &provider.Error{Provider: "test", StatusCode: 503, Retryable: true}
+	fatal := &provider.Error{Provider: "test", StatusCode: 400}
+
+	tests := []struct {
+		name         string
+		errs         []error
+		wantErr      bool
+		wantAttempts int
+	}{
+		{name: "Transient Then Success", errs: []error{transient, transient}, wantAttempts: 3},
+		{name: "Fatal Aborts", errs: []error{fatal}, wantErr: true, wantAttempts: 1},
+		{name: "Retries Exhausted", errs: []error{transient, transient, transient}, wantErr: true, wantAttempts: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model := &flakyModel{errs: tt.errs}
+			model.responses = []*types.ChatResponse{{Message: types.AssistantMessage("ok")}}
+			ag, err := New(Config{Provider: model, MaxProviderRetries: 2, ProviderRetryBackoff: time.Millisecond})
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			out, err := ag.Run(context.Background(), "hi")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if model.attempts != tt.wantAttempts {
+				t.Errorf("provider attempts = %d, want %d", model.attempts, tt.wantAttempts)
+			}
+			if !tt.wantErr && out != "ok" {
+				t.Errorf("Run() = %q, want %q", out, "ok")
+			}
+			// Retries never add to the conversation.
+			if want := map[bool]int{false: 2, true: 1}[tt.wantErr]; len(ag.History()) != want {
+				t.Errorf("len(History()) = %d, want %d", len(ag.History()), want)
+			}
+		})
+	}
+}
+
+// messageCounter counts one token per message.
+type messageCounter struct{}
+
+func (messageCounter) CountMessages(messages []types.Message, model string) (int, error) {
+	return len(messages), nil
+}
+
+func TestRun_MaxContextTokens(t *testing.T) {
+	model := &scriptedModel{responses: []*types.ChatResponse{
+		{Message: types.AssistantMessage("one")},
+		{Message: types.AssistantMessage("two")},
+		{Message: types.AssistantMessage("three")},
+	}}
+	ag, err := New(Config{Provider: model, MaxContextTokens: 4, TokenCounter: messageCounter{}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	for _, q := range []string{"a", "b", "c"} {
+		if _, err := ag.Run(context.Background(), q); err != nil {
+			t.Fatalf("Run(%q) error = %v", q, err)
+		}
+	}
+
+	last := model.calls[2]
+	if len(last) != 4 || last[0].Role != types.RoleSystem || last[3].Content != "c" {
+		t.Errorf("last request = %+v, want the system prompt and the 3 latest messages", last)
+	}
+	if n := len(ag.History()); n != 6 {
+		t.Errorf("history has %d messages, want all 6 kept", n)
+	}
+}
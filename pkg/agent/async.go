@@ -0,0 +1,40 @@
+package agent
+
+import "context"
+
+// RunHandle tracks a turn started with RunAsync.
+type RunHandle struct {
+	done   chan struct{}
+	cancel context.CancelFunc
+	out    string
+	err    error
+}
+
+// RunAsync starts Run in a goroutine and returns immediately.
+// Cancelling ctx or calling Cancel on the handle aborts the turn.
+func (a *Agent) RunAsync(ctx context.Context, input string, opts ...RunOption) *RunHandle {
+	ctx, cancel := context.WithCancel(ctx)
+	h := &RunHandle{done: make(chan struct{}), cancel: cancel}
+	go func() {
+		defer close(h.done)
+		defer cancel()
+		h.out, h.err = a.Run(ctx, input, opts...)
+	}()
+	return h
+}
+
+// Done is closed when the turn finishes.
+func (h *RunHandle) Done() <-chan struct{} {
+	return h.done
+}
+
+// Result waits for the turn to finish and returns its outcome.
+func (h *RunHandle) Result() (string, error) {
+	<-h.done
+	return h.out, h.err
+}
+
+// Cancel aborts the turn. Result then reports the context error.
+func (h *RunHandle) Cancel() {
+	h.cancel()
+}
@@ -0,0 +1,51 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"giai/pkg/provider"
+	"giai/pkg/types"
+)
+
+// blockingModel waits for its context before failing, to exercise cancellation.
+type blockingModel struct{ scriptedModel }
+
+func (m *blockingModel) Chat(ctx context.Context, messages []types.Message, opts ...provider.Option) (*types.ChatResponse, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestRunAsync(t *testing.T) {
+	model := &scriptedModel{responses: []*types.ChatResponse{{Message: types.AssistantMessage("hello")}}}
+	ag, err := New(Config{Provider: model})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	h := ag.RunAsync(context.Background(), "hi")
+	select {
+	case <-h.Done():
+	case <-time.After(time.Second):
+		t.Fatal("RunAsync() did not finish")
+	}
+	out, err := h.Result()
+	if err != nil || out != "hello" {
+		t.Errorf("Result() = %q, %v; want %q, nil", out, err, "hello")
+	}
+}
+
+func TestRunAsync_Cancel(t *testing.T) {
+	ag, err := New(Config{Provider: &blockingModel{}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	h := ag.RunAsync(context.Background(), "hi")
+	h.Cancel()
+	if _, err := h.Result(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Result() error = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,37 @@
+package agent
+
+import (
+	"context"
+
+	"giai/pkg/types"
+)
+
+// Hooks are optional callbacks invoked during a turn. Nil hooks are skipped.
+type Hooks struct {
+	// OnInterimText receives assistant text that arrives together with tool calls
+	// (e.g. "Let me look that up."), before the tools run.
+	OnInterimText func(text string)
+	// OnToolResult receives each tool result message once its call has executed.
+	OnToolResult func(call types.ToolCall, result types.Message)
+	// OnToolCallDelta receives each raw tool call fragment while RunStream is
+	// streaming, keeping tool-call activity out of the text callback.
+	OnToolCallDelta func(fragment types.ToolCall)
+	// OnToolCalls receives the planned actions of a round of tool calls before
+	// any of them runs, and returns the CallIDs the user approved. They are
+	// added to tool.MetadataApprovedCalls for the round, so calls whose IDs
+	// were minted during the run can still be approved. An error rejects
+	// every call in the round and ends the turn.
+	OnToolCalls func(ctx context.Context, actions []PlannedAction) (approved []string, err error)
+}
+
+func (h Hooks) interimText(text string) {
+	if h.OnInterimText != nil && text != "" {
+		h.OnInterimText(text)
+	}
+}
+
+func (h Hooks) toolResult(call types.ToolCall, result types.Message) {
+	if h.OnToolResult != nil {
+		h.OnToolResult(call, result)
+	}
+}
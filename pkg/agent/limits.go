@@ -0,0 +1,71 @@
+package agent
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"giai/pkg/types"
+)
+
+// OversizePolicy decides what happens when a message exceeds MaxMessageBytes.
+type OversizePolicy int
+
+const (
+	// OversizeError fails the request with a *MessageTooLargeError.
+	OversizeError OversizePolicy = iota
+	// OversizeTruncate shortens oversized tool outputs in the request sent to
+	// the provider; stored history is left intact. Oversized
+	// messages of other roles still fail with *MessageTooLargeError.
+	OversizeTruncate
+)
+
+// MessageTooLargeError reports a message whose content exceeds MaxMessageBytes.
+type MessageTooLargeError struct {
+	Index int // Position in the request, 0 being the system prompt
+	Role  types.Role
+	Size  int
+	Limit int
+}
+
+func (e *MessageTooLargeError) Error() string {
+	return fmt.Sprintf("agent: %s message %d is %d bytes, exceeding the %d byte limit", e.Role, e.Index, e.Size, e.Limit)
+}
+
+// enforceMessageSize applies the MaxMessageBytes policy to messages, returning
+// a copy when anything was truncated.
+func (a *Agent) enforceMessageSize(messages []types.Message) ([]types.Message, error) {
+	if a.maxMessageBytes <= 0 {
+		return messages, nil
+	}
+	var out []types.Message
+	for i, msg := range messages {
+		if len(msg.Content) <= a.maxMessageBytes {
+			continue
+		}
+		if a.oversizePolicy != OversizeTruncate || msg.Role != types.RoleTool {
+			return nil, &MessageTooLargeError{Index: i, Role: msg.Role, Size: len(msg.Content), Limit: a.maxMessageBytes}
+		}
+		if out == nil {
+			out = append([]types.Message(nil), messages...)
+		}
+		out[i].Content = truncateContent(msg.Content, a.maxMessageBytes)
+	}
+	if out == nil {
+		return messages, nil
+	}
+	return out, nil
+}
+
+// truncateContent cuts s to at most limit bytes, including a note of how much
+// was dropped, without splitting a UTF-8 sequence.
+func truncateContent(s string, limit int) string {
+	note := fmt.Sprintf("\n[truncated %d bytes]", len(s))
+	keep := limit - len(note)
+	if keep <= 0 {
+		return note[:min(limit, len(note))]
+	}
+	for keep > 0 && !utf8.RuneStart(s[keep]) {
+		keep--
+	}
+	return s[:keep] + fmt.Sprintf("\n[truncated %d bytes]", len(s)-keep)
+}
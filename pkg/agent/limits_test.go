@@ -0,0 +1,77 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"giai/pkg/tool"
+	"giai/pkg/types"
+)
+
+func TestRun_MaxMessageBytes(t *testing.T) {
+	big := strings.Repeat("x", 500)
+	newModel := func() *scriptedModel {
+		return &scriptedModel{
+			responses: []*types.ChatResponse{
+				{Message: types.AssistantToolCall(types.NewToolCall("call_1", "echo", `{"text":"`+big+`"}`))},
+				{Message: types.AssistantMessage("done")},
+			},
+		}
+	}
+
+	t.Run("Error", func(t *testing.T) {
+		model := newModel()
+		ag, err := New(Config{Provider: model, Tools: []tool.Tool{newEchoTool()}, MaxMessageBytes: 200})
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		_, err = ag.Run(context.Background(), "hi")
+		var tooLarge *MessageTooLargeError
+		if !errors.As(err, &tooLarge) {
+			t.Fatalf("Run() error = %v, want *MessageTooLargeError", err)
+		}
+		if tooLarge.Role != types.RoleTool || tooLarge.Size != len(big) || tooLarge.Limit != 200 {
+			t.Errorf("error = %+v, want the tool result at 500 of 200 bytes", tooLarge)
+		}
+		if len(model.calls) != 1 {
+			t.Errorf("provider calls = %d, want 1", len(model.calls))
+		}
+	})
+
+	t.Run("Truncate", func(t *testing.T) {
+		model := newModel()
+		ag, err := New(Config{
+			Provider:        model,
+			Tools:           []tool.Tool{newEchoTool()},
+			MaxMessageBytes: 200,
+			OversizePolicy:  OversizeTruncate,
+		})
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		if _, err := ag.Run(context.Background(), "hi"); err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+
+		sent := model.calls[1][3]
+		if len(sent.Content) > 200 || !strings.Contains(sent.Content, "[truncated") {
+			t.Errorf("sent tool result is %d bytes (%q), want a truncated result within 200", len(sent.Content), sent.Content)
+		}
+		if stored := ag.History()[2].Content; stored != big {
+			t.Errorf("stored tool result was modified: %d bytes", len(stored))
+		}
+	})
+
+	t.Run("Truncate Rejects User Message", func(t *testing.T) {
+		ag, err := New(Config{Provider: newModel(), MaxMessageBytes: 200, OversizePolicy: OversizeTruncate})
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		var tooLarge *MessageTooLargeError
+		if _, err := ag.Run(context.Background(), big); !errors.As(err, &tooLarge) {
+			t.Errorf("Run() error = %v, want *MessageTooLargeError", err)
+		}
+	})
+}
@@ -0,0 +1,76 @@
+package agent
+
+import "giai/pkg/provider"
+
+// RunOption customizes a single turn.
+type RunOption func(*runConfig)
+
+type runConfig struct {
+	allowed map[string]bool // Nil allows every tool
+	denied  map[string]bool
+
+	toolMetadata map[string]any
+	chatOptions  []provider.Option
+}
+
+// WithAllowedTools restricts the turn to the named tools. Other tools are
+// neither advertised to the model nor executed if it calls them anyway.
+func WithAllowedTools(names []string) RunOption {
+	return func(rc *runConfig) {
+		rc.allowed = make(map[string]bool, len(names))
+		for _, n := range names {
+			rc.allowed[n] = true
+		}
+	}
+}
+
+// WithDeniedTools blocks the named tools for the turn. Deny takes precedence over allow.
+func WithDeniedTools(names []string) RunOption {
+	return func(rc *runConfig) {
+		if rc.denied == nil {
+			rc.denied = make(map[string]bool, len(names))
+		}
+		for _, n := range names {
+			rc.denied[n] = true
+		}
+	}
+}
+
+// WithToolMetadata merges md into the ToolContext.Metadata of every tool the
+// turn executes, e.g. {tool.MetadataApprovedCalls: []string{"call_1"}} for
+// calls whose IDs are known in advance. Calls planned during the run are
+// approved through Hooks.OnToolCalls instead.
+func WithToolMetadata(md map[string]any) RunOption {
+	return func(rc *runConfig) {
+		if rc.toolMetadata == nil {
+			rc.toolMetadata = make(map[string]any, len(md))
+		}
+		for k, v := range md {
+			rc.toolMetadata[k] = v
+		}
+	}
+}
+
+// WithChatOptions adds provider options for the turn, applied after
+// Config.Options, e.g. provider.WithServerTools for a single run.
+func WithChatOptions(opts ...provider.Option) RunOption {
+	return func(rc *runConfig) {
+		rc.chatOptions = append(rc.chatOptions, opts...)
+	}
+}
+
+func newRunConfig(opts []RunOption) *runConfig {
+	rc := &runConfig{}
+	for _, opt := range opts {
+		opt(rc)
+	}
+	return rc
+}
+
+// toolAllowed reports whether the tool may be advertised and executed in this turn.
+func (rc *runConfig) toolAllowed(name string) bool {
+	if rc.denied[name] {
+		return false
+	}
+	return rc.allowed == nil || rc.allowed[name]
+}
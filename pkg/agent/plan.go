@@ -0,0 +1,49 @@
+package agent
+
+import (
+	"encoding/json"
+
+	"giai/pkg/tool"
+	"giai/pkg/types"
+)
+
+// PlannedAction describes one tool call the model intends to make, for
+// presenting a turn's plan to a user before anything runs.
+type PlannedAction struct {
+	CallID           string
+	Tool             string
+	Description      string
+	Arguments        map[string]any // Nil when the model sent malformed JSON
+	RequiresApproval bool
+}
+
+// PendingActions converts the tool calls in resp into planned actions, in call
+// order. Descriptions and approval flags come from the agent's tools; calls to
+// unknown tools are included with neither. Hooks.OnToolCalls receives them
+// before each round of calls runs and returns the CallIDs a user approves.
+func (a *Agent) PendingActions(resp *types.ChatResponse) []PlannedAction {
+	if resp == nil {
+		return nil
+	}
+	actions := make([]PlannedAction, 0, len(resp.Message.ToolCalls))
+	for _, call := range resp.Message.ToolCalls {
+		action := PlannedAction{
+			CallID: call.ID,
+			Tool:   call.Function.Name,
+		}
+		if call.Function.Arguments != "" {
+			var args map[string]any
+			if json.Unmarshal([]byte(call.Function.Arguments), &args) == nil {
+				action.Arguments = args
+			}
+		}
+		if t, ok := a.toolIndex[call.Function.Name]; ok {
+			action.Description = t.Description()
+			if et, ok := t.(tool.EnhancedTool); ok {
+				action.RequiresApproval = et.RequiresApproval()
+			}
+		}
+		actions = append(actions, action)
+	}
+	return actions
+}
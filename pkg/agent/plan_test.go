@@ -0,0 +1,158 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"giai/pkg/provider"
+	"giai/pkg/tool"
+	"giai/pkg/types"
+)
+
+func TestPendingActions(t *testing.T) {
+	deploy := tool.NewFunc("deploy", "Deploy a service.", nil).WithApproval(true)
+	ag, err := New(Config{Provider: &scriptedModel{}, Tools: []tool.Tool{newEchoTool(), deploy}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	resp := &types.ChatResponse{Message: types.AssistantToolCall(
+		types.NewToolCall("call_1", "echo", `{"text":"hi"}`),
+		types.NewToolCall("call_2", "deploy", `{"input":"api"}`),
+		types.NewToolCall("call_3", "missing", `not json`),
+	)}
+
+	actions := ag.PendingActions(resp)
+	if len(actions) != 3 {
+		t.Fatalf("len(PendingActions()) = %d, want 3", len(actions))
+	}
+
+	want := []PlannedAction{
+		{CallID: "call_1", Tool: "echo", Description: "Echo the input text."},
+		{CallID: "call_2", Tool: "deploy", Description: "Deploy a service.", RequiresApproval: true},
+		{CallID: "call_3", Tool: "missing"},
+	}
+	for i, w := range want {
+		got := actions[i]
+		if got.CallID != w.CallID || got.Tool != w.Tool || got.Description != w.Description || got.RequiresApproval != w.RequiresApproval {
+			t.Errorf("action %d = %+v, want %+v", i, got, w)
+		}
+	}
+	if actions[0].Arguments["text"] != "hi" || actions[1].Arguments["input"] != "api" {
+		t.Errorf("Arguments = %v, %v, want parsed JSON", actions[0].Arguments, actions[1].Arguments)
+	}
+	if actions[2].Arguments != nil {
+		t.Errorf("Arguments = %v, want nil for malformed JSON", actions[2].Arguments)
+	}
+}
+
+func TestRun_WithToolMetadataApproves(t *testing.T) {
+	ran := false
+	deploy := tool.NewFunc("deploy", "Deploy a service.", func(ctx context.Context, input map[string]any, tc *tool.ToolContext) (any, error) {
+		ran = true
+		return "deployed", nil
+	}).WithApproval(true)
+
+	model := &scriptedModel{
+		responses: []*types.ChatResponse{
+			{Message: types.AssistantToolCall(types.NewToolCall("call_1", "deploy", `{"input":"api"}`))},
+			{Message: types.AssistantMessage("done")},
+		},
+	}
+	ag, err := New(Config{Provider: model, Tools: []tool.Tool{deploy}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, err := ag.Run(context.Background(), "ship it", WithToolMetadata(map[string]any{tool.MetadataApprovedCalls: []string{"call_1"}})); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if !ran {
+		t.Errorf("approved tool did not run; result = %q", ag.History()[2].Content)
+	}
+}
+
+func TestRun_ApprovalIsPerCall(t *testing.T) {
+	runs := 0
+	deploy := tool.NewFunc("deploy", "Deploy a service.", func(ctx context.Context, input map[string]any, tc *tool.ToolContext) (any, error) {
+		runs++
+		return "deployed", nil
+	}).WithApproval(true).WithNoRetry()
+
+	model := &scriptedModel{
+		responses: []*types.ChatResponse{
+			{Message: types.AssistantToolCall(types.NewToolCall("call_1", "deploy", `{"input":"api"}`))},
+			{Message: types.AssistantToolCall(types.NewToolCall("call_2", "deploy", `{"input":"db"}`))},
+			{Message: types.AssistantMessage("done")},
+		},
+	}
+	ag, err := New(Config{Provider: model, Tools: []tool.Tool{deploy}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, err := ag.Run(context.Background(), "ship api", WithToolMetadata(map[string]any{tool.MetadataApprovedCalls: []string{"call_1"}})); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if runs != 1 {
+		t.Errorf("deploy ran %d times, want only the approved call", runs)
+	}
+	if res := ag.History()[4]; res.Metadata["error"] != true {
+		t.Errorf("unapproved call result = %+v, want an error", res)
+	}
+}
+
+func TestRun_OnToolCallsApproves(t *testing.T) {
+	runs := 0
+	deploy := tool.NewFunc("deploy", "Deploy a service.", func(ctx context.Context, input map[string]any, tc *tool.ToolContext) (any, error) {
+		runs++
+		return "deployed", nil
+	}).WithApproval(true).WithNoRetry()
+	newModel := func() provider.ChatModel {
+		// Emulated tool calls get IDs minted during the run, unknown beforehand.
+		return provider.WithToolEmulation(&scriptedModel{responses: []*types.ChatResponse{
+			{Message: types.AssistantMessage(`<tool_call>{"name": "deploy", "arguments": {"input": "api"}}</tool_call>`)},
+			{Message: types.AssistantMessage("done")},
+		}})
+	}
+
+	var seen []PlannedAction
+	approve := func(ctx context.Context, actions []PlannedAction) ([]string, error) {
+		seen = actions
+		var ids []string
+		for _, a := range actions {
+			ids = append(ids, a.CallID)
+		}
+		return ids, nil
+	}
+	ag, err := New(Config{Provider: newModel(), Tools: []tool.Tool{deploy}, Hooks: Hooks{OnToolCalls: approve}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, err := ag.Run(context.Background(), "ship it"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(seen) != 1 || seen[0].CallID == "" || !seen[0].RequiresApproval {
+		t.Fatalf("OnToolCalls saw %+v, want the deploy call with its minted ID", seen)
+	}
+	if runs != 1 {
+		t.Errorf("deploy ran %d times, want 1 after approval", runs)
+	}
+
+	// A rejecting hook ends the turn without running anything.
+	reject := errors.New("user declined")
+	ag, err = New(Config{Provider: newModel(), Tools: []tool.Tool{deploy}, Hooks: Hooks{
+		OnToolCalls: func(ctx context.Context, actions []PlannedAction) ([]string, error) { return nil, reject },
+	}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, err := ag.Run(context.Background(), "ship it"); !errors.Is(err, reject) {
+		t.Errorf("Run() error = %v, want the hook's error", err)
+	}
+	if runs != 1 {
+		t.Errorf("deploy ran %d times, want no run after rejection", runs)
+	}
+	if last := ag.History()[len(ag.History())-1]; last.Role != types.RoleTool || last.Metadata["error"] != true {
+		t.Errorf("last message = %+v, want an error result for the rejected call", last)
+	}
+}
@@ -0,0 +1,32 @@
+package agent
+
+import (
+	"time"
+
+	"giai/pkg/provider"
+	"giai/pkg/types"
+)
+
+// TurnStats summarizes the cost of one agent turn.
+type TurnStats struct {
+	Usage     types.Usage   // Token usage summed over all LLM calls
+	Cost      float64       // Estimated USD cost; excludes calls whose model has no known pricing
+	Latency   time.Duration // Wall-clock duration of the turn
+	LLMCalls  int           // Number of provider requests
+	ToolCalls int           // Number of tool executions requested by the model
+}
+
+// record accounts for one provider response. The model reported by the
+// provider is preferred for pricing; the requested model is the fallback.
+func (s *TurnStats) record(resp *types.ChatResponse, opts []provider.Option) {
+	s.LLMCalls++
+	s.Usage = s.Usage.Add(resp.Usage)
+
+	model := resp.Model
+	if model == "" {
+		model = resolveOptions(opts).Model
+	}
+	if cost, ok := provider.EstimateCost(model, resp.Usage); ok {
+		s.Cost += cost
+	}
+}
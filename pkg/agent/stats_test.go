@@ -0,0 +1,59 @@
+package agent
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"giai/pkg/tool"
+	"giai/pkg/types"
+)
+
+func TestRunWithStats(t *testing.T) {
+	model := &scriptedModel{
+		responses: []*types.ChatResponse{
+			{
+				Message: types.AssistantToolCall(
+					types.NewToolCall("call_1", "echo", `{"text":"a"}`),
+					types.NewToolCall("call_2", "echo", `{"text":"b"}`),
+				),
+				Usage: types.Usage{PromptTokens: 1000, CompletionTokens: 100, TotalTokens: 1100},
+				Model: "gpt-4o",
+			},
+			{
+				Message: types.AssistantMessage("done"),
+				Usage:   types.Usage{PromptTokens: 2000, CompletionTokens: 200, TotalTokens: 2200},
+				Model:   "gpt-4o",
+			},
+		},
+	}
+
+	ag, err := New(Config{Provider: model, Tools: []tool.Tool{newEchoTool()}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	out, stats, err := ag.RunWithStats(context.Background(), "hi")
+	if err != nil {
+		t.Fatalf("RunWithStats() error = %v", err)
+	}
+	if out != "done" {
+		t.Errorf("RunWithStats() = %q, want %q", out, "done")
+	}
+	if stats.LLMCalls != 2 {
+		t.Errorf("LLMCalls = %d, want 2", stats.LLMCalls)
+	}
+	if stats.ToolCalls != 2 {
+		t.Errorf("ToolCalls = %d, want 2", stats.ToolCalls)
+	}
+	if stats.Usage.TotalTokens != 3300 {
+		t.Errorf("Usage.TotalTokens = %d, want 3300", stats.Usage.TotalTokens)
+	}
+	// gpt-4o: $2.50/M input, $10/M output.
+	if want := 3000*2.50/1e6 + 300*10.0/1e6; math.Abs(stats.Cost-want) > 1e-9 {
+		t.Errorf("Cost = %v, want %v", stats.Cost, want)
+	}
+	if stats.Latency <= 0 {
+		t.Errorf("Latency = %v, want > 0", stats.Latency)
+	}
+}
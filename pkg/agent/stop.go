@@ -0,0 +1,47 @@
+package agent
+
+import (
+	"errors"
+
+	"giai/pkg/types"
+)
+
+// ErrStopped is returned when Config.StopCondition ends a turn.
+var ErrStopped = errors.New("agent: stopped by StopCondition")
+
+// LoopState describes the progress of a turn for Config.StopCondition.
+type LoopState struct {
+	// Iteration counts the model round trips of the turn so far, from 1.
+	Iteration int
+	// Messages is the stored history, ending with the latest tool results.
+	Messages []types.Message
+	// ToolCalls lists the tool calls requested during the turn, in order.
+	ToolCalls []types.ToolCall
+}
+
+// DetectToolLoop is a StopCondition that trips once the turn contains two
+// identical tool calls: same tool, same arguments. A model asking for the
+// same thing twice rarely learns anything new from the answer.
+func DetectToolLoop(state LoopState) bool {
+	seen := make(map[string]bool, len(state.ToolCalls))
+	for _, call := range state.ToolCalls {
+		key := call.Function.Name + "\x00" + canonicalArguments(call.Function.Arguments)
+		if seen[key] {
+			return true
+		}
+		seen[key] = true
+	}
+	return false
+}
+
+// stopRequested evaluates StopCondition after a round of tool calls.
+func (a *Agent) stopRequested(iteration int, calls []types.ToolCall) bool {
+	if a.stopCondition == nil {
+		return false
+	}
+	return a.stopCondition(LoopState{
+		Iteration: iteration + 1,
+		Messages:  a.memory.History(),
+		ToolCalls: calls,
+	})
+}
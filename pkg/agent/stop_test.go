@@ -0,0 +1,91 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"giai/pkg/tool"
+	"giai/pkg/types"
+)
+
+func TestRun_StopConditionDetectsToolLoop(t *testing.T) {
+	model := alwaysCalls("echo", 5)
+	a, err := New(Config{
+		Provider:      model,
+		Tools:         []tool.Tool{newEchoTool()},
+		StopCondition: DetectToolLoop,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	_, err = a.Run(context.Background(), "hi")
+	if !errors.Is(err, ErrStopped) {
+		t.Fatalf("Run() error = %v, want ErrStopped", err)
+	}
+	if len(model.calls) != 2 {
+		t.Errorf("provider called %d times, want 2: the repeated call trips the detector", len(model.calls))
+	}
+	if last := a.History()[len(a.History())-1]; last.Role != types.RoleTool {
+		t.Errorf("last message role = %q, want the repeated call's tool result stored", last.Role)
+	}
+}
+
+func TestRun_StopConditionSeesLoopState(t *testing.T) {
+	var states []LoopState
+	a, err := New(Config{
+		Provider: alwaysCalls("echo", 5),
+		Tools:    []tool.Tool{newEchoTool()},
+		StopCondition: func(state LoopState) bool {
+			states = append(states, state)
+			return state.Iteration == 3
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if _, err := a.Run(context.Background(), "hi"); !errors.Is(err, ErrStopped) {
+		t.Fatalf("Run() error = %v, want ErrStopped", err)
+	}
+	if len(states) != 3 {
+		t.Fatalf("StopCondition called %d times, want 3", len(states))
+	}
+	if got := len(states[2].ToolCalls); got != 3 {
+		t.Errorf("len(ToolCalls) = %d, want 3", got)
+	}
+	// user, then an assistant call and a tool result per iteration
+	if got := len(states[2].Messages); got != 7 {
+		t.Errorf("len(Messages) = %d, want 7", got)
+	}
+}
+
+func TestDetectToolLoop(t *testing.T) {
+	tests := []struct {
+		name  string
+		calls []types.ToolCall
+		want  bool
+	}{
+		{"no calls", nil, false},
+		{"different arguments", []types.ToolCall{
+			types.NewToolCall("1", "read", `{"path":"a"}`),
+			types.NewToolCall("2", "read", `{"path":"b"}`),
+		}, false},
+		{"different tools", []types.ToolCall{
+			types.NewToolCall("1", "read", `{"path":"a"}`),
+			types.NewToolCall("2", "stat", `{"path":"a"}`),
+		}, false},
+		{"repeat with reordered keys", []types.ToolCall{
+			types.NewToolCall("1", "grep", `{"pattern":"x","path":"."}`),
+			types.NewToolCall("2", "grep", `{"path": ".", "pattern": "x"}`),
+		}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectToolLoop(LoopState{ToolCalls: tt.calls}); got != tt.want {
+				t.Errorf("DetectToolLoop() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package agent
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// StreamTo runs a streamed turn and writes each content delta to w as it arrives,
+// flushing after every write when w implements http.Flusher.
+// If a write fails the turn is cancelled and the write error is returned.
+func StreamTo(ctx context.Context, a *Agent, input string, w io.Writer) (string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	flusher, _ := w.(http.Flusher)
+	var writeErr error
+	out, err := a.RunStream(ctx, input, func(delta string) {
+		if writeErr != nil {
+			return
+		}
+		if _, writeErr = io.WriteString(w, delta); writeErr != nil {
+			cancel()
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	})
+	if writeErr != nil {
+		return "", writeErr
+	}
+	return out, err
+}
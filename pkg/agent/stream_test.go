@@ -0,0 +1,236 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"giai/pkg/provider"
+	"giai/pkg/tool"
+	"giai/pkg/types"
+)
+
+type flushBuffer struct {
+	bytes.Buffer
+	flushes int
+}
+
+func (f *flushBuffer) Flush() { f.flushes++ }
+
+func TestStreamTo(t *testing.T) {
+	chunks := []provider.ChatChunk{{Content: "Hello, "}, {Content: "world"}, {FinishReason: "stop"}}
+
+	t.Run("Buffer", func(t *testing.T) {
+		ag, err := New(Config{Provider: &scriptedModel{streams: [][]provider.ChatChunk{chunks}}})
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		var buf bytes.Buffer
+		out, err := StreamTo(context.Background(), ag, "hi", &buf)
+		if err != nil {
+			t.Fatalf("StreamTo() error = %v", err)
+		}
+		if out != "Hello, world" || buf.String() != "Hello, world" {
+			t.Errorf("StreamTo() = %q, buffer %q, want %q", out, buf.String(), "Hello, world")
+		}
+	})
+
+	t.Run("Flusher", func(t *testing.T) {
+		ag, err := New(Config{Provider: &scriptedModel{streams: [][]provider.ChatChunk{chunks}}})
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		var fb flushBuffer
+		if _, err := StreamTo(context.Background(), ag, "hi", &fb); err != nil {
+			t.Fatalf("StreamTo() error = %v", err)
+		}
+		if fb.flushes != 2 {
+			t.Errorf("flushes = %d, want 2", fb.flushes)
+		}
+	})
+}
+
+func TestRunStream_TrimsStopSequences(t *testing.T) {
+	model := &scriptedModel{streams: [][]provider.ChatChunk{{
+		{Content: "42<|e"},
+		{Content: "nd|>"},
+		{FinishReason: "stop"},
+	}}}
+	ag, err := New(Config{
+		Provider: model,
+		Options:  []provider.Option{func(o *provider.ChatOptions) { o.Stop = []string{"<|end|>"} }},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	var deltas string
+	out, err := ag.RunStream(context.Background(), "hi", func(d string) { deltas += d })
+	if err != nil {
+		t.Fatalf("RunStream() error = %v", err)
+	}
+	if out != "42" || deltas != "42" {
+		t.Errorf("RunStream() = %q, deltas %q, want %q", out, deltas, "42")
+	}
+}
+
+func TestRunStream_ExecutesTools(t *testing.T) {
+	call := types.NewToolCall("call_1", "echo", `{"te`)
+	rest := types.NewToolCall("", "", `xt":"pong"}`)
+	model := &scriptedModel{streams: [][]provider.ChatChunk{
+		{{Content: "Checking. "}, {ToolCall: &call}, {ToolCall: &rest}, {FinishReason: "tool_calls"}},
+		{{Content: "Got "}, {Content: "pong"}, {FinishReason: "stop"}},
+	}}
+	ag, err := New(Config{Provider: model, Tools: []tool.Tool{newEchoTool()}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	var deltas string
+	out, err := ag.RunStream(context.Background(), "ping", func(d string) { deltas += d })
+	if err != nil {
+		t.Fatalf("RunStream() error = %v", err)
+	}
+	if out != "Got pong" || deltas != "Checking. Got pong" {
+		t.Errorf("RunStream() = %q, deltas %q", out, deltas)
+	}
+
+	history := ag.History()
+	if len(history) != 4 {
+		t.Fatalf("len(History()) = %d, want 4", len(history))
+	}
+	if calls := history[1].ToolCalls; len(calls) != 1 || calls[0].Function.Arguments != `{"text":"pong"}` {
+		t.Errorf("assistant tool calls = %+v, want one assembled echo call", calls)
+	}
+	if res := history[2]; res.Role != types.RoleTool || res.Content != "pong" {
+		t.Errorf("tool result = %+v, want pong", res)
+	}
+}
+
+func TestRunStream_ParallelToolCallFragments(t *testing.T) {
+	fragment := func(index int, id, name, args string) provider.ChatChunk {
+		tc := types.NewToolCall(id, name, args)
+		tc.Index = index
+		return provider.ChatChunk{ToolCall: &tc}
+	}
+	model := &scriptedModel{streams: [][]provider.ChatChunk{
+		{
+			fragment(0, "call_1", "echo", `{"text":`),
+			fragment(1, "call_2", "echo", `{"text":`),
+			fragment(1, "", "", `"two"}`),
+			fragment(0, "", "", `"one"}`),
+			{FinishReason: "tool_calls"},
+		},
+		{{Content: "done"}, {FinishReason: "stop"}},
+	}}
+	ag, err := New(Config{Provider: model, Tools: []tool.Tool{newEchoTool()}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if _, err := ag.RunStream(context.Background(), "go", nil); err != nil {
+		t.Fatalf("RunStream() error = %v", err)
+	}
+	history := ag.History()
+	if len(history) != 5 {
+		t.Fatalf("len(History()) = %d, want 5", len(history))
+	}
+	for i, want := range []string{"one", "two"} {
+		if res := history[2+i]; res.ToolCallID != fmt.Sprintf("call_%d", i+1) || res.Content != want {
+			t.Errorf("tool result %d = %+v, want %q for call_%d", i, res, want, i+1)
+		}
+	}
+}
+
+func TestRunStream_ToolCallFragmentsSkipTextCallback(t *testing.T) {
+	call := types.NewToolCall("call_1", "echo", `{"text":`)
+	rest := types.NewToolCall("", "", `"pong"}`)
+	model := &scriptedModel{streams: [][]provider.ChatChunk{
+		{
+			{Content: "Let me "},
+			{ToolCall: &call},
+			{Content: "check."},
+			{Content: `{"text":`, ToolCall: &rest}, // Argument text leaked into Content
+			{FinishReason: "tool_calls"},
+		},
+		{{Content: "pong"}, {FinishReason: "stop"}},
+	}}
+
+	var fragments []types.ToolCall
+	ag, err := New(Config{
+		Provider: model,
+		Tools:    []tool.Tool{newEchoTool()},
+		Hooks:    Hooks{OnToolCallDelta: func(tc types.ToolCall) { fragments = append(fragments, tc) }},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	var deltas []string
+	if _, err := ag.RunStream(context.Background(), "ping", func(d string) { deltas = append(deltas, d) }); err != nil {
+		t.Fatalf("RunStream() error = %v", err)
+	}
+	if want := []string{"Let me ", "check.", "pong"}; !reflect.DeepEqual(deltas, want) {
+		t.Errorf("deltas = %q, want %q", deltas, want)
+	}
+	if len(fragments) != 2 || fragments[0].ID != "call_1" || fragments[1].Function.Arguments != `"pong"}` {
+		t.Errorf("tool call fragments = %+v, want the two streamed fragments", fragments)
+	}
+}
+
+// noStreamModel rejects every Stream call the way reasoning-only endpoints do.
+type noStreamModel struct {
+	scriptedModel
+	streamCalls int
+}
+
+func (m *noStreamModel) Stream(ctx context.Context, messages []types.Message, opts ...provider.Option) (<-chan provider.ChatChunk, error) {
+	m.streamCalls++
+	return nil, provider.NewError("fake", 400, "unsupported_value",
+		"Unsupported value: 'stream' does not support true with this model.", errors.New("bad request"))
+}
+
+func TestRunStream_AutoFallbackNonStreaming(t *testing.T) {
+	t.Run("Enabled", func(t *testing.T) {
+		model := &noStreamModel{scriptedModel: scriptedModel{responses: []*types.ChatResponse{
+			{Message: types.Message{Role: types.RoleAssistant, Content: "full answer"}},
+			{Message: types.Message{Role: types.RoleAssistant, Content: "second"}},
+		}}}
+		ag, err := New(Config{Provider: model, AutoFallbackNonStreaming: true})
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+
+		var deltas []string
+		out, err := ag.RunStream(context.Background(), "hi", func(d string) { deltas = append(deltas, d) })
+		if err != nil {
+			t.Fatalf("RunStream() error = %v", err)
+		}
+		if out != "full answer" || len(deltas) != 1 || deltas[0] != "full answer" {
+			t.Errorf("RunStream() = %q, deltas %q, want one full-answer delta", out, deltas)
+		}
+
+		if _, err := ag.RunStream(context.Background(), "again", nil); err != nil {
+			t.Fatalf("second RunStream() error = %v", err)
+		}
+		if model.streamCalls != 1 {
+			t.Errorf("Stream calls = %d, want 1 (later turns skip streaming)", model.streamCalls)
+		}
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		model := &noStreamModel{scriptedModel: scriptedModel{responses: []*types.ChatResponse{
+			{Message: types.Message{Role: types.RoleAssistant, Content: "full answer"}},
+		}}}
+		ag, err := New(Config{Provider: model})
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		if _, err := ag.RunStream(context.Background(), "hi", nil); !provider.IsStreamingUnsupported(err) {
+			t.Errorf("RunStream() error = %v, want the streaming error", err)
+		}
+	})
+}
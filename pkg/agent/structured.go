@@ -0,0 +1,76 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"giai/pkg/schema"
+	"giai/pkg/types"
+)
+
+// ResponseSchemaError reports a final answer that still violated ResponseSchema
+// after the allowed correction attempts.
+type ResponseSchemaError struct {
+	Content string // The last answer
+	Errors  []schema.Error
+}
+
+func (e *ResponseSchemaError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return "agent: response does not match the response schema: " + strings.Join(msgs, "; ")
+}
+
+// finishAnswer stores a final answer and, when ResponseSchema is set, checks
+// it. It reports whether the turn is over; if not, a request to fix the
+// violations has been stored and the model should be asked again.
+// *retries counts the corrections asked for so far in this turn.
+func (a *Agent) finishAnswer(msg types.Message, retries *int) (bool, error) {
+	a.memory.Add(msg)
+	if a.responseSchema == nil {
+		return true, nil
+	}
+	errs := responseViolations(a.responseSchema, msg.Content)
+	if len(errs) == 0 {
+		return true, nil
+	}
+	if *retries >= a.responseSchemaRetries {
+		return true, &ResponseSchemaError{Content: msg.Content, Errors: errs}
+	}
+	*retries++
+
+	var sb strings.Builder
+	sb.WriteString("Your response does not match the required JSON schema:\n")
+	for _, err := range errs {
+		fmt.Fprintf(&sb, "- %s\n", err.Error())
+	}
+	sb.WriteString("Reply again with only the corrected JSON.")
+	a.memory.Add(types.UserMessage(sb.String()))
+	return false, nil
+}
+
+// responseViolations parses content as JSON, tolerating a Markdown code fence
+// around it, and validates it against s.
+func responseViolations(s map[string]any, content string) []schema.Error {
+	var value any
+	if err := json.Unmarshal([]byte(stripCodeFence(content)), &value); err != nil {
+		return []schema.Error{{Message: fmt.Sprintf("response is not valid JSON: %v", err)}}
+	}
+	return schema.Validate(s, value)
+}
+
+// stripCodeFence removes a surrounding ``` or ```json fence.
+func stripCodeFence(s string) string {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "```") || !strings.HasSuffix(s, "```") || len(s) < 6 {
+		return s
+	}
+	s = strings.TrimSuffix(s[3:], "```")
+	if i := strings.IndexByte(s, '\n'); i >= 0 && !strings.ContainsAny(s[:i], "{[\"") {
+		s = s[i+1:] // Drop the language tag line
+	}
+	return strings.TrimSpace(s)
+}
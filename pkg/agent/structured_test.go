@@ -0,0 +1,82 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"giai/pkg/types"
+)
+
+var personSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"name": map[string]any{"type": "string"},
+		"age":  map[string]any{"type": "integer", "minimum": 0},
+	},
+	"required": []string{"name", "age"},
+}
+
+func TestRun_ResponseSchemaReprompts(t *testing.T) {
+	model := &scriptedModel{responses: []*types.ChatResponse{
+		{Message: types.AssistantMessage(`{"name": "Ada", "age": "thirty-six"}`)},
+		{Message: types.AssistantMessage("```json\n{\"name\": \"Ada\", \"age\": 36}\n```")},
+	}}
+	ag, err := New(Config{Provider: model, ResponseSchema: personSchema})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	out, err := ag.Run(context.Background(), "Who wrote the first program?")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if !strings.Contains(out, `"age": 36`) {
+		t.Errorf("Run() = %q, want the corrected answer", out)
+	}
+	if len(model.calls) != 2 {
+		t.Fatalf("provider calls = %d, want 2", len(model.calls))
+	}
+	fix := model.calls[1][len(model.calls[1])-1]
+	if fix.Role != types.RoleUser || !strings.Contains(fix.Content, "/age: expected integer, got string") {
+		t.Errorf("correction request = %+v, want the violation listed", fix)
+	}
+}
+
+func TestRun_ResponseSchemaGivesUp(t *testing.T) {
+	model := &scriptedModel{responses: []*types.ChatResponse{
+		{Message: types.AssistantMessage("not json")},
+		{Message: types.AssistantMessage(`{"name": "Ada"}`)},
+	}}
+	ag, err := New(Config{Provider: model, ResponseSchema: personSchema, ResponseSchemaRetries: 1})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	_, err = ag.Run(context.Background(), "Who?")
+	var schemaErr *ResponseSchemaError
+	if !errors.As(err, &schemaErr) {
+		t.Fatalf("Run() error = %v, want *ResponseSchemaError", err)
+	}
+	if len(schemaErr.Errors) != 1 || !strings.Contains(schemaErr.Errors[0].Message, `missing required property "age"`) {
+		t.Errorf("Errors = %v, want the missing age", schemaErr.Errors)
+	}
+}
+
+func TestStripCodeFence(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: `{"a":1}`, want: `{"a":1}`},
+		{in: "```json\n{\"a\":1}\n```", want: `{"a":1}`},
+		{in: "```\n[1]\n```", want: `[1]`},
+		{in: "```{\"a\":1}```", want: `{"a":1}`},
+	}
+	for _, tt := range tests {
+		if got := stripCodeFence(tt.in); got != tt.want {
+			t.Errorf("stripCodeFence(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
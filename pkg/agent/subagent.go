@@ -0,0 +1,71 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"giai/pkg/memory"
+	"giai/pkg/tool"
+)
+
+// MetadataAgentDepth is the ToolContext.Metadata key holding how deeply a tool
+// call is nested in sub-agents: absent or 0 in a top-level agent, 1 inside an
+// agent called through AsTool, and so on.
+const MetadataAgentDepth = "agent_depth"
+
+// MaxDepthError reports a sub-agent call that would nest deeper than the
+// sub-agent's MaxDepth. It aborts the turn of every agent in the call chain.
+type MaxDepthError struct {
+	Tool     string // Name the sub-agent was exposed under
+	Depth    int    // Depth the call would have run at
+	MaxDepth int
+}
+
+func (e *MaxDepthError) Error() string {
+	return fmt.Sprintf("agent: sub-agent %q would run at depth %d, exceeding its limit of %d", e.Tool, e.Depth, e.MaxDepth)
+}
+
+// AsTool exposes the agent as a tool other agents can call with an "input"
+// task. Each call runs as a fresh turn on a copy of the agent with empty
+// memory, so calls are independent and may safely re-enter the agent. The
+// nesting depth is carried in Metadata[MetadataAgentDepth] and checked against
+// MaxDepth, so agents calling each other cannot recurse forever.
+// Failed calls are not retried, since a retry would repeat the whole turn; use
+// the returned Func's options, e.g. WithTimeout, to tune it further.
+func (a *Agent) AsTool(name, description string) *tool.Func {
+	return tool.NewFunc(name, description, func(ctx context.Context, input map[string]any, tc *tool.ToolContext) (any, error) {
+		depth := agentDepth(tc) + 1
+		if depth > a.maxDepth {
+			return nil, &MaxDepthError{Tool: name, Depth: depth, MaxDepth: a.maxDepth}
+		}
+		task, ok := input["input"].(string)
+		if !ok {
+			return nil, fmt.Errorf("input must be a string")
+		}
+
+		cfg := a.cfg
+		cfg.Memory = memory.NewInMemory()
+		// The caller holds one of its executor's slots while this runs; sharing
+		// the executor would let nested calls exhaust them and deadlock.
+		cfg.Executor = tool.NewExecutor(a.executor.Config())
+		sub, err := New(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return sub.Run(ctx, task, WithToolMetadata(map[string]any{MetadataAgentDepth: depth}))
+	}).WithNoRetry()
+}
+
+// agentDepth reads the sub-agent depth from a tool context.
+func agentDepth(tc *tool.ToolContext) int {
+	if tc == nil {
+		return 0
+	}
+	switch d := tc.Metadata[MetadataAgentDepth].(type) {
+	case int:
+		return d
+	case float64:
+		return int(d)
+	}
+	return 0
+}
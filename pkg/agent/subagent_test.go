@@ -0,0 +1,113 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"giai/pkg/tool"
+	"giai/pkg/types"
+)
+
+// alwaysCalls returns a model that answers every request with a call to name.
+func alwaysCalls(name string, n int) *scriptedModel {
+	m := &scriptedModel{}
+	for i := 0; i < n; i++ {
+		m.responses = append(m.responses, &types.ChatResponse{
+			Message: types.AssistantToolCall(types.NewToolCall("call_1", name, `{"input":"go on"}`)),
+		})
+	}
+	return m
+}
+
+func TestAsTool(t *testing.T) {
+	helper, err := New(Config{Provider: &scriptedModel{responses: []*types.ChatResponse{
+		{Message: types.AssistantMessage("4")},
+	}}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	got, err := helper.AsTool("math", "Does math.").Execute(context.Background(), map[string]any{"input": "2+2"}, tool.NewToolContext())
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if got != "4" {
+		t.Errorf("Execute() = %v, want 4", got)
+	}
+	if len(helper.History()) != 0 {
+		t.Errorf("len(History()) = %d, want 0: calls run on a copy", len(helper.History()))
+	}
+}
+
+func TestAsTool_OwnExecutor(t *testing.T) {
+	// One slot: the caller holds it while the sub-agent runs its own tool call.
+	exec := tool.NewExecutor(tool.ExecutorConfig{MaxConcurrency: 1})
+	helperModel := &scriptedModel{responses: []*types.ChatResponse{
+		{Message: types.AssistantToolCall(types.NewToolCall("call_1", "echo", `{"text":"4"}`))},
+		{Message: types.AssistantMessage("4")},
+	}}
+	helper, err := New(Config{Provider: helperModel, Tools: []tool.Tool{newEchoTool()}, Executor: exec})
+	if err != nil {
+		t.Fatalf("New(helper) error = %v", err)
+	}
+	parent, err := New(Config{
+		Provider: &scriptedModel{responses: []*types.ChatResponse{
+			{Message: types.AssistantToolCall(types.NewToolCall("call_1", "math", `{"input":"2+2"}`))},
+			{Message: types.AssistantMessage("It is 4.")},
+		}},
+		Tools:    []tool.Tool{helper.AsTool("math", "Does math.")},
+		Executor: exec,
+	})
+	if err != nil {
+		t.Fatalf("New(parent) error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := parent.Run(ctx, "what is 2+2?"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(helperModel.calls) != 2 {
+		t.Fatalf("sub-agent made %d provider calls, want 2", len(helperModel.calls))
+	}
+	sent := helperModel.calls[1]
+	if result := sent[len(sent)-1]; result.Role != types.RoleTool || result.Content != "4" {
+		t.Errorf("sub-agent tool result = %+v, want the echo output without waiting on the caller's executor", result)
+	}
+}
+
+func TestAsTool_MutualRecursionHitsMaxDepth(t *testing.T) {
+	var a, b *Agent
+	// Forwarders break the construction cycle: each agent's tool calls the other.
+	askA := tool.NewFunc("ask_a", "Ask agent A.", func(ctx context.Context, input map[string]any, tc *tool.ToolContext) (any, error) {
+		return a.AsTool("ask_a", "Ask agent A.").Execute(ctx, input, tc)
+	}).WithNoRetry()
+	askB := tool.NewFunc("ask_b", "Ask agent B.", func(ctx context.Context, input map[string]any, tc *tool.ToolContext) (any, error) {
+		return b.AsTool("ask_b", "Ask agent B.").Execute(ctx, input, tc)
+	}).WithNoRetry()
+
+	var err error
+	a, err = New(Config{Provider: alwaysCalls("ask_b", 20), Tools: []tool.Tool{askB}, MaxDepth: 3})
+	if err != nil {
+		t.Fatalf("New(a) error = %v", err)
+	}
+	b, err = New(Config{Provider: alwaysCalls("ask_a", 20), Tools: []tool.Tool{askA}, MaxDepth: 3})
+	if err != nil {
+		t.Fatalf("New(b) error = %v", err)
+	}
+
+	_, err = a.Run(context.Background(), "start")
+	var depthErr *MaxDepthError
+	if !errors.As(err, &depthErr) {
+		t.Fatalf("Run() error = %v, want *MaxDepthError", err)
+	}
+	if depthErr.Tool != "ask_a" || depthErr.Depth != 4 || depthErr.MaxDepth != 3 {
+		t.Errorf("MaxDepthError = %+v, want ask_a at depth 4 of 3", depthErr)
+	}
+	// The failing result is still recorded before the turn aborts.
+	history := a.History()
+	if last := history[len(history)-1]; last.Role != types.RoleTool {
+		t.Errorf("last message = %+v, want the tool result", last)
+	}
+}
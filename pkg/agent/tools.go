@@ -0,0 +1,416 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"giai/pkg/provider"
+	"giai/pkg/tool"
+	"giai/pkg/types"
+)
+
+// normalizeToolCallIDs ensures every tool call carries a unique, non-empty ID,
+// minting one when the provider omitted it or reused an earlier one.
+// Tool result messages are built from the normalized calls, so their
+// tool_call_id always matches an entry in the assistant message.
+func normalizeToolCallIDs(calls []types.ToolCall) []types.ToolCall {
+	out := make([]types.ToolCall, len(calls))
+	seen := make(map[string]bool, len(calls))
+	for i, call := range calls {
+		if call.ID == "" || seen[call.ID] {
+			call.ID = types.NewToolCallID()
+		}
+		if call.Type == "" {
+			call.Type = "function"
+		}
+		seen[call.ID] = true
+		out[i] = call
+	}
+	return out
+}
+
+// separateServerToolCalls moves calls to the provider built-in tools named in
+// serverTools (see provider.WithServerTools) out of msg.ToolCalls into
+// Metadata["server_tool_calls"]. The provider already ran them, so they are
+// neither executed locally nor answered with tool results. Calls of a
+// non-function type are treated as built-in too.
+func separateServerToolCalls(msg types.Message, serverTools []string) types.Message {
+	var local, server []types.ToolCall
+	for _, call := range msg.ToolCalls {
+		if (call.Type != "" && call.Type != "function") || slices.Contains(serverTools, call.Function.Name) {
+			server = append(server, call)
+		} else {
+			local = append(local, call)
+		}
+	}
+	if len(server) == 0 {
+		return msg
+	}
+	metadata := make(map[string]any, len(msg.Metadata)+1)
+	for k, v := range msg.Metadata {
+		metadata[k] = v
+	}
+	metadata["server_tool_calls"] = server
+	msg.Metadata = metadata
+	msg.ToolCalls = local
+	return msg
+}
+
+// runToolCalls records an assistant message carrying tool calls, executes the
+// calls and records their results. A non-empty requestID, the ID of the
+// provider response that asked for the calls, is stamped on each tool's
+// context and result as Metadata["request_id"]. It reports whether every call
+// failed. A returned error aborts the turn; every result has been recorded by then.
+func (a *Agent) runToolCalls(ctx context.Context, msg types.Message, requestID string, rc *runConfig) (bool, error) {
+	// Keep any text sent alongside the tool calls; it is part of the transcript.
+	msg.ToolCalls = normalizeToolCallIDs(msg.ToolCalls)
+	a.memory.Add(msg)
+	a.hooks.interimText(msg.Content)
+
+	results, err := a.executeToolCalls(ctx, msg.ToolCalls, requestID, rc)
+	allFailed := len(results) > 0
+	for j, result := range results {
+		if result.Metadata["error"] != true {
+			allFailed = false
+		}
+		// Transform first, so the summarizer never sees what the transform redacts.
+		result = a.transformOutbound(result)
+		if result.Metadata["error"] != true {
+			result = a.summarizeLargeResult(ctx, msg.ToolCalls[j], result)
+		}
+		result = a.dedupToolResult(msg.ToolCalls[j], result)
+		if requestID != "" {
+			if result.Metadata == nil {
+				result.Metadata = map[string]any{}
+			}
+			result.Metadata[tool.MetadataRequestID] = requestID
+		}
+		a.memory.Add(result)
+		a.hooks.toolResult(msg.ToolCalls[j], result)
+	}
+	return allFailed, err
+}
+
+// countToolFailures tracks rounds of tool calls that failed entirely and
+// returns a *ToolErrorLimitError once MaxConsecutiveToolErrors is reached.
+func (a *Agent) countToolFailures(failed bool, count *int) error {
+	if !failed {
+		*count = 0
+		return nil
+	}
+	*count++
+	if a.maxToolErrors > 0 && *count >= a.maxToolErrors {
+		return &ToolErrorLimitError{Rounds: *count}
+	}
+	return nil
+}
+
+// executeToolCalls runs the requested tools through the executor and returns
+// one tool result message per call, in call order. Failures are reported to the
+// model as result content rather than aborting the turn, except a sub-agent
+// exceeding its MaxDepth, which is also returned so the turn ends.
+func (a *Agent) executeToolCalls(ctx context.Context, calls []types.ToolCall, requestID string, rc *runConfig) ([]types.Message, error) {
+	results := make([]types.Message, len(calls))
+
+	tc := a.toolContext()
+	for k, v := range rc.toolMetadata {
+		tc.Metadata[k] = v
+	}
+	if requestID != "" {
+		tc.Metadata[tool.MetadataRequestID] = requestID
+	}
+	if a.hooks.OnToolCalls != nil {
+		approvedCalls, err := a.hooks.OnToolCalls(ctx, a.PendingActions(&types.ChatResponse{Message: types.Message{ToolCalls: calls}}))
+		if err != nil {
+			// Every call still gets a result, so the history stays well formed.
+			for i, call := range calls {
+				results[i] = a.toolErrorResult(call, fmt.Errorf("tool calls rejected: %w", err))
+			}
+			return results, err
+		}
+		tc.Metadata[tool.MetadataApprovedCalls] = mergeApprovedCalls(tc.Metadata[tool.MetadataApprovedCalls], approvedCalls)
+	}
+	built, errs := BuildExecuteRequests(&types.ChatResponse{Message: types.Message{ToolCalls: calls}}, a.toolIndex, tc)
+
+	var requests []*tool.ExecuteRequest
+	var pending []int
+	for i, call := range calls {
+		if errs[i] != nil {
+			results[i] = a.toolErrorResult(call, errs[i])
+			continue
+		}
+		if !rc.toolAllowed(call.Function.Name) {
+			results[i] = a.toolErrorResult(call, fmt.Errorf("tool %q is not allowed for this request", call.Function.Name))
+			continue
+		}
+		requests = append(requests, built[i])
+		pending = append(pending, i)
+	}
+
+	// Results are slotted by call index, never by completion order: providers
+	// require tool messages to follow the assistant's tool_calls order.
+	var fatal error
+	for j, res := range a.executor.ExecuteBatch(ctx, requests) {
+		call := calls[pending[j]]
+		var depthErr *MaxDepthError
+		if res != nil && fatal == nil && errors.As(res.Error, &depthErr) {
+			fatal = res.Error
+		}
+		results[pending[j]] = a.toolResult(call, res)
+	}
+	return results, fatal
+}
+
+// mergeApprovedCalls adds approved to the call IDs already listed under
+// tool.MetadataApprovedCalls, accepting the []string and []any forms.
+func mergeApprovedCalls(existing any, approved []string) []string {
+	var out []string
+	switch ids := existing.(type) {
+	case []string:
+		out = append(out, ids...)
+	case []any:
+		for _, id := range ids {
+			if s, ok := id.(string); ok {
+				out = append(out, s)
+			}
+		}
+	}
+	return append(out, approved...)
+}
+
+// BuildExecuteRequests turns the tool calls of resp into executor requests,
+// looking each tool up by name in index and decoding its JSON arguments.
+// Both slices are indexed like resp.Message.ToolCalls: for each call exactly
+// one of requests[i] and errs[i] is non-nil. Every request gets its own copy
+// of tc, with a private Metadata map and the call ID as its ExecutionID; a nil
+// tc means a fresh context.
+func BuildExecuteRequests(resp *types.ChatResponse, index map[string]tool.Tool, tc *tool.ToolContext) ([]*tool.ExecuteRequest, []error) {
+	if resp == nil {
+		return nil, nil
+	}
+	if tc == nil {
+		tc = tool.NewToolContext()
+	}
+	calls := resp.Message.ToolCalls
+	requests := make([]*tool.ExecuteRequest, len(calls))
+	errs := make([]error, len(calls))
+	for i, call := range calls {
+		t, ok := index[call.Function.Name]
+		if !ok {
+			errs[i] = fmt.Errorf("tool %q not found", call.Function.Name)
+			continue
+		}
+		input := map[string]any{}
+		if call.Function.Arguments != "" {
+			if err := json.Unmarshal([]byte(call.Function.Arguments), &input); err != nil {
+				errs[i] = fmt.Errorf("invalid arguments: %w", err)
+				continue
+			}
+		}
+		callCtx := *tc
+		// Each call is its own execution: identical calls made in different
+		// turns must not share an idempotency key.
+		if call.ID != "" {
+			callCtx.ExecutionID = call.ID
+		}
+		callCtx.Metadata = make(map[string]any, len(tc.Metadata))
+		for k, v := range tc.Metadata {
+			callCtx.Metadata[k] = v
+		}
+		requests[i] = &tool.ExecuteRequest{Tool: t, Input: input, Context: &callCtx, CallID: call.ID}
+	}
+	return requests, errs
+}
+
+// toolResult converts an execution result into the tool message answering
+// call, with failures worded by ToolErrorTemplate.
+func (a *Agent) toolResult(call types.ToolCall, res *tool.ExecuteResult) types.Message {
+	return tool.ResultToMessageWith(call, res, func(call types.ToolCall, err error) string {
+		return a.toolErrorTemplate.Render(map[string]any{
+			"tool":  call.Function.Name,
+			"error": err.Error(),
+		})
+	})
+}
+
+// toolErrorResult reports a call that failed before it could run, flagged
+// with Metadata["error"] = true like any other failure.
+func (a *Agent) toolErrorResult(call types.ToolCall, err error) types.Message {
+	return a.toolResult(call, &tool.ExecuteResult{Error: err})
+}
+
+// summarizeLargeResult replaces the content of a successful result message
+// with a summary when it is oversized and summarization is enabled.
+func (a *Agent) summarizeLargeResult(ctx context.Context, call types.ToolCall, msg types.Message) types.Message {
+	if !a.summarizeResults {
+		return msg
+	}
+	content := msg.Content
+	tokens := provider.TokenizerForModel(resolveOptions(a.options).Model).Count(content)
+	if tokens <= a.resultTokenLimit {
+		return msg
+	}
+
+	summary, err := a.summarizeToolResult(ctx, call, content)
+	if err != nil {
+		// Fall back to the full output rather than losing it.
+		if a.logger != nil {
+			a.logger.Error("tool result summarization failed", "tool", call.Function.Name, "error", err)
+		}
+		return msg
+	}
+
+	msg.Content = fmt.Sprintf("[summarized from %d tokens]\n%s", tokens, summary)
+	metadata := make(map[string]any, len(msg.Metadata)+2)
+	for k, v := range msg.Metadata {
+		metadata[k] = v
+	}
+	metadata["summarized"] = true
+	metadata["original_tokens"] = tokens
+	msg.Metadata = metadata
+	return msg
+}
+
+const summarizePrompt = `You condense tool output for another assistant. Keep every fact, value, ` +
+	`file path and identifier relevant to the user's request; drop everything else. ` +
+	`Reply with the summary only.`
+
+// summarizeToolResult asks the summarizer to condense content in light of the user's request.
+func (a *Agent) summarizeToolResult(ctx context.Context, call types.ToolCall, content string) (string, error) {
+	var query string
+	history := a.memory.History()
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Role == types.RoleUser {
+			query = history[i].Content
+			break
+		}
+	}
+
+	prompt := fmt.Sprintf("User request: %s\nTool: %s\nArguments: %s\n\nOutput:\n%s",
+		query, call.Function.Name, a.redactedArguments(call), content)
+	resp, err := a.summarizer.Chat(ctx, []types.Message{
+		types.SystemMessage(summarizePrompt),
+		types.UserMessage(prompt),
+	})
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(resp.Message.Content) == "" {
+		return "", ErrEmptyResponse
+	}
+	return resp.Message.Content, nil
+}
+
+// dedupMinBytes is the smallest tool result DeduplicateToolOutputs replaces;
+// shorter outputs cost less to resend than the note pointing back to them.
+const dedupMinBytes = 256
+
+// dedupToolResult replaces result with a reference note when an earlier result
+// in history came from the same tool with the same arguments and has identical
+// content. Keying on tool and arguments keeps different files with the same
+// contents from being conflated.
+func (a *Agent) dedupToolResult(call types.ToolCall, result types.Message) types.Message {
+	if !a.dedupResults || len(result.Content) < dedupMinBytes {
+		return result
+	}
+
+	args := canonicalArguments(call.Function.Arguments)
+	calls := make(map[string]types.ToolCall)
+	for _, msg := range a.memory.History() {
+		for _, c := range msg.ToolCalls {
+			calls[c.ID] = c
+		}
+		if msg.Role != types.RoleTool || msg.Content != result.Content {
+			continue
+		}
+		prev, ok := calls[msg.ToolCallID]
+		if !ok || prev.Function.Name != call.Function.Name || canonicalArguments(prev.Function.Arguments) != args {
+			continue
+		}
+
+		note := types.ToolResultMessage(result.ToolCallID, fmt.Sprintf("[output unchanged; see earlier %s of %s]", call.Function.Name, describeArguments(a.redactedArguments(call))))
+		note.Metadata = map[string]any{"deduplicated": true, "original_tool_call_id": prev.ID}
+		return note
+	}
+	return result
+}
+
+// toolOutputTag matches the opening and closing tags of the default wrapper.
+var toolOutputTag = regexp.MustCompile(`(?i)<(/?\s*tool_output)`)
+
+// wrapToolOutputs returns messages with each tool result rendered through
+// ToolOutputTemplate. Tags of the default <tool_output> wrapper inside the
+// output are escaped, so a tool cannot close the block early and smuggle in
+// text that reads as the agent's own. The stored messages are not modified.
+func (a *Agent) wrapToolOutputs(messages []types.Message) ([]types.Message, error) {
+	names := make(map[string]string)
+	out := make([]types.Message, len(messages))
+	for i, msg := range messages {
+		for _, c := range msg.ToolCalls {
+			names[c.ID] = c.Function.Name
+		}
+		if msg.Role == types.RoleTool {
+			// One pass, so placeholders inside the output are left alone.
+			content, err := a.toolOutputTemplate.RenderStrict(map[string]any{
+				"tool":   names[msg.ToolCallID],
+				"output": toolOutputTag.ReplaceAllString(msg.Content, "&lt;$1"),
+			})
+			if err != nil {
+				return nil, fmt.Errorf("tool output template: %w", err)
+			}
+			msg.Content = content
+		}
+		out[i] = msg
+	}
+	return out, nil
+}
+
+// canonicalArguments re-encodes JSON arguments with sorted keys so equivalent
+// calls compare equal regardless of key order or whitespace.
+func canonicalArguments(args string) string {
+	var v any
+	if err := json.Unmarshal([]byte(args), &v); err != nil {
+		return args
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return args
+	}
+	return string(b)
+}
+
+// redactedArguments returns the arguments of call with the values its tool's
+// schema marks sensitive masked, for text the agent writes into the context.
+func (a *Agent) redactedArguments(call types.ToolCall) string {
+	t, ok := a.toolIndex[call.Function.Name]
+	if !ok {
+		return call.Function.Arguments
+	}
+	return tool.RedactArguments(t.InputSchema(), call.Function.Arguments)
+}
+
+// describeArguments names the target of a call for reference notes: its
+// "path" argument when present, otherwise the raw arguments.
+func describeArguments(args string) string {
+	var input map[string]any
+	if json.Unmarshal([]byte(args), &input) == nil {
+		if path, ok := input["path"].(string); ok && path != "" {
+			return path
+		}
+	}
+	return args
+}
+
+// toolContext builds the context handed to tools invoked by the agent.
+func (a *Agent) toolContext() *tool.ToolContext {
+	opts := []tool.Option{tool.WithSessionID(a.sessionID)}
+	if a.logger != nil {
+		opts = append(opts, tool.WithLogger(a.logger))
+	}
+	return tool.NewToolContext(opts...)
+}
@@ -0,0 +1,36 @@
+package memory
+
+import "giai/pkg/types"
+
+// compactSeparator joins the contents of merged messages.
+const compactSeparator = "\n\n"
+
+// Compact merges runs of adjacent messages with the same role (and author
+// name) into one, joining their contents with a blank line, for providers that
+// require alternating roles. Messages carrying tool calls, tool results or
+// content parts (images) are never merged, so every tool result still answers
+// its own call and no part is dropped. The merged
+// message keeps the first message's metadata. The input is not modified.
+func Compact(messages []types.Message) []types.Message {
+	out := make([]types.Message, 0, len(messages))
+	for _, msg := range messages {
+		if n := len(out); n > 0 && mergeable(out[n-1], msg) {
+			switch {
+			case out[n-1].Content == "":
+				out[n-1].Content = msg.Content
+			case msg.Content != "":
+				out[n-1].Content += compactSeparator + msg.Content
+			}
+			continue
+		}
+		out = append(out, msg)
+	}
+	return out
+}
+
+func mergeable(prev, next types.Message) bool {
+	return prev.Role == next.Role && prev.Name == next.Name &&
+		prev.Role != types.RoleTool &&
+		len(prev.ToolCalls) == 0 && len(next.ToolCalls) == 0 &&
+		len(prev.Parts) == 0 && len(next.Parts) == 0
+}
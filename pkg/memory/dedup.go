@@ -0,0 +1,68 @@
+package memory
+
+import (
+	"sync"
+
+	"giai/pkg/types"
+)
+
+// Dedup wraps another Memory and drops an Add that repeats the immediately
+// preceding message (same role, content and tool-call IDs). Messages with
+// content parts are never treated as repeats. It guards against
+// double-recording in retry and resume paths.
+type Dedup struct {
+	mu    sync.Mutex
+	inner Memory
+}
+
+// NewDedup wraps inner with duplicate suppression.
+func NewDedup(inner Memory) *Dedup {
+	return &Dedup{inner: inner}
+}
+
+// Add appends message unless it duplicates the last stored message.
+func (d *Dedup) Add(message types.Message) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	history := d.inner.History()
+	if n := len(history); n > 0 && sameMessage(history[n-1], message) {
+		return
+	}
+	d.inner.Add(message)
+}
+
+// History returns the wrapped memory's history.
+func (d *Dedup) History() []types.Message {
+	return d.inner.History()
+}
+
+// Reset clears the wrapped memory.
+func (d *Dedup) Reset() {
+	d.inner.Reset()
+}
+
+// Unwrap returns the wrapped memory.
+func (d *Dedup) Unwrap() Memory {
+	return d.inner
+}
+
+func sameMessage(a, b types.Message) bool {
+	if a.Role != b.Role || a.Content != b.Content || a.ToolCallID != b.ToolCallID {
+		return false
+	}
+	// Parts (e.g. images) are not compared, so their messages are always kept.
+	if len(a.Parts) > 0 || len(b.Parts) > 0 {
+		return false
+	}
+	if len(a.ToolCalls) != len(b.ToolCalls) {
+		return false
+	}
+	for i := range a.ToolCalls {
+		if a.ToolCalls[i].ID != b.ToolCalls[i].ID {
+			return false
+		}
+	}
+	return true
+}
+
+var _ Memory = (*Dedup)(nil)
@@ -1,6 +1,10 @@
 package memory
 
 import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -14,15 +18,53 @@ type Memory interface {
 	Reset()
 }
 
+// CheckpointID identifies a saved snapshot of a conversation.
+type CheckpointID string
+
+// Checkpointable is implemented by memories that can snapshot and roll back history,
+// enabling "what-if" branches of a conversation.
+type Checkpointable interface {
+	// Checkpoint snapshots the current history and returns its ID.
+	Checkpoint() CheckpointID
+	// Restore replaces the current history with a previously saved snapshot.
+	Restore(id CheckpointID) error
+}
+
 // InMemory is a simple thread-safe memory backend.
 type InMemory struct {
-	mu       sync.RWMutex
-	messages []types.Message
+	mu          sync.RWMutex
+	messages    []types.Message
+	checkpoints map[CheckpointID][]types.Message
+	nextID      int
+	limit       int // Max messages kept; 0 means unbounded
 }
 
 // NewInMemory creates an empty memory store.
 func NewInMemory() *InMemory {
-	return &InMemory{messages: make([]types.Message, 0, 8)}
+	return &InMemory{
+		messages:    make([]types.Message, 0, 8),
+		checkpoints: make(map[CheckpointID][]types.Message),
+	}
+}
+
+// NewInMemoryWithLimit creates a memory store that keeps at most maxMessages messages,
+// evicting the oldest non-system messages first. A limit <= 0 means unbounded.
+// This is a cheap count-based guard; it does not look at token usage.
+func NewInMemoryWithLimit(maxMessages int) *InMemory {
+	m := NewInMemory()
+	m.SetLimit(maxMessages)
+	return m
+}
+
+// SetLimit changes the message cap and trims history immediately if it is exceeded.
+func (m *InMemory) SetLimit(maxMessages int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if maxMessages < 0 {
+		maxMessages = 0
+	}
+	m.limit = maxMessages
+	m.enforceLimit()
 }
 
 // Add appends a message to history.
@@ -30,6 +72,28 @@ func (m *InMemory) Add(message types.Message) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.messages = append(m.messages, message)
+	m.enforceLimit()
+}
+
+// enforceLimit drops the oldest non-system messages until the cap is met.
+// System messages are never evicted, and an assistant message goes together
+// with the tool results that follow it, so no result is left without its
+// call. Callers must hold m.mu.
+func (m *InMemory) enforceLimit() {
+	if m.limit <= 0 {
+		return
+	}
+	for len(m.messages) > m.limit {
+		first := slices.IndexFunc(m.messages, func(msg types.Message) bool { return msg.Role != types.RoleSystem })
+		if first < 0 {
+			return
+		}
+		end := first + 1
+		for end < len(m.messages) && m.messages[end].Role == types.RoleTool {
+			end++
+		}
+		m.messages = slices.Delete(m.messages, first, end)
+	}
 }
 
 // History returns a copy of the conversation so callers cannot mutate internal state.
@@ -48,6 +112,56 @@ func (m *InMemory) Reset() {
 	m.messages = m.messages[:0]
 }
 
+// Checkpoint snapshots the current history. The snapshot is isolated from later writes.
+func (m *InMemory) Checkpoint() CheckpointID {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nextID++
+	id := CheckpointID("cp-" + strconv.Itoa(m.nextID))
+	if m.checkpoints == nil {
+		m.checkpoints = make(map[CheckpointID][]types.Message)
+	}
+	m.checkpoints[id] = cloneMessages(m.messages)
+	return id
+}
+
+// Restore rolls history back to the given checkpoint. The checkpoint stays valid,
+// so the same branch point can be restored multiple times.
+func (m *InMemory) Restore(id CheckpointID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	snapshot, ok := m.checkpoints[id]
+	if !ok {
+		return fmt.Errorf("checkpoint %q not found", id)
+	}
+	m.messages = cloneMessages(snapshot)
+	// The limit may have been lowered since the checkpoint was taken.
+	m.enforceLimit()
+	return nil
+}
+
+// cloneMessages copies messages including their tool calls, metadata and
+// content parts so that mutations on one copy never leak into another.
+func cloneMessages(messages []types.Message) []types.Message {
+	out := make([]types.Message, len(messages))
+	copy(out, messages)
+	for i := range out {
+		if len(out[i].ToolCalls) > 0 {
+			out[i].ToolCalls = append([]types.ToolCall(nil), out[i].ToolCalls...)
+		}
+		if out[i].Metadata != nil {
+			out[i].Metadata = maps.Clone(out[i].Metadata)
+		}
+		if len(out[i].Parts) > 0 {
+			out[i].Parts = slices.Clone(out[i].Parts)
+			for j := range out[i].Parts {
+				out[i].Parts[j].Data = slices.Clone(out[i].Parts[j].Data)
+			}
+		}
+	}
+	return out
+}
+
 // FormatHistory renders a simple bullet list of the conversation for prompts.
 func FormatHistory(messages []types.Message) string {
 	if len(messages) == 0 {
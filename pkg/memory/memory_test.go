@@ -0,0 +1,289 @@
+package memory
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"giai/pkg/provider"
+	"giai/pkg/types"
+)
+
+func TestInMemory_CheckpointRestore(t *testing.T) {
+	m := NewInMemory()
+	m.Add(types.Message{Role: types.RoleUser, Content: "hello"})
+	m.Add(types.Message{Role: types.RoleAssistant, Content: "hi"})
+
+	cp := m.Checkpoint()
+
+	// Branch A diverges.
+	m.Add(types.Message{Role: types.RoleUser, Content: "branch A"})
+	if got := len(m.History()); got != 3 {
+		t.Fatalf("len(History()) = %d, want 3", got)
+	}
+
+	if err := m.Restore(cp); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if got := len(m.History()); got != 2 {
+		t.Fatalf("len(History()) after restore = %d, want 2", got)
+	}
+
+	// Branch B continues from the checkpoint without touching it.
+	m.Add(types.Message{Role: types.RoleUser, Content: "branch B"})
+	if err := m.Restore(cp); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	history := m.History()
+	if len(history) != 2 {
+		t.Fatalf("len(History()) after second restore = %d, want 2", len(history))
+	}
+	if history[1].Content != "hi" {
+		t.Errorf("history[1].Content = %q, want %q", history[1].Content, "hi")
+	}
+}
+
+func TestInMemory_CheckpointIsolatesMetadataAndParts(t *testing.T) {
+	m := NewInMemory()
+	msg := types.Message{
+		Role:     types.RoleUser,
+		Content:  "look",
+		Metadata: map[string]any{"source": "upload"},
+		Parts:    []types.ContentPart{types.ImagePart("image/png", []byte("png"))},
+	}
+	m.Add(msg)
+	cp := m.Checkpoint()
+
+	// Mutating the caller's message after the checkpoint must not reach it.
+	msg.Metadata["source"] = "changed"
+	msg.Parts[0].Data[0] = 'X'
+	msg.Parts[0].MIMEType = "image/gif"
+	if err := m.Restore(cp); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	// Nor may changes to restored history.
+	got := m.History()[0]
+	got.Metadata["source"] = "restored"
+	if err := m.Restore(cp); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	got = m.History()[0]
+	if got.Metadata["source"] != "upload" {
+		t.Errorf("Metadata = %v, want the checkpointed value", got.Metadata)
+	}
+	if p := got.Parts[0]; p.MIMEType != "image/png" || string(p.Data) != "png" {
+		t.Errorf("Parts[0] = %+v, want the checkpointed image", p)
+	}
+}
+
+func TestInMemory_RestoreUnknown(t *testing.T) {
+	m := NewInMemory()
+	if err := m.Restore("missing"); err == nil {
+		t.Error("Restore() expected error for unknown checkpoint")
+	}
+}
+
+func TestInMemoryWithLimit_EvictsOldest(t *testing.T) {
+	m := NewInMemoryWithLimit(3)
+	m.Add(types.Message{Role: types.RoleSystem, Content: "sys"})
+	for _, c := range []string{"u1", "u2", "u3", "u4"} {
+		m.Add(types.Message{Role: types.RoleUser, Content: c})
+	}
+
+	got := m.History()
+	want := []string{"sys", "u3", "u4"}
+	if len(got) != len(want) {
+		t.Fatalf("History() len = %d, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		if got[i].Content != w {
+			t.Errorf("History()[%d] = %q, want %q", i, got[i].Content, w)
+		}
+	}
+
+	m.SetLimit(2)
+	if got := m.History(); len(got) != 2 || got[0].Role != types.RoleSystem || got[1].Content != "u4" {
+		t.Errorf("after SetLimit(2) History() = %+v, want [sys u4]", got)
+	}
+}
+
+func TestInMemoryWithLimit_RestoreAppliesLimit(t *testing.T) {
+	m := NewInMemory()
+	for _, c := range []string{"u1", "u2", "u3"} {
+		m.Add(types.Message{Role: types.RoleUser, Content: c})
+	}
+	cp := m.Checkpoint()
+
+	m.SetLimit(2)
+	if err := m.Restore(cp); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if got := m.History(); len(got) != 2 || got[0].Content != "u2" || got[1].Content != "u3" {
+		t.Errorf("History() after Restore = %+v, want [u2 u3]", got)
+	}
+}
+
+func TestInMemoryWithLimit_EvictsToolResultsWithCall(t *testing.T) {
+	m := NewInMemoryWithLimit(3)
+	m.Add(types.SystemMessage("sys"))
+	m.Add(types.UserMessage("weather?"))
+	m.Add(types.AssistantToolCall(
+		types.NewToolCall("call_1", "weather", `{"city":"Oslo"}`),
+		types.NewToolCall("call_2", "weather", `{"city":"Rome"}`),
+	))
+	m.Add(types.ToolResultMessage("call_1", "3C"))
+	m.Add(types.ToolResultMessage("call_2", "18C"))
+	m.Add(types.AssistantMessage("Cold in Oslo, warm in Rome."))
+
+	got := m.History()
+	if len(got) != 2 || got[0].Role != types.RoleSystem || got[1].Role != types.RoleAssistant || len(got[1].ToolCalls) != 0 {
+		t.Errorf("History() = %+v, want [sys answer] with no orphaned tool results", got)
+	}
+}
+
+func TestDedup_SkipsRepeatedMessage(t *testing.T) {
+	m := NewDedup(NewInMemory())
+	msg := types.Message{Role: types.RoleUser, Content: "hello"}
+
+	m.Add(msg)
+	m.Add(msg)
+	if got := len(m.History()); got != 1 {
+		t.Fatalf("len(History()) = %d, want 1", got)
+	}
+
+	// Only the immediately preceding message is compared.
+	m.Add(types.Message{Role: types.RoleAssistant, Content: "hi"})
+	m.Add(msg)
+	if got := len(m.History()); got != 3 {
+		t.Errorf("len(History()) = %d, want 3", got)
+	}
+
+	// Tool results for different calls are distinct even with equal content.
+	m.Add(types.ToolResultMessage("call_1", "ok"))
+	m.Add(types.ToolResultMessage("call_2", "ok"))
+	if got := len(m.History()); got != 5 {
+		t.Errorf("len(History()) = %d, want 5", got)
+	}
+
+	// Messages with different images but the same text are both kept.
+	m.Add(types.UserImageMessage("what is this?", "https://example.com/a.png"))
+	m.Add(types.UserImageMessage("what is this?", "https://example.com/b.png"))
+	if got := len(m.History()); got != 7 {
+		t.Errorf("len(History()) = %d, want 7", got)
+	}
+}
+
+func TestCompact(t *testing.T) {
+	call := types.NewToolCall("call_1", "search", `{}`)
+	in := []types.Message{
+		types.SystemMessage("Be brief."),
+		types.UserMessage("first"),
+		types.UserMessage("second"),
+		types.AssistantMessage("Let me check."),
+		types.AssistantToolCall(call),
+		types.ToolResultMessage("call_1", "a"),
+		types.ToolResultMessage("call_2", "b"),
+		types.UserMessage("third"),
+		types.UserMessage(""),
+		types.AssistantMessage("done"),
+		types.UserMessage("look"),
+		types.UserImageMessage("", "https://example.com/a.png"),
+	}
+
+	got := Compact(in)
+	want := []types.Message{
+		types.SystemMessage("Be brief."),
+		types.UserMessage("first\n\nsecond"),
+		types.AssistantMessage("Let me check."),
+		types.AssistantToolCall(call),
+		types.ToolResultMessage("call_1", "a"),
+		types.ToolResultMessage("call_2", "b"),
+		types.UserMessage("third"),
+		types.AssistantMessage("done"),
+		types.UserMessage("look"),
+		types.UserImageMessage("", "https://example.com/a.png"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Compact() =\n%+v\nwant\n%+v", got, want)
+	}
+	if in[1].Content != "first" {
+		t.Errorf("Compact() modified its input: %q", in[1].Content)
+	}
+}
+
+// countingTokens counts one token per message.
+type countingTokens struct{}
+
+func (countingTokens) CountMessages(messages []types.Message, model string) (int, error) {
+	return len(messages), nil
+}
+
+func TestTrimToTokens(t *testing.T) {
+	call := types.NewToolCall("c1", "read", `{}`)
+	messages := []types.Message{
+		types.SystemMessage("sys"),
+		types.UserMessage("first"),
+		types.AssistantToolCall(call),
+		types.ToolResultMessage("c1", "data"),
+		types.AssistantMessage("answer"),
+		types.UserMessage("second"),
+	}
+
+	tests := []struct {
+		name string
+		max  int
+		want []string
+	}{
+		{"Fits", 6, []string{"sys", "first", "", "data", "answer", "second"}},
+		{"Drops Oldest", 5, []string{"sys", "", "data", "answer", "second"}},
+		{"Drops Call With Its Result", 4, []string{"sys", "answer", "second"}},
+		{"Keeps System And Latest", 1, []string{"sys", "second"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := TrimToTokens(messages, countingTokens{}, tt.max, "")
+			var contents []string
+			for _, msg := range got {
+				contents = append(contents, msg.Content)
+			}
+			if !reflect.DeepEqual(contents, tt.want) {
+				t.Errorf("TrimToTokens(max %d) = %q, want %q", tt.max, contents, tt.want)
+			}
+		})
+	}
+	if len(messages) != 6 {
+		t.Error("TrimToTokens() modified its input")
+	}
+}
+
+// countedTokens is countingTokens recording how many messages it was given.
+type countedTokens struct{ seen *int }
+
+func (c countedTokens) CountMessages(messages []types.Message, model string) (int, error) {
+	*c.seen += len(messages)
+	return len(messages), nil
+}
+
+func TestTrimToTokens_CountsEachMessageOnce(t *testing.T) {
+	var messages []types.Message
+	for i := 0; i < 100; i++ {
+		messages = append(messages, types.UserMessage("m"))
+	}
+	seen := 0
+	if got := TrimToTokens(messages, countedTokens{&seen}, 1, ""); len(got) != 1 {
+		t.Fatalf("TrimToTokens() kept %d messages, want 1", len(got))
+	}
+	if seen != len(messages) {
+		t.Errorf("counted %d messages, want each of the %d once", seen, len(messages))
+	}
+}
+
+func TestTrimToTokens_Tiktoken(t *testing.T) {
+	messages := []types.Message{types.UserMessage(strings.Repeat("word ", 200)), types.UserMessage("short")}
+	got := TrimToTokens(messages, provider.TiktokenCounter{}, 50, "gpt-4o")
+	if len(got) != 1 || got[0].Content != "short" {
+		t.Errorf("TrimToTokens() kept %d messages, want only the short one", len(got))
+	}
+}
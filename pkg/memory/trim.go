@@ -0,0 +1,64 @@
+package memory
+
+import (
+	"giai/pkg/provider"
+	"giai/pkg/types"
+)
+
+// TrimToTokens drops the oldest non-system messages until counter puts the
+// transcript at or under max tokens for model. Tool results are dropped along
+// with the call that requested them, so no result is left without its call.
+// System messages and the latest message are always kept, even when they alone
+// exceed max. A counting error leaves the messages as they are. The input is
+// not modified.
+//
+// Each message is counted once, on its own, and the transcript's total is the
+// sum of those counts plus the overhead of an empty transcript.
+func TrimToTokens(messages []types.Message, counter provider.TokenCounter, max int, model string) []types.Message {
+	total, err := counter.CountMessages(nil, model)
+	if err != nil {
+		return messages
+	}
+	base := total
+	counts := make([]int, len(messages))
+	for i := range messages {
+		n, err := counter.CountMessages(messages[i:i+1], model)
+		if err != nil {
+			return messages
+		}
+		counts[i] = n - base
+		total += counts[i]
+	}
+
+	drop := make([]bool, len(messages))
+	dropped := false
+	for i := 0; total > max && i < len(messages); {
+		if messages[i].Role == types.RoleSystem {
+			i++
+			continue
+		}
+		end := i + 1
+		for end < len(messages) && messages[end].Role == types.RoleTool {
+			end++
+		}
+		if end >= len(messages) {
+			break
+		}
+		for ; i < end; i++ {
+			drop[i] = true
+			total -= counts[i]
+		}
+		dropped = true
+	}
+	if !dropped {
+		return messages
+	}
+
+	out := make([]types.Message, 0, len(messages))
+	for i, msg := range messages {
+		if !drop[i] {
+			out = append(out, msg)
+		}
+	}
+	return out
+}
@@ -15,9 +15,17 @@ type Parser[T any] interface {
 	GetFormatInstructions() string
 }
 
+// ParserOptions tunes how JSONParser decodes.
+type ParserOptions struct {
+	// UseNumber decodes numbers into `any` targets as json.Number instead of
+	// float64, preserving precision of large integers such as IDs.
+	UseNumber bool
+}
+
 // JSONParser parses JSON output into a struct.
 type JSONParser[T any] struct {
 	// Optional schema description
+	opts ParserOptions
 }
 
 // NewJSONParser creates a new JSON parser.
@@ -25,18 +33,39 @@ func NewJSONParser[T any]() *JSONParser[T] {
 	return &JSONParser[T]{}
 }
 
+// NewJSONParserWithOptions creates a JSON parser with custom decoding options.
+func NewJSONParserWithOptions[T any](opts ParserOptions) *JSONParser[T] {
+	return &JSONParser[T]{opts: opts}
+}
+
 // Parse tries to extract and parse JSON from the text.
 // It handles cases where the JSON is embedded in markdown code blocks.
 func (p *JSONParser[T]) Parse(text string) (T, error) {
 	var zero T
 	cleaned := cleanJSON(text)
 	
-	if err := json.Unmarshal([]byte(cleaned), &zero); err != nil {
+	if err := p.decode(cleaned, &zero); err != nil {
 		return zero, fmt.Errorf("failed to parse JSON: %w. Input: %s", err, text)
 	}
 	return zero, nil
 }
 
+func (p *JSONParser[T]) decode(data string, v any) error {
+	if !p.opts.UseNumber {
+		return json.Unmarshal([]byte(data), v)
+	}
+	dec := json.NewDecoder(strings.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	// Match json.Unmarshal, which rejects trailing data.
+	if dec.More() {
+		return fmt.Errorf("unexpected data after top-level value")
+	}
+	return nil
+}
+
 func (p *JSONParser[T]) GetFormatInstructions() string {
 	return "Return the output as a valid JSON object."
 }
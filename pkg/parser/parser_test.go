@@ -0,0 +1,34 @@
+package parser
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestJSONParser_UseNumber(t *testing.T) {
+	type result struct {
+		ID any `json:"id"`
+	}
+	input := "```json\n{\"id\": 9007199254740993}\n```"
+
+	got, err := NewJSONParserWithOptions[result](ParserOptions{UseNumber: true}).Parse(input)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	n, ok := got.ID.(json.Number)
+	if !ok {
+		t.Fatalf("ID = %T, want json.Number", got.ID)
+	}
+	if id, err := n.Int64(); err != nil || id != 9007199254740993 {
+		t.Errorf("ID = %v, want 9007199254740993", n)
+	}
+
+	// Default behavior is unchanged.
+	got, err = NewJSONParser[result]().Parse(input)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if _, ok := got.ID.(float64); !ok {
+		t.Errorf("default ID = %T, want float64", got.ID)
+	}
+}
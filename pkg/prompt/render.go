@@ -0,0 +1,250 @@
+package prompt
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// RenderStrict renders the template with block support and fails on missing
+// variables instead of leaving placeholders behind:
+//
+//	{{name}}, {{user.name}}          variable, with dotted lookup into maps
+//	{{#if cond}}...{{else}}...{{/if}} conditional on a truthy value
+//	{{#each items}}...{{/each}}      loop; {{this}} is the item, {{@index}} its index
+//
+// Inside a loop, names resolve against the current item first, then outer scopes.
+// A missing variable in an #if condition counts as false rather than an error,
+// so optional sections need no placeholder value.
+func (t Template) RenderStrict(vars map[string]any) (string, error) {
+	nodes, err := parseTemplate(t.Text)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	r := renderer{scopes: []scope{{value: vars}}}
+	if err := r.render(&sb, nodes); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+type nodeKind int
+
+const (
+	textNode nodeKind = iota
+	varNode
+	ifNode
+	eachNode
+)
+
+type node struct {
+	kind     nodeKind
+	text     string // Literal text, or the variable path
+	body     []node
+	elseBody []node
+}
+
+// parseTemplate turns template text into a node tree.
+func parseTemplate(text string) ([]node, error) {
+	p := &parser{rest: text}
+	nodes, end, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+	if end != "" {
+		return nil, fmt.Errorf("unexpected {{%s}}", end)
+	}
+	return nodes, nil
+}
+
+type parser struct {
+	rest string
+}
+
+// parse reads nodes until the input ends or a closing/else tag is reached,
+// which is returned so the caller can check it matches its block.
+func (p *parser) parse() ([]node, string, error) {
+	var nodes []node
+	for p.rest != "" {
+		start := strings.Index(p.rest, "{{")
+		if start < 0 {
+			nodes = append(nodes, node{kind: textNode, text: p.rest})
+			p.rest = ""
+			break
+		}
+		if start > 0 {
+			nodes = append(nodes, node{kind: textNode, text: p.rest[:start]})
+		}
+		end := strings.Index(p.rest[start+2:], "}}")
+		if end < 0 {
+			return nil, "", fmt.Errorf("unclosed {{ in template")
+		}
+		tag := strings.TrimSpace(p.rest[start+2 : start+2+end])
+		p.rest = p.rest[start+2+end+2:]
+
+		switch {
+		case tag == "else" || strings.HasPrefix(tag, "/"):
+			return nodes, tag, nil
+		case strings.HasPrefix(tag, "#if "):
+			n, err := p.block(ifNode, strings.TrimSpace(tag[len("#if "):]), "/if", true)
+			if err != nil {
+				return nil, "", err
+			}
+			nodes = append(nodes, n)
+		case strings.HasPrefix(tag, "#each "):
+			n, err := p.block(eachNode, strings.TrimSpace(tag[len("#each "):]), "/each", false)
+			if err != nil {
+				return nil, "", err
+			}
+			nodes = append(nodes, n)
+		case strings.HasPrefix(tag, "#"):
+			return nil, "", fmt.Errorf("unknown block {{%s}}", tag)
+		case tag == "":
+			return nil, "", fmt.Errorf("empty {{}} in template")
+		default:
+			nodes = append(nodes, node{kind: varNode, text: tag})
+		}
+	}
+	return nodes, "", nil
+}
+
+// block parses the body of an #if or #each up to its closing tag.
+func (p *parser) block(kind nodeKind, path, closing string, allowElse bool) (node, error) {
+	if path == "" {
+		return node{}, fmt.Errorf("block is missing its variable before {{%s}}", closing)
+	}
+	n := node{kind: kind, text: path}
+	body, end, err := p.parse()
+	if err != nil {
+		return node{}, err
+	}
+	n.body = body
+	if end == "else" && allowElse {
+		n.elseBody, end, err = p.parse()
+		if err != nil {
+			return node{}, err
+		}
+	}
+	if end != closing {
+		if end == "" {
+			return node{}, fmt.Errorf("missing {{%s}}", closing)
+		}
+		return node{}, fmt.Errorf("unexpected {{%s}}, want {{%s}}", end, closing)
+	}
+	return n, nil
+}
+
+type scope struct {
+	value any
+	index int
+	loop  bool
+}
+
+type renderer struct {
+	scopes []scope
+}
+
+func (r *renderer) render(sb *strings.Builder, nodes []node) error {
+	for _, n := range nodes {
+		switch n.kind {
+		case textNode:
+			sb.WriteString(n.text)
+		case varNode:
+			v, ok := r.lookup(n.text)
+			if !ok {
+				return fmt.Errorf("missing variable %q", n.text)
+			}
+			sb.WriteString(fmt.Sprint(v))
+		case ifNode:
+			v, _ := r.lookup(n.text)
+			body := n.elseBody
+			if truthy(v) {
+				body = n.body
+			}
+			if err := r.render(sb, body); err != nil {
+				return err
+			}
+		case eachNode:
+			v, ok := r.lookup(n.text)
+			if !ok {
+				return fmt.Errorf("missing variable %q", n.text)
+			}
+			rv := reflect.ValueOf(v)
+			if v == nil || rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+				return fmt.Errorf("{{#each %s}} needs a list, got %T", n.text, v)
+			}
+			for i := 0; i < rv.Len(); i++ {
+				r.scopes = append(r.scopes, scope{value: rv.Index(i).Interface(), index: i, loop: true})
+				err := r.render(sb, n.body)
+				r.scopes = r.scopes[:len(r.scopes)-1]
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// lookup resolves a variable path against the scopes, innermost first.
+func (r *renderer) lookup(path string) (any, bool) {
+	top := r.scopes[len(r.scopes)-1]
+	switch path {
+	case "this", ".":
+		return top.value, true
+	case "@index":
+		return top.index, top.loop
+	}
+
+	parts := strings.Split(path, ".")
+	if parts[0] == "this" {
+		return descend(top.value, parts[1:])
+	}
+	for i := len(r.scopes) - 1; i >= 0; i-- {
+		if _, ok := field(r.scopes[i].value, parts[0]); ok {
+			return descend(r.scopes[i].value, parts)
+		}
+	}
+	return nil, false
+}
+
+func descend(v any, parts []string) (any, bool) {
+	for _, p := range parts {
+		var ok bool
+		if v, ok = field(v, p); !ok {
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// field reads key from a map with string keys.
+func field(v any, key string) (any, bool) {
+	if m, ok := v.(map[string]any); ok {
+		val, ok := m[key]
+		return val, ok
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+		return nil, false
+	}
+	val := rv.MapIndex(reflect.ValueOf(key).Convert(rv.Type().Key()))
+	if !val.IsValid() {
+		return nil, false
+	}
+	return val.Interface(), true
+}
+
+// truthy treats nil, false, zero numbers, empty strings and empty collections as false.
+func truthy(v any) bool {
+	if v == nil {
+		return false
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array, reflect.String:
+		return rv.Len() > 0
+	}
+	return !rv.IsZero()
+}
@@ -0,0 +1,54 @@
+package prompt
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderStrict(t *testing.T) {
+	vars := map[string]any{
+		"service": "api",
+		"debug":   true,
+		"ports":   []any{8080, 9090},
+		"env":     []any{map[string]any{"key": "MODE", "value": "prod"}},
+		"owner":   map[string]any{"name": "ops"},
+		"empty":   []any{},
+	}
+
+	tests := []struct {
+		name    string
+		text    string
+		want    string
+		wantErr string
+	}{
+		{name: "Variable", text: "name: {{service}}", want: "name: api"},
+		{name: "Dotted", text: "owner: {{owner.name}}", want: "owner: ops"},
+		{name: "If", text: "{{#if debug}}log: debug{{else}}log: info{{/if}}", want: "log: debug"},
+		{name: "Else", text: "{{#if empty}}some{{else}}none{{/if}}", want: "none"},
+		{name: "If Missing Is False", text: "{{#if tls}}tls: on{{/if}}ok", want: "ok"},
+		{name: "Each", text: "{{#each ports}}- {{@index}}:{{this}}\n{{/each}}", want: "- 0:8080\n- 1:9090\n"},
+		{name: "Each Fields And Outer Scope", text: "{{#each env}}{{service}}_{{key}}={{value}}{{/each}}", want: "api_MODE=prod"},
+		{name: "Missing", text: "{{service}} {{region}}", wantErr: `missing variable "region"`},
+		{name: "Each Not List", text: "{{#each service}}x{{/each}}", wantErr: "needs a list"},
+		{name: "Unclosed Block", text: "{{#if debug}}x", wantErr: "missing {{/if}}"},
+		{name: "Mismatched Block", text: "{{#each ports}}x{{/if}}", wantErr: "unexpected {{/if}}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewTemplate(tt.text).RenderStrict(vars)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("RenderStrict() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RenderStrict() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("RenderStrict() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -24,3 +24,44 @@ func (t Template) Render(vars map[string]any) string {
 	}
 	return out
 }
+
+// fragmentSeparator joins composed fragments.
+const fragmentSeparator = "\n\n"
+
+// Compose concatenates fragments in order, separated by blank lines, into one
+// Template. Empty fragments are skipped. The result needs the union of the
+// fragments' variables, so one vars map renders every fragment.
+func Compose(fragments ...Template) Template {
+	parts := make([]string, 0, len(fragments))
+	for _, f := range fragments {
+		if text := strings.TrimSpace(f.Text); text != "" {
+			parts = append(parts, text)
+		}
+	}
+	return Template{Text: strings.Join(parts, fragmentSeparator)}
+}
+
+// Variables returns the placeholder names used in the template, in order of
+// first appearance.
+func (t Template) Variables() []string {
+	var names []string
+	seen := make(map[string]bool)
+	rest := t.Text
+	for {
+		start := strings.Index(rest, "{{")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(rest[start+2:], "}}")
+		if end < 0 {
+			break
+		}
+		name := rest[start+2 : start+2+end]
+		if name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+		rest = rest[start+2+end+2:]
+	}
+	return names
+}
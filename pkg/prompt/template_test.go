@@ -0,0 +1,27 @@
+package prompt
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompose(t *testing.T) {
+	persona := NewTemplate("You are {{name}}, a coding assistant.")
+	safety := NewTemplate("Never reveal secrets from {{project}}.\n")
+	format := NewTemplate("Answer in {{language}}. Sign as {{name}}.")
+
+	composed := Compose(persona, NewTemplate(""), safety, format)
+
+	wantVars := []string{"name", "project", "language"}
+	if got := composed.Variables(); !reflect.DeepEqual(got, wantVars) {
+		t.Errorf("Variables() = %v, want %v", got, wantVars)
+	}
+
+	got := composed.Render(map[string]any{"name": "Giai", "project": "acme", "language": "English"})
+	want := "You are Giai, a coding assistant.\n\n" +
+		"Never reveal secrets from acme.\n\n" +
+		"Answer in English. Sign as Giai."
+	if got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,653 @@
+package anthropic
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"giai/pkg/provider"
+	"giai/pkg/types"
+)
+
+// Config contains Anthropic credential and runtime options.
+type Config struct {
+	APIKey      string
+	BaseURL     string // Defaults to https://api.anthropic.com
+	Model       string
+	HTTPClient  *http.Client
+	Temperature float64 // Default temperature
+	MaxTokens   int     // Default max_tokens, which the API requires; defaults to 4096
+	// StrictSampling rejects an out-of-range temperature or top_p with a
+	// *provider.ParamRangeError instead of clamping it into range.
+	StrictSampling bool
+	Logger         provider.Logger // Optional: notified when a parameter is clamped
+}
+
+// ChatModel implements provider.ChatModel using the Anthropic Messages API.
+type ChatModel struct {
+	apiKey             string
+	baseURL            string
+	client             *http.Client
+	defaultModel       string
+	defaultTemperature float64
+	defaultMaxTokens   int
+	strictSampling     bool
+	logger             provider.Logger
+}
+
+const (
+	defaultBaseURL     = "https://api.anthropic.com"
+	defaultModel       = "claude-3-5-sonnet-latest"
+	defaultTemperature = 0.7
+	defaultMaxTokens   = 4096
+	apiVersion         = "2023-06-01"
+)
+
+// MetadataThinking is the assistant message metadata key holding the thinking
+// blocks of an extended-thinking response. The API requires them, signatures
+// intact, when the message is sent back alongside tool results.
+const MetadataThinking = "anthropic_thinking"
+
+// samplingRange is the temperature and top_p range the API accepts.
+var samplingRange = provider.SamplingRange{MaxTemperature: 1, MaxTopP: 1}
+
+// NewChatModel builds an Anthropic chat provider.
+func NewChatModel(cfg Config) (provider.ChatModel, error) {
+	if cfg.APIKey == "" {
+		return nil, fmt.Errorf("anthropic api key is required")
+	}
+
+	baseURL := strings.TrimRight(cfg.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	client := cfg.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	modelName := cfg.Model
+	if strings.TrimSpace(modelName) == "" {
+		modelName = defaultModel
+	}
+	temp := cfg.Temperature
+	if temp == 0 {
+		temp = defaultTemperature
+	}
+	maxTokens := cfg.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = defaultMaxTokens
+	}
+
+	return &ChatModel{
+		apiKey:             cfg.APIKey,
+		baseURL:            baseURL,
+		client:             client,
+		defaultModel:       modelName,
+		defaultTemperature: temp,
+		defaultMaxTokens:   maxTokens,
+		strictSampling:     cfg.StrictSampling,
+		logger:             cfg.Logger,
+	}, nil
+}
+
+func (m *ChatModel) Name() string {
+	return "anthropic"
+}
+
+// Capabilities reports what the configured default model supports.
+func (m *ChatModel) Capabilities() provider.Capabilities {
+	return provider.CapabilitiesForModel(m.defaultModel)
+}
+
+// Wire types of the Messages API.
+
+type messagesRequest struct {
+	Model         string         `json:"model"`
+	MaxTokens     int            `json:"max_tokens"`
+	System        string         `json:"system,omitempty"`
+	Messages      []message      `json:"messages"`
+	Temperature   *float64       `json:"temperature,omitempty"`
+	TopP          *float64       `json:"top_p,omitempty"`
+	StopSequences []string       `json:"stop_sequences,omitempty"`
+	Tools         []toolSpec     `json:"tools,omitempty"`
+	ToolChoice    map[string]any `json:"tool_choice,omitempty"`
+	Thinking      *thinking      `json:"thinking,omitempty"`
+	Metadata      *metadata      `json:"metadata,omitempty"`
+	Stream        bool           `json:"stream,omitempty"`
+}
+
+type message struct {
+	Role    string         `json:"role"`
+	Content []contentBlock `json:"content"`
+}
+
+type contentBlock struct {
+	Type string `json:"type"`
+
+	Text string `json:"text,omitempty"` // text
+
+	Source *imageSource `json:"source,omitempty"` // image
+
+	Thinking  string `json:"thinking,omitempty"`  // thinking
+	Signature string `json:"signature,omitempty"` // thinking
+	Data      string `json:"data,omitempty"`      // redacted_thinking
+
+	ID    string          `json:"id,omitempty"`    // tool_use
+	Name  string          `json:"name,omitempty"`  // tool_use
+	Input json.RawMessage `json:"input,omitempty"` // tool_use
+
+	ToolUseID string         `json:"tool_use_id,omitempty"` // tool_result
+	Content   []contentBlock `json:"content,omitempty"`     // tool_result
+	IsError   bool           `json:"is_error,omitempty"`    // tool_result
+}
+
+type imageSource struct {
+	Type      string `json:"type"` // "base64" or "url"
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+type toolSpec struct {
+	Type        string `json:"type,omitempty"` // Server tools only, e.g. "web_search_20250305"
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	InputSchema any    `json:"input_schema,omitempty"`
+}
+
+type thinking struct {
+	Type         string `json:"type"`
+	BudgetTokens int    `json:"budget_tokens"`
+}
+
+type metadata struct {
+	UserID string `json:"user_id,omitempty"`
+}
+
+type messagesResponse struct {
+	ID         string         `json:"id"`
+	Model      string         `json:"model"`
+	Content    []contentBlock `json:"content"`
+	StopReason string         `json:"stop_reason"`
+	Usage      usage          `json:"usage"`
+}
+
+type usage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+type apiError struct {
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (m *ChatModel) prepareRequest(messages []types.Message, opts []provider.Option) (messagesRequest, error) {
+	// 1. Apply options
+	options := provider.ResolveOptions(provider.ChatOptions{
+		Model:       m.defaultModel,
+		Temperature: m.defaultTemperature,
+		MaxTokens:   m.defaultMaxTokens,
+	}, opts...)
+	if err := provider.ClampSampling(&options, samplingRange, m.strictSampling, m.logger); err != nil {
+		return messagesRequest{}, err
+	}
+	if err := provider.ValidateToolChoice(options.ToolChoice); err != nil {
+		return messagesRequest{}, err
+	}
+
+	// 2. Convert Messages; system prompts go to the top-level system field.
+	req := messagesRequest{
+		Model:         options.Model,
+		MaxTokens:     options.MaxTokens,
+		StopSequences: options.Stop,
+	}
+	var system []string
+	for _, msg := range messages {
+		if msg.Role == types.RoleSystem {
+			system = append(system, msg.Content)
+			continue
+		}
+		role, blocks, err := convertMessage(msg)
+		if err != nil {
+			return messagesRequest{}, err
+		}
+		// Roles must alternate: consecutive tool results, or a user message
+		// after them, share one user turn.
+		if n := len(req.Messages); n > 0 && req.Messages[n-1].Role == role {
+			req.Messages[n-1].Content = append(req.Messages[n-1].Content, blocks...)
+			continue
+		}
+		req.Messages = append(req.Messages, message{Role: role, Content: blocks})
+	}
+	req.System = strings.Join(system, "\n\n")
+
+	// 3. Sampling and extras
+	if options.ThinkingBudget > 0 {
+		// Extended thinking rejects custom sampling parameters.
+		req.Thinking = &thinking{Type: "enabled", BudgetTokens: options.ThinkingBudget}
+		// max_tokens covers thinking too and must exceed the budget; keep the
+		// default room for the answer on top of it.
+		if req.MaxTokens <= options.ThinkingBudget {
+			req.MaxTokens = options.ThinkingBudget + m.defaultMaxTokens
+		}
+	} else {
+		req.Temperature = &options.Temperature
+		if options.TopP > 0 {
+			req.TopP = &options.TopP
+		}
+	}
+	if options.User != "" {
+		req.Metadata = &metadata{UserID: options.User}
+	}
+
+	// 4. Handle Tools
+	for _, t := range options.Tools {
+		req.Tools = append(req.Tools, toolSpec{
+			Name:        t.Function.Name,
+			Description: t.Function.Description,
+			InputSchema: t.Function.Parameters,
+		})
+	}
+	// Server tools run on Anthropic's side; their results come back as
+	// server_tool_use blocks, which are not surfaced as tool calls.
+	for _, typ := range options.ServerTools {
+		req.Tools = append(req.Tools, toolSpec{Type: typ, Name: serverToolName(typ)})
+	}
+	if len(req.Tools) > 0 {
+		req.ToolChoice = convertToolChoice(options.ToolChoice, options.ParallelToolCalls)
+	}
+
+	return req, nil
+}
+
+// serverToolName derives a server tool's name from its versioned type, which
+// is the name followed by a date: "web_search_20250305" is "web_search".
+func serverToolName(typ string) string {
+	if i := strings.LastIndexByte(typ, '_'); i > 0 && len(typ)-i == 9 {
+		if _, err := strconv.Atoi(typ[i+1:]); err == nil {
+			return typ[:i]
+		}
+	}
+	return typ
+}
+
+// convertMessage maps a message to an Anthropic role and content blocks.
+func convertMessage(msg types.Message) (string, []contentBlock, error) {
+	switch msg.Role {
+	case types.RoleAssistant:
+		// Thinking must be passed back unchanged, ahead of the tool use it led to.
+		blocks := thinkingBlocks(msg.Metadata[MetadataThinking])
+		if msg.Content != "" {
+			blocks = append(blocks, contentBlock{Type: "text", Text: msg.Content})
+		}
+		for _, tc := range msg.ToolCalls {
+			input := json.RawMessage(tc.Function.Arguments)
+			if strings.TrimSpace(tc.Function.Arguments) == "" {
+				input = json.RawMessage("{}")
+			} else if !json.Valid(input) {
+				return "", nil, fmt.Errorf("anthropic: tool call %s has invalid JSON arguments", tc.ID)
+			}
+			blocks = append(blocks, contentBlock{Type: "tool_use", ID: tc.ID, Name: tc.Function.Name, Input: input})
+		}
+		return "assistant", blocks, nil
+	case types.RoleTool:
+		result := contentBlock{
+			Type:      "tool_result",
+			ToolUseID: msg.ToolCallID,
+			Content:   userBlocks(msg),
+			IsError:   msg.Metadata["error"] == true,
+		}
+		return "user", []contentBlock{result}, nil
+	default:
+		return "user", userBlocks(msg), nil
+	}
+}
+
+// userBlocks renders text and image parts; tool results accept the same blocks.
+func userBlocks(msg types.Message) []contentBlock {
+	var blocks []contentBlock
+	if msg.Content != "" {
+		blocks = append(blocks, contentBlock{Type: "text", Text: msg.Content})
+	}
+	for _, p := range msg.Parts {
+		switch p.Type {
+		case types.PartText:
+			blocks = append(blocks, contentBlock{Type: "text", Text: p.Text})
+		case types.PartImage:
+			src := &imageSource{Type: "url", URL: p.ImageURL}
+			if p.ImageURL == "" {
+				src = &imageSource{Type: "base64", MediaType: p.MIMEType, Data: base64.StdEncoding.EncodeToString(p.Data)}
+			}
+			blocks = append(blocks, contentBlock{Type: "image", Source: src})
+		}
+	}
+	if len(blocks) == 0 {
+		// The API rejects empty content.
+		blocks = append(blocks, contentBlock{Type: "text", Text: " "})
+	}
+	return blocks
+}
+
+// convertToolChoice maps an OpenAI-style tool choice onto Anthropic's.
+func convertToolChoice(choice any, parallel *bool) map[string]any {
+	var out map[string]any
+	switch c := choice.(type) {
+	case string:
+		switch c {
+		case "none":
+			out = map[string]any{"type": "none"}
+		case "required":
+			out = map[string]any{"type": "any"}
+		}
+	case map[string]any:
+		fn, _ := c["function"].(map[string]any)
+		out = map[string]any{"type": "tool", "name": fn["name"]}
+	}
+	if parallel != nil && !*parallel {
+		if out == nil {
+			out = map[string]any{"type": "auto"}
+		}
+		if out["type"] != "none" {
+			out["disable_parallel_tool_use"] = true
+		}
+	}
+	return out
+}
+
+// finishReasons maps Anthropic stop reasons onto the OpenAI-style values used elsewhere.
+var finishReasons = map[string]string{
+	"end_turn":      "stop",
+	"stop_sequence": "stop",
+	"max_tokens":    "length",
+	"tool_use":      "tool_calls",
+	"refusal":       "content_filter",
+}
+
+func finishReason(stop string) string {
+	if r, ok := finishReasons[stop]; ok {
+		return r
+	}
+	return stop
+}
+
+// Chat implements provider.ChatModel.Chat
+func (m *ChatModel) Chat(ctx context.Context, messages []types.Message, opts ...provider.Option) (*types.ChatResponse, error) {
+	req, err := m.prepareRequest(messages, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := m.do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var resp messagesResponse
+	if err := json.NewDecoder(body).Decode(&resp); err != nil {
+		return nil, provider.NewError("anthropic", 0, "", "invalid response", err)
+	}
+
+	chatMsg := types.Message{Role: types.RoleAssistant}
+	var text strings.Builder
+	var thought []contentBlock
+	for _, b := range resp.Content {
+		switch b.Type {
+		case "text":
+			text.WriteString(b.Text)
+		case "tool_use":
+			args := string(b.Input)
+			if args == "" {
+				args = "{}"
+			}
+			chatMsg.ToolCalls = append(chatMsg.ToolCalls, types.NewToolCall(b.ID, b.Name, args))
+		case "thinking", "redacted_thinking":
+			thought = append(thought, b)
+		}
+	}
+	chatMsg.Content = text.String()
+	if len(thought) > 0 {
+		chatMsg.Metadata = map[string]any{MetadataThinking: thought}
+	}
+
+	out := &types.ChatResponse{
+		ID:           resp.ID,
+		Message:      chatMsg,
+		FinishReason: finishReason(resp.StopReason),
+		Model:        resp.Model,
+		Usage: types.Usage{
+			PromptTokens:     resp.Usage.InputTokens,
+			CompletionTokens: resp.Usage.OutputTokens,
+			TotalTokens:      resp.Usage.InputTokens + resp.Usage.OutputTokens,
+		},
+	}
+	if provider.ResolveOptions(provider.ChatOptions{}, opts...).IncludeRaw {
+		out.Raw = resp
+	}
+	return out, nil
+}
+
+// streamEvent covers the server-sent events of a streamed response.
+type streamEvent struct {
+	Type    string `json:"type"`
+	Index   int    `json:"index"`
+	Message struct {
+		ID    string `json:"id"`
+		Usage usage  `json:"usage"`
+	} `json:"message"`
+	ContentBlock contentBlock `json:"content_block"`
+	Delta        struct {
+		Type        string `json:"type"`
+		Text        string `json:"text"`
+		PartialJSON string `json:"partial_json"`
+		Thinking    string `json:"thinking"`
+		Signature   string `json:"signature"`
+		StopReason  string `json:"stop_reason"`
+	} `json:"delta"`
+	Usage *usage `json:"usage"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Stream implements provider.ChatModel.Stream
+func (m *ChatModel) Stream(ctx context.Context, messages []types.Message, opts ...provider.Option) (<-chan provider.ChatChunk, error) {
+	req, err := m.prepareRequest(messages, opts)
+	if err != nil {
+		return nil, err
+	}
+	req.Stream = true
+
+	body, err := m.do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan provider.ChatChunk)
+	go func() {
+		defer close(ch)
+		defer body.Close()
+
+		// As in the openai provider, the latest finish reason and usage ride
+		// along on an error chunk.
+		var (
+			id           string
+			finish       string
+			inputTokens  int
+			usageSoFar   *types.Usage
+			scanner      = bufio.NewScanner(body)
+			sawTerminate bool
+			thought      []contentBlock
+			thoughtAt    = make(map[int]int)  // Index in thought of each thinking block
+			toolUses     = make(map[int]bool) // Indexes of tool_use blocks, whose input is streamed
+		)
+		scanner.Buffer(make([]byte, 64*1024), 1<<20)
+		for scanner.Scan() {
+			data, ok := strings.CutPrefix(scanner.Text(), "data:")
+			if !ok {
+				continue // event names, comments and blank separators
+			}
+			var ev streamEvent
+			if err := json.Unmarshal([]byte(strings.TrimSpace(data)), &ev); err != nil {
+				ch <- provider.ChatChunk{Error: provider.NewError("anthropic", 0, "", "invalid stream event", err), FinishReason: finish, Usage: usageSoFar}
+				return
+			}
+
+			switch ev.Type {
+			case "message_start":
+				id = ev.Message.ID
+				inputTokens = ev.Message.Usage.InputTokens
+			case "content_block_start":
+				switch {
+				case ev.ContentBlock.Type == "tool_use":
+					toolUses[ev.Index] = true
+					call := types.NewToolCall(ev.ContentBlock.ID, ev.ContentBlock.Name, "")
+					call.Index = ev.Index
+					ch <- provider.ChatChunk{ToolCall: &call, ID: id}
+				case ev.ContentBlock.Type == "thinking" || ev.ContentBlock.Type == "redacted_thinking":
+					thoughtAt[ev.Index] = len(thought)
+					thought = append(thought, ev.ContentBlock)
+				case ev.ContentBlock.Text != "":
+					ch <- provider.ChatChunk{Content: ev.ContentBlock.Text, ID: id}
+				}
+			case "content_block_delta":
+				switch ev.Delta.Type {
+				case "text_delta":
+					ch <- provider.ChatChunk{Content: ev.Delta.Text, ID: id}
+				case "input_json_delta":
+					if !toolUses[ev.Index] {
+						continue // Input of a server tool, which already ran.
+					}
+					call := types.NewToolCall("", "", ev.Delta.PartialJSON)
+					call.Index = ev.Index
+					ch <- provider.ChatChunk{ToolCall: &call, ID: id}
+				case "thinking_delta":
+					if i, ok := thoughtAt[ev.Index]; ok {
+						thought[i].Thinking += ev.Delta.Thinking
+					}
+				case "signature_delta":
+					if i, ok := thoughtAt[ev.Index]; ok {
+						thought[i].Signature += ev.Delta.Signature
+					}
+				}
+			case "message_delta":
+				finish = finishReason(ev.Delta.StopReason)
+				if ev.Usage != nil {
+					usageSoFar = &types.Usage{
+						PromptTokens:     inputTokens,
+						CompletionTokens: ev.Usage.OutputTokens,
+						TotalTokens:      inputTokens + ev.Usage.OutputTokens,
+					}
+				}
+				chunk := provider.ChatChunk{FinishReason: finish, Usage: usageSoFar, ID: id}
+				if len(thought) > 0 {
+					chunk.Metadata = map[string]any{MetadataThinking: thought}
+				}
+				ch <- chunk
+			case "message_stop":
+				sawTerminate = true
+				return
+			case "error":
+				err := errors.New(ev.Error.Message)
+				ch <- provider.ChatChunk{Error: provider.NewError("anthropic", statusForErrorType(ev.Error.Type), ev.Error.Type, ev.Error.Message, err), FinishReason: finish, Usage: usageSoFar}
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			ch <- provider.ChatChunk{Error: provider.NewError("anthropic", 0, "", "", err), FinishReason: finish, Usage: usageSoFar}
+		} else if !sawTerminate {
+			ch <- provider.ChatChunk{Error: provider.NewError("anthropic", 0, "", "stream ended before message_stop", io.ErrUnexpectedEOF), FinishReason: finish, Usage: usageSoFar}
+		}
+	}()
+
+	return ch, nil
+}
+
+// do sends req and returns the response body, normalizing API failures.
+func (m *ChatModel) do(ctx context.Context, req messagesRequest) (io.ReadCloser, error) {
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("anthropic: encode request: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, m.baseURL+"/v1/messages", bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("anthropic: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-api-key", m.apiKey)
+	httpReq.Header.Set("anthropic-version", apiVersion)
+
+	resp, err := m.client.Do(httpReq)
+	if err != nil {
+		return nil, provider.NewError("anthropic", 0, "", "", err)
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return resp.Body, nil
+	}
+	defer resp.Body.Close()
+	return nil, wrapError(resp.StatusCode, resp.Body)
+}
+
+// Helpers
+
+// thinkingBlocks reads the blocks stored under MetadataThinking, whether kept
+// as returned or decoded from JSON after a round trip through storage.
+func thinkingBlocks(v any) []contentBlock {
+	if blocks, ok := v.([]contentBlock); ok {
+		return blocks
+	}
+	if v == nil {
+		return nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var blocks []contentBlock
+	if json.Unmarshal(raw, &blocks) != nil {
+		return nil
+	}
+	return blocks
+}
+
+// wrapError normalizes an Anthropic error response into *provider.Error.
+func wrapError(status int, body io.Reader) error {
+	raw, _ := io.ReadAll(io.LimitReader(body, 1<<16))
+	var apiErr apiError
+	msg := strings.TrimSpace(string(raw))
+	if json.Unmarshal(raw, &apiErr) == nil && apiErr.Error.Message != "" {
+		msg = apiErr.Error.Message
+	}
+	if msg == "" {
+		msg = http.StatusText(status)
+	}
+	return provider.NewError("anthropic", status, apiErr.Error.Type, msg, errors.New(msg))
+}
+
+// statusForErrorType maps error types reported mid-stream to their HTTP
+// status, so retry classification matches errors returned up front.
+func statusForErrorType(t string) int {
+	switch t {
+	case "overloaded_error":
+		return 529
+	case "rate_limit_error":
+		return http.StatusTooManyRequests
+	case "api_error":
+		return http.StatusInternalServerError
+	case "invalid_request_error":
+		return http.StatusBadRequest
+	}
+	return 0
+}
@@ -0,0 +1,303 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"giai/pkg/provider"
+	"giai/pkg/types"
+)
+
+func TestNewChatModel(t *testing.T) {
+	if _, err := NewChatModel(Config{}); err == nil {
+		t.Error("NewChatModel() expected error for missing API key")
+	}
+	m, err := NewChatModel(Config{APIKey: "test-key"})
+	if err != nil {
+		t.Fatalf("NewChatModel() error = %v", err)
+	}
+	if !provider.CapabilitiesOf(m).Tools {
+		t.Errorf("Capabilities() = %+v, want tools for the default model", provider.CapabilitiesOf(m))
+	}
+}
+
+func TestPrepareRequest_Messages(t *testing.T) {
+	m, _ := NewChatModel(Config{APIKey: "test-key"})
+	call := types.NewToolCall("toolu_1", "lookup", `{"q":"go"}`)
+	msgs := []types.Message{
+		{Role: types.RoleSystem, Content: "be brief"},
+		{Role: types.RoleUser, Content: "search"},
+		{Role: types.RoleAssistant, ToolCalls: []types.ToolCall{call}},
+		{Role: types.RoleTool, ToolCallID: "toolu_1", Content: "boom", Metadata: map[string]any{"error": true}},
+		{Role: types.RoleUser, Content: "try again"},
+	}
+	def := types.NewToolDefinition("lookup", "search things", map[string]any{"type": "object"})
+
+	req, err := m.(*ChatModel).prepareRequest(msgs, []provider.Option{
+		provider.WithTools(def),
+		provider.WithToolChoice(provider.ForceTool("lookup")),
+	})
+	if err != nil {
+		t.Fatalf("prepareRequest() error = %v", err)
+	}
+
+	if req.System != "be brief" {
+		t.Errorf("System = %q, want the system prompt", req.System)
+	}
+	if len(req.Messages) != 3 {
+		t.Fatalf("got %d messages, want user, assistant and a merged user turn: %+v", len(req.Messages), req.Messages)
+	}
+	use := req.Messages[1].Content[0]
+	if req.Messages[1].Role != "assistant" || use.Type != "tool_use" || use.ID != "toolu_1" || string(use.Input) != `{"q":"go"}` {
+		t.Errorf("assistant content = %+v, want a tool_use block", req.Messages[1].Content)
+	}
+	turn := req.Messages[2]
+	if turn.Role != "user" || len(turn.Content) != 2 {
+		t.Fatalf("last turn = %+v, want the tool result and the user text", turn)
+	}
+	if r := turn.Content[0]; r.Type != "tool_result" || r.ToolUseID != "toolu_1" || !r.IsError || r.Content[0].Text != "boom" {
+		t.Errorf("tool result = %+v", r)
+	}
+	if turn.Content[1].Text != "try again" {
+		t.Errorf("user text = %+v", turn.Content[1])
+	}
+	if len(req.Tools) != 1 || req.Tools[0].Name != "lookup" {
+		t.Errorf("Tools = %+v", req.Tools)
+	}
+	if req.ToolChoice["type"] != "tool" || req.ToolChoice["name"] != "lookup" {
+		t.Errorf("ToolChoice = %v, want the forced tool", req.ToolChoice)
+	}
+}
+
+func TestPrepareRequest_ServerTools(t *testing.T) {
+	m, _ := NewChatModel(Config{APIKey: "test-key"})
+	req, err := m.(*ChatModel).prepareRequest([]types.Message{{Role: types.RoleUser, Content: "news?"}}, []provider.Option{
+		provider.WithTools(types.NewToolDefinition("lookup", "Look up.", map[string]any{"type": "object"})),
+		provider.WithServerTools("web_search_20250305"),
+	})
+	if err != nil {
+		t.Fatalf("prepareRequest() error = %v", err)
+	}
+	got, err := json.Marshal(req.Tools)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	want := `[{"name":"lookup","description":"Look up.","input_schema":{"type":"object"}},{"type":"web_search_20250305","name":"web_search"}]`
+	if string(got) != want {
+		t.Errorf("tools = %s, want %s", got, want)
+	}
+}
+
+func TestChat(t *testing.T) {
+	var got messagesRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" || r.Header.Get("x-api-key") != "test-key" || r.Header.Get("anthropic-version") == "" {
+			t.Errorf("unexpected request %s %v", r.URL.Path, r.Header)
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		fmt.Fprint(w, `{"id":"msg_1","model":"claude-3-5-sonnet-latest","stop_reason":"tool_use",
+			"content":[{"type":"text","text":"Let me check."},{"type":"tool_use","id":"toolu_1","name":"lookup","input":{"q":"go"}}],
+			"usage":{"input_tokens":12,"output_tokens":5}}`)
+	}))
+	defer srv.Close()
+
+	m, _ := NewChatModel(Config{APIKey: "test-key", BaseURL: srv.URL})
+	resp, err := m.Chat(context.Background(), []types.Message{{Role: types.RoleUser, Content: "hi"}})
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if got.MaxTokens != defaultMaxTokens || got.Model != defaultModel {
+		t.Errorf("request = %+v, want default model and max_tokens", got)
+	}
+	if resp.Message.Content != "Let me check." || resp.FinishReason != "tool_calls" {
+		t.Errorf("Chat() = %+v", resp)
+	}
+	if len(resp.Message.ToolCalls) != 1 || resp.Message.ToolCalls[0].Function.Arguments != `{"q":"go"}` {
+		t.Errorf("ToolCalls = %+v", resp.Message.ToolCalls)
+	}
+	if resp.Usage != (types.Usage{PromptTokens: 12, CompletionTokens: 5, TotalTokens: 17}) {
+		t.Errorf("Usage = %+v", resp.Usage)
+	}
+}
+
+func TestChat_Thinking(t *testing.T) {
+	var got messagesRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		fmt.Fprint(w, `{"id":"msg_1","stop_reason":"tool_use",
+			"content":[{"type":"thinking","thinking":"Look it up.","signature":"sig_1"},{"type":"redacted_thinking","data":"opaque"},
+				{"type":"tool_use","id":"toolu_1","name":"lookup","input":{"q":"go"}}],
+			"usage":{"input_tokens":12,"output_tokens":5}}`)
+	}))
+	defer srv.Close()
+
+	m, _ := NewChatModel(Config{APIKey: "test-key", BaseURL: srv.URL})
+	resp, err := m.Chat(context.Background(), []types.Message{{Role: types.RoleUser, Content: "hi"}}, provider.WithThinkingBudget(8000))
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if got.Thinking == nil || got.MaxTokens <= got.Thinking.BudgetTokens {
+		t.Errorf("max_tokens = %d with thinking %+v, want room beyond the budget", got.MaxTokens, got.Thinking)
+	}
+
+	// The thinking must survive storage as JSON and go back first, unchanged.
+	stored, err := json.Marshal(resp.Message)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var restored types.Message
+	if err := json.Unmarshal(stored, &restored); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	for _, msg := range []types.Message{resp.Message, restored} {
+		_, blocks, err := convertMessage(msg)
+		if err != nil {
+			t.Fatalf("convertMessage() error = %v", err)
+		}
+		if len(blocks) != 3 || blocks[0].Type != "thinking" || blocks[0].Signature != "sig_1" || blocks[0].Thinking != "Look it up." ||
+			blocks[1].Type != "redacted_thinking" || blocks[1].Data != "opaque" || blocks[2].Type != "tool_use" {
+			t.Errorf("assistant blocks = %+v, want the thinking blocks ahead of the tool use", blocks)
+		}
+	}
+}
+
+func TestChat_Error(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		fmt.Fprint(w, `{"type":"error","error":{"type":"rate_limit_error","message":"slow down"}}`)
+	}))
+	defer srv.Close()
+
+	m, _ := NewChatModel(Config{APIKey: "test-key", BaseURL: srv.URL})
+	_, err := m.Chat(context.Background(), []types.Message{{Role: types.RoleUser, Content: "hi"}})
+	var perr *provider.Error
+	if !errors.As(err, &perr) {
+		t.Fatalf("Chat() error = %v, want *provider.Error", err)
+	}
+	if perr.StatusCode != 429 || perr.Code != "rate_limit_error" || perr.Message != "slow down" || !perr.Retryable {
+		t.Errorf("error = %+v", perr)
+	}
+}
+
+const streamBody = `event: message_start
+data: {"type":"message_start","message":{"id":"msg_2","usage":{"input_tokens":9,"output_tokens":1}}}
+
+event: content_block_start
+data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hel"}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"lo"}}
+
+event: content_block_start
+data: {"type":"content_block_start","index":1,"content_block":{"type":"server_tool_use","id":"srvtoolu_1","name":"web_search","input":{}}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"query\":\"go\"}"}}
+
+event: content_block_start
+data: {"type":"content_block_start","index":2,"content_block":{"type":"tool_use","id":"toolu_9","name":"lookup","input":{}}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":2,"delta":{"type":"input_json_delta","partial_json":"{\"q\":"}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":2,"delta":{"type":"input_json_delta","partial_json":"\"go\"}"}}
+
+event: message_delta
+data: {"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":7}}
+
+event: message_stop
+data: {"type":"message_stop"}
+
+`
+
+func TestStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req messagesRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if !req.Stream {
+			t.Error("request did not ask for a stream")
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, streamBody)
+	}))
+	defer srv.Close()
+
+	m, _ := NewChatModel(Config{APIKey: "test-key", BaseURL: srv.URL})
+	var deltas string
+	resp, err := provider.StreamAndCollect(context.Background(), m, []types.Message{{Role: types.RoleUser, Content: "hi"}}, func(d string) { deltas += d })
+	if err != nil {
+		t.Fatalf("StreamAndCollect() error = %v", err)
+	}
+	if deltas != "Hello" || resp.Message.Content != "Hello" {
+		t.Errorf("content = %q (deltas %q), want Hello", resp.Message.Content, deltas)
+	}
+	if len(resp.Message.ToolCalls) != 1 {
+		t.Fatalf("ToolCalls = %+v, want one", resp.Message.ToolCalls)
+	}
+	if tc := resp.Message.ToolCalls[0]; tc.ID != "toolu_9" || tc.Function.Name != "lookup" || tc.Function.Arguments != `{"q":"go"}` {
+		t.Errorf("tool call = %+v", tc)
+	}
+	if resp.FinishReason != "tool_calls" || resp.Usage.TotalTokens != 16 {
+		t.Errorf("finish = %q, usage = %+v", resp.FinishReason, resp.Usage)
+	}
+}
+
+func TestStream_Thinking(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `data: {"type":"message_start","message":{"id":"msg_3","usage":{"input_tokens":9}}}
+
+data: {"type":"content_block_start","index":0,"content_block":{"type":"thinking","thinking":""}}
+
+data: {"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"Look "}}
+
+data: {"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"it up."}}
+
+data: {"type":"content_block_delta","index":0,"delta":{"type":"signature_delta","signature":"sig_1"}}
+
+data: {"type":"content_block_start","index":1,"content_block":{"type":"text","text":"Done."}}
+
+data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":7}}
+
+data: {"type":"message_stop"}
+
+`)
+	}))
+	defer srv.Close()
+
+	m, _ := NewChatModel(Config{APIKey: "test-key", BaseURL: srv.URL})
+	var deltas string
+	resp, err := provider.StreamAndCollect(context.Background(), m, []types.Message{{Role: types.RoleUser, Content: "hi"}}, func(d string) { deltas += d })
+	if err != nil {
+		t.Fatalf("StreamAndCollect() error = %v", err)
+	}
+	if deltas != "Done." {
+		t.Errorf("deltas = %q, want only the answer text", deltas)
+	}
+	blocks := thinkingBlocks(resp.Message.Metadata[MetadataThinking])
+	if len(blocks) != 1 || blocks[0].Thinking != "Look it up." || blocks[0].Signature != "sig_1" {
+		t.Errorf("thinking = %+v, want the assembled block with its signature", blocks)
+	}
+}
+
+func TestStream_ErrorEvent(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "event: error\ndata: {\"type\":\"error\",\"error\":{\"type\":\"overloaded_error\",\"message\":\"Overloaded\"}}\n\n")
+	}))
+	defer srv.Close()
+
+	m, _ := NewChatModel(Config{APIKey: "test-key", BaseURL: srv.URL})
+	_, err := provider.StreamAndCollect(context.Background(), m, []types.Message{{Role: types.RoleUser, Content: "hi"}}, nil)
+	var perr *provider.Error
+	if !errors.As(err, &perr) || perr.Code != "overloaded_error" || !perr.Retryable {
+		t.Errorf("StreamAndCollect() error = %v, want a retryable overloaded_error", err)
+	}
+}
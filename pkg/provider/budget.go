@@ -0,0 +1,113 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"giai/pkg/types"
+)
+
+// ErrBudgetExceeded is returned by a Budgeted model once its session has used
+// up its completion token limit. The call is rejected before reaching the provider.
+var ErrBudgetExceeded = errors.New("completion token budget exceeded")
+
+type budgeted struct {
+	inner ChatModel
+	limit int
+
+	mu   sync.Mutex
+	used int
+}
+
+// Budgeted wraps inner and caps the completion tokens it may spend in total.
+// Usage is accumulated from each response, or from the last usage a stream
+// reports (it is cumulative), charged once when the stream ends; a stream
+// that reports no usage is charged an estimate of its output. Once usage
+// reaches limit, further calls fail with ErrBudgetExceeded. The call that
+// crosses the limit still completes, so spend can overshoot by one response.
+// Create one Budgeted per session, alongside that session's memory.
+func Budgeted(inner ChatModel, limit int) ChatModel {
+	return &budgeted{inner: inner, limit: limit}
+}
+
+func (b *budgeted) Name() string {
+	return b.inner.Name()
+}
+
+// check rejects the call when the budget is spent.
+func (b *budgeted) check() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.used >= b.limit {
+		return fmt.Errorf("%w: used %d of %d tokens", ErrBudgetExceeded, b.used, b.limit)
+	}
+	return nil
+}
+
+func (b *budgeted) charge(usage types.Usage) {
+	b.mu.Lock()
+	b.used += usage.CompletionTokens
+	b.mu.Unlock()
+}
+
+// Chat implements ChatModel.Chat
+func (b *budgeted) Chat(ctx context.Context, messages []types.Message, opts ...Option) (*types.ChatResponse, error) {
+	if err := b.check(); err != nil {
+		return nil, err
+	}
+	resp, err := b.inner.Chat(ctx, messages, opts...)
+	if err != nil {
+		return nil, err
+	}
+	b.charge(resp.Usage)
+	return resp, nil
+}
+
+// Stream implements ChatModel.Stream
+func (b *budgeted) Stream(ctx context.Context, messages []types.Message, opts ...Option) (<-chan ChatChunk, error) {
+	if err := b.check(); err != nil {
+		return nil, err
+	}
+	stream, err := b.inner.Stream(ctx, messages, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan ChatChunk)
+	go func() {
+		defer close(out)
+		var (
+			usage  *types.Usage
+			output strings.Builder
+		)
+		defer func() {
+			if usage != nil {
+				b.charge(*usage)
+				return
+			}
+			model := ResolveOptions(ChatOptions{}, opts...).Model
+			b.charge(types.Usage{CompletionTokens: TokenizerForModel(model).Count(output.String())})
+		}()
+		for chunk := range stream {
+			// Usage is cumulative and may repeat, e.g. on a trailing error chunk.
+			if chunk.Usage != nil {
+				usage = chunk.Usage
+			}
+			output.WriteString(chunk.Content)
+			if chunk.ToolCall != nil {
+				output.WriteString(chunk.ToolCall.Function.Name)
+				output.WriteString(chunk.ToolCall.Function.Arguments)
+			}
+			if !forward(ctx, out, chunk) {
+				drain(stream)
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+var _ ChatModel = (*budgeted)(nil)
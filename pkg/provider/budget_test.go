@@ -0,0 +1,107 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"giai/pkg/types"
+)
+
+func TestBudgeted(t *testing.T) {
+	inner := &fakeModel{name: "inner", content: "ok", usage: types.Usage{CompletionTokens: 40}}
+	m := Budgeted(inner, 100)
+	ctx := context.Background()
+
+	// 40 + 40 + 40 crosses the limit on the third call.
+	for i := 0; i < 3; i++ {
+		if _, err := m.Chat(ctx, nil); err != nil {
+			t.Fatalf("Chat() call %d error = %v", i+1, err)
+		}
+	}
+
+	if _, err := m.Chat(ctx, nil); !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("Chat() error = %v, want ErrBudgetExceeded", err)
+	}
+	if _, err := m.Stream(ctx, nil); !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("Stream() error = %v, want ErrBudgetExceeded", err)
+	}
+	if got := inner.calls.Load(); got != 3 {
+		t.Errorf("inner calls = %d, want 3", got)
+	}
+}
+
+func TestBudgeted_Stream(t *testing.T) {
+	inner := &fakeModel{name: "inner", content: "ok", usage: types.Usage{CompletionTokens: 60}}
+	m := Budgeted(inner, 50)
+	ctx := context.Background()
+
+	stream, err := m.Stream(ctx, nil)
+	if err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+	drain(stream)
+
+	if _, err := m.Chat(ctx, nil); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("Chat() error = %v, want ErrBudgetExceeded after streamed usage", err)
+	}
+}
+
+func TestBudgeted_StreamWithoutUsage(t *testing.T) {
+	inner := &chunkModel{chunks: []ChatChunk{{Content: "one two three "}, {Content: "four five"}, {FinishReason: "stop"}}}
+	m := Budgeted(inner, 3)
+	ctx := context.Background()
+
+	stream, err := m.Stream(ctx, nil, WithModel("gpt-4o"))
+	if err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+	drain(stream)
+
+	if _, err := m.Stream(ctx, nil); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("Stream() error = %v, want ErrBudgetExceeded from the estimated usage", err)
+	}
+}
+
+func TestBudgeted_StreamChargesUsageOnce(t *testing.T) {
+	// The error chunk repeats the cumulative usage already reported.
+	usage := &types.Usage{CompletionTokens: 30}
+	inner := &chunkModel{chunks: []ChatChunk{
+		{Content: "partial"},
+		{FinishReason: "length", Usage: usage},
+		{Error: errors.New("connection reset"), FinishReason: "length", Usage: usage},
+	}}
+	m := Budgeted(inner, 50)
+	ctx := context.Background()
+
+	stream, err := m.Stream(ctx, nil)
+	if err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+	drain(stream)
+
+	stream, err = m.Stream(ctx, nil)
+	if err != nil {
+		t.Fatalf("Stream() error = %v, want 30 of 50 tokens used", err)
+	}
+	drain(stream)
+}
+
+func TestBudgeted_StreamAbandoned(t *testing.T) {
+	inner := &chunkModel{chunks: []ChatChunk{{Content: "a"}, {Content: "b"}, {Content: "c"}}}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	stream, err := Budgeted(inner, 100).Stream(ctx, nil)
+	if err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+	<-stream
+	cancel()
+
+	select {
+	case <-inner.done:
+	case <-time.After(time.Second):
+		t.Fatal("inner stream still blocked after the consumer went away")
+	}
+}
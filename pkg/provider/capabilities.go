@@ -0,0 +1,100 @@
+package provider
+
+import (
+	"sort"
+	"strings"
+)
+
+// Capabilities describes what a model supports.
+type Capabilities struct {
+	Tools             bool
+	Vision            bool
+	JSONMode          bool
+	StructuredOutputs bool // JSON schema response format
+	Streaming         bool
+	ParallelToolCalls bool
+	Reasoning         bool
+
+	// Known is false when the model is not in the capability table and the
+	// flags are conservative guesses rather than facts.
+	Known bool
+}
+
+// CapabilityReporter is implemented by providers that can describe their model.
+type CapabilityReporter interface {
+	Capabilities() Capabilities
+}
+
+// modelCapabilities maps model-name prefixes to capabilities.
+// The longest matching prefix wins, so specific entries override families.
+var modelCapabilities = map[string]Capabilities{
+	"gpt-3.5-turbo": {Tools: true, JSONMode: true, Streaming: true, ParallelToolCalls: true},
+	"gpt-4":         {Tools: true, Streaming: true},
+	"gpt-4-turbo":   {Tools: true, Vision: true, JSONMode: true, Streaming: true, ParallelToolCalls: true},
+	"gpt-4o":        {Tools: true, Vision: true, JSONMode: true, StructuredOutputs: true, Streaming: true, ParallelToolCalls: true},
+	"gpt-4.1":       {Tools: true, Vision: true, JSONMode: true, StructuredOutputs: true, Streaming: true, ParallelToolCalls: true},
+	"o1":            {Tools: true, Vision: true, JSONMode: true, StructuredOutputs: true, Streaming: true, Reasoning: true},
+	"o1-mini":       {Streaming: true, Reasoning: true},
+	"o1-preview":    {Streaming: true, Reasoning: true},
+	"o3":            {Tools: true, Vision: true, JSONMode: true, StructuredOutputs: true, Streaming: true, Reasoning: true},
+	"o3-mini":       {Tools: true, JSONMode: true, StructuredOutputs: true, Streaming: true, Reasoning: true},
+	"o4-mini":       {Tools: true, Vision: true, JSONMode: true, StructuredOutputs: true, Streaming: true, Reasoning: true},
+	"gemini-pro":    {Tools: true, Streaming: true},
+	"gemini-1.5":    {Tools: true, Vision: true, JSONMode: true, StructuredOutputs: true, Streaming: true, ParallelToolCalls: true},
+	"gemini-2":      {Tools: true, Vision: true, JSONMode: true, StructuredOutputs: true, Streaming: true, ParallelToolCalls: true},
+	"claude-3":      {Tools: true, Vision: true, Streaming: true, ParallelToolCalls: true},
+}
+
+// modelPrefixes holds the capability table keys, longest first.
+var modelPrefixes = sortedPrefixes(modelCapabilities)
+
+// CapabilitiesForModel looks up a model by name. Vendor prefixes such as
+// "openai/" (OpenRouter style) are ignored. Unknown models only report streaming.
+func CapabilitiesForModel(model string) Capabilities {
+	if prefix, ok := matchModelPrefix(model, modelPrefixes); ok {
+		c := modelCapabilities[prefix]
+		c.Known = true
+		return c
+	}
+	return Capabilities{Streaming: true}
+}
+
+// sortedPrefixes returns the keys of a model table, longest first.
+func sortedPrefixes[V any](table map[string]V) []string {
+	keys := make([]string, 0, len(table))
+	for k := range table {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) > len(keys[j])
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// matchModelPrefix finds the longest prefix (from a longest-first list) matching model.
+// A prefix matches the exact name or the name followed by "-" or "." (dated
+// snapshots, size variants). Vendor prefixes such as "openai/" are ignored.
+func matchModelPrefix(model string, prefixes []string) (string, bool) {
+	name := strings.ToLower(strings.TrimSpace(model))
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	for _, prefix := range prefixes {
+		if name == prefix || strings.HasPrefix(name, prefix+"-") || strings.HasPrefix(name, prefix+".") {
+			return prefix, true
+		}
+	}
+	return "", false
+}
+
+// CapabilitiesOf returns the capabilities reported by m, or conservative
+// defaults when the provider does not implement CapabilityReporter.
+func CapabilitiesOf(m ChatModel) Capabilities {
+	if r, ok := m.(CapabilityReporter); ok {
+		return r.Capabilities()
+	}
+	return Capabilities{Streaming: true}
+}
@@ -0,0 +1,26 @@
+package provider
+
+import "testing"
+
+func TestCapabilitiesForModel(t *testing.T) {
+	tests := []struct {
+		model string
+		want  Capabilities
+	}{
+		{"gpt-4o", Capabilities{Tools: true, Vision: true, JSONMode: true, StructuredOutputs: true, Streaming: true, ParallelToolCalls: true, Known: true}},
+		{"gpt-4o-mini-2024-07-18", Capabilities{Tools: true, Vision: true, JSONMode: true, StructuredOutputs: true, Streaming: true, ParallelToolCalls: true, Known: true}},
+		{"gpt-4-0613", Capabilities{Tools: true, Streaming: true, Known: true}},
+		{"o1-mini", Capabilities{Streaming: true, Reasoning: true, Known: true}},
+		{"openai/gpt-4o", Capabilities{Tools: true, Vision: true, JSONMode: true, StructuredOutputs: true, Streaming: true, ParallelToolCalls: true, Known: true}},
+		{"gemini-2.0-flash", Capabilities{Tools: true, Vision: true, JSONMode: true, StructuredOutputs: true, Streaming: true, ParallelToolCalls: true, Known: true}},
+		{"my-local-model", Capabilities{Streaming: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			if got := CapabilitiesForModel(tt.model); got != tt.want {
+				t.Errorf("CapabilitiesForModel(%q) = %+v, want %+v", tt.model, got, tt.want)
+			}
+		})
+	}
+}
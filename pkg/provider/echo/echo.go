@@ -3,19 +3,70 @@ package echo
 import (
 	"context"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"giai/pkg/provider"
 	"giai/pkg/types"
 )
 
 // ChatModel is a deterministic echo provider useful for tests and fallbacks.
+// Chat and Stream produce byte-identical content.
 type ChatModel struct {
 	Prefix string
+
+	transforms []func(string) string
+	chunkSize  int           // Runes per streamed chunk; 0 streams word by word
+	chunkDelay time.Duration // Pause before each streamed chunk
+}
+
+// Option configures an echo ChatModel.
+type Option func(*ChatModel)
+
+// WithReverse reverses the echoed content, so tests can tell a round-tripped
+// reply from the input.
+func WithReverse() Option {
+	return func(p *ChatModel) {
+		p.transforms = append(p.transforms, func(s string) string {
+			r := []rune(s)
+			for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+				r[i], r[j] = r[j], r[i]
+			}
+			return string(r)
+		})
+	}
+}
+
+// WithUppercase upper-cases the echoed content.
+func WithUppercase() Option {
+	return func(p *ChatModel) {
+		p.transforms = append(p.transforms, strings.ToUpper)
+	}
+}
+
+// WithChunkSize streams the content in chunks of n runes instead of word by
+// word. Multibyte characters are never split. n <= 0 keeps word chunks.
+func WithChunkSize(n int) Option {
+	return func(p *ChatModel) {
+		p.chunkSize = n
+	}
+}
+
+// WithChunkDelay pauses d before each streamed content chunk, simulating a
+// provider's cadence for UI and backpressure tests.
+func WithChunkDelay(d time.Duration) Option {
+	return func(p *ChatModel) {
+		p.chunkDelay = d
+	}
 }
 
-// New returns a new echo provider.
-func New(prefix string) provider.ChatModel {
-	return &ChatModel{Prefix: prefix}
+// New returns a new echo provider. Options are applied in order.
+func New(prefix string, opts ...Option) provider.ChatModel {
+	p := &ChatModel{Prefix: prefix}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
 }
 
 func (p *ChatModel) Name() string {
@@ -27,6 +78,11 @@ func (p *ChatModel) Name() string {
 
 // Chat implements provider.ChatModel
 func (p *ChatModel) Chat(ctx context.Context, messages []types.Message, opts ...provider.Option) (*types.ChatResponse, error) {
+	// Honor cancellation so tests can exercise aborted turns deterministically.
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	var sb strings.Builder
 	if p.Prefix != "" {
 		sb.WriteString(strings.TrimSpace(p.Prefix))
@@ -39,6 +95,9 @@ func (p *ChatModel) Chat(ctx context.Context, messages []types.Message, opts ...
 	}
 
 	responseContent := sb.String()
+	for _, transform := range p.transforms {
+		responseContent = transform(responseContent)
+	}
 
 	return &types.ChatResponse{
 		Message: types.Message{
@@ -68,11 +127,17 @@ func (p *ChatModel) Stream(ctx context.Context, messages []types.Message, opts .
 			return
 		}
 
-		// Simulate streaming by words
-		words := strings.Split(resp.Message.Content, " ")
-		for _, word := range words {
+		for _, chunk := range p.split(resp.Message.Content) {
+			if p.chunkDelay > 0 {
+				select {
+				case <-time.After(p.chunkDelay):
+				case <-ctx.Done():
+					ch <- provider.ChatChunk{Error: ctx.Err()}
+					return
+				}
+			}
 			ch <- provider.ChatChunk{
-				Content: word + " ",
+				Content: chunk,
 			}
 		}
 
@@ -85,4 +150,36 @@ func (p *ChatModel) Stream(ctx context.Context, messages []types.Message, opts .
 	return ch, nil
 }
 
+// split cuts content into stream chunks that concatenate back to exactly the
+// Chat content: words with their trailing separators by default, or runs of
+// chunkSize runes.
+func (p *ChatModel) split(content string) []string {
+	if p.chunkSize <= 0 {
+		var words []string
+		for _, word := range strings.SplitAfter(content, " ") {
+			if word != "" {
+				words = append(words, word)
+			}
+		}
+		return words
+	}
+	return splitRunes(content, p.chunkSize)
+}
+
+// splitRunes cuts s into chunks of at most n runes, never inside a multibyte character.
+func splitRunes(s string, n int) []string {
+	var chunks []string
+	for len(s) > 0 {
+		end, count := 0, 0
+		for end < len(s) && count < n {
+			_, size := utf8.DecodeRuneInString(s[end:])
+			end += size
+			count++
+		}
+		chunks = append(chunks, s[:end])
+		s = s[end:]
+	}
+	return chunks
+}
+
 var _ provider.ChatModel = (*ChatModel)(nil)
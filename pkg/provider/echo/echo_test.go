@@ -0,0 +1,123 @@
+package echo
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"giai/pkg/types"
+)
+
+func TestStreamMatchesChat(t *testing.T) {
+	msgs := []types.Message{
+		types.SystemMessage("Be  brief. "),
+		types.UserMessage("line one\n\nline two "),
+	}
+
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{name: "Plain", want: "bot Be  brief. \nline one\n\nline two \n"},
+		{name: "Uppercase", opts: []Option{WithUppercase()}, want: "BOT BE  BRIEF. \nLINE ONE\n\nLINE TWO \n"},
+		{name: "Reverse", opts: []Option{WithReverse()}, want: "\n owt enil\n\neno enil\n .feirb  eB tob"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := New("bot", tt.opts...)
+			ctx := context.Background()
+
+			resp, err := m.Chat(ctx, msgs)
+			if err != nil {
+				t.Fatalf("Chat() error = %v", err)
+			}
+			if resp.Message.Content != tt.want {
+				t.Errorf("Chat() content = %q, want %q", resp.Message.Content, tt.want)
+			}
+
+			stream, err := m.Stream(ctx, msgs)
+			if err != nil {
+				t.Fatalf("Stream() error = %v", err)
+			}
+			var sb strings.Builder
+			for chunk := range stream {
+				sb.WriteString(chunk.Content)
+			}
+			if sb.String() != resp.Message.Content {
+				t.Errorf("Stream() content = %q, want %q", sb.String(), resp.Message.Content)
+			}
+		})
+	}
+}
+
+func TestStreamChunkSize(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		size  int
+		want  []string
+	}{
+		{name: "ASCII", input: "abcdefg", size: 3, want: []string{"abc", "def", "g\n"}},
+		{name: "Multibyte", input: "héllo世界🙂", size: 2, want: []string{"hé", "ll", "o世", "界🙂", "\n"}},
+		{name: "Larger Than Content", input: "hi", size: 10, want: []string{"hi\n"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := New("", WithChunkSize(tt.size))
+			stream, err := m.Stream(context.Background(), []types.Message{types.UserMessage(tt.input)})
+			if err != nil {
+				t.Fatalf("Stream() error = %v", err)
+			}
+			var got []string
+			for chunk := range stream {
+				if chunk.Content != "" {
+					if !utf8.ValidString(chunk.Content) {
+						t.Errorf("chunk %q is not valid UTF-8", chunk.Content)
+					}
+					got = append(got, chunk.Content)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("chunks = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStreamChunkDelay(t *testing.T) {
+	const delay = 20 * time.Millisecond
+	m := New("", WithChunkSize(2), WithChunkDelay(delay))
+
+	start := time.Now()
+	stream, err := m.Stream(context.Background(), []types.Message{types.UserMessage("abcd")})
+	if err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+	chunks := 0
+	for chunk := range stream {
+		if chunk.Content != "" {
+			chunks++
+		}
+	}
+	if chunks != 3 {
+		t.Fatalf("chunks = %d, want 3", chunks)
+	}
+	if elapsed := time.Since(start); elapsed < 3*delay {
+		t.Errorf("stream took %v, want at least %v", elapsed, 3*delay)
+	}
+}
+
+func TestChatCancelledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := New("").Chat(ctx, []types.Message{types.UserMessage("hello")}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Chat() error = %v, want context.Canceled", err)
+	}
+}
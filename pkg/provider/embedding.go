@@ -0,0 +1,144 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Embedder turns texts into embedding vectors.
+type Embedder interface {
+	// Embed returns one vector per text, in order.
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+	// MaxBatchSize is the most texts a single Embed call accepts; 0 means no limit.
+	MaxBatchSize() int
+}
+
+// ChunkConfig controls how ChunkAndEmbed splits a document.
+type ChunkConfig struct {
+	// Size is the largest chunk, in runes or, with Tokenizer, in tokens.
+	// Defaults to 1000.
+	Size int
+	// Overlap is how much of the end of a chunk is repeated at the start of
+	// the next one, in the same unit as Size. Must be less than Size.
+	Overlap int
+	// Tokenizer, when set, measures Size and Overlap in tokens instead of runes.
+	Tokenizer Tokenizer
+}
+
+// Chunk is one embedded piece of a document.
+type Chunk struct {
+	Text      string
+	Embedding []float32
+	Offset    int // Byte offset of Text in the source document
+}
+
+const defaultChunkSize = 1000
+
+// ChunkAndEmbed splits text into chunks of at most cfg.Size, breaking between
+// words, and embeds them in batches no larger than e.MaxBatchSize. A single
+// word longer than Size is split by runes, or kept whole when sizing by tokens.
+func ChunkAndEmbed(ctx context.Context, e Embedder, text string, cfg ChunkConfig) ([]Chunk, error) {
+	if cfg.Size <= 0 {
+		cfg.Size = defaultChunkSize
+	}
+	if cfg.Overlap < 0 || cfg.Overlap >= cfg.Size {
+		return nil, fmt.Errorf("chunk overlap %d must be in [0, %d)", cfg.Overlap, cfg.Size)
+	}
+
+	chunks := splitChunks(text, cfg)
+	if len(chunks) == 0 {
+		return nil, nil
+	}
+
+	batch := e.MaxBatchSize()
+	if batch <= 0 {
+		batch = len(chunks)
+	}
+	for start := 0; start < len(chunks); start += batch {
+		end := min(start+batch, len(chunks))
+		texts := make([]string, end-start)
+		for i := range texts {
+			texts[i] = chunks[start+i].Text
+		}
+		vecs, err := e.Embed(ctx, texts)
+		if err != nil {
+			return nil, fmt.Errorf("embed chunks %d-%d: %w", start, end-1, err)
+		}
+		if len(vecs) != len(texts) {
+			return nil, fmt.Errorf("embedder returned %d vectors for %d texts", len(vecs), len(texts))
+		}
+		for i, v := range vecs {
+			chunks[start+i].Embedding = v
+		}
+	}
+	return chunks, nil
+}
+
+// segment is a word and its trailing whitespace, the unit chunks are built from.
+type segment struct {
+	start, end int // Byte range in the document
+	size       int // Runes or tokens
+}
+
+var wordPattern = regexp.MustCompile(`\S+\s*`)
+
+// splitChunks packs whole segments into chunks of at most cfg.Size, starting
+// each chunk with up to cfg.Overlap of the previous one's trailing segments.
+func splitChunks(text string, cfg ChunkConfig) []Chunk {
+	var segs []segment
+	for _, loc := range wordPattern.FindAllStringIndex(text, -1) {
+		segs = append(segs, measureSegments(text, loc[0], loc[1], cfg)...)
+	}
+
+	var chunks []Chunk
+	for i := 0; i < len(segs); {
+		j, size := i, 0
+		for j < len(segs) && (j == i || size+segs[j].size <= cfg.Size) {
+			size += segs[j].size
+			j++
+		}
+		chunks = append(chunks, Chunk{
+			Text:   strings.TrimRightFunc(text[segs[i].start:segs[j-1].end], unicode.IsSpace),
+			Offset: segs[i].start,
+		})
+		if j == len(segs) {
+			break
+		}
+		// Back up over trailing segments for the overlap, always advancing by at least one.
+		k, overlap := j, 0
+		for k > i+1 && overlap+segs[k-1].size <= cfg.Overlap {
+			overlap += segs[k-1].size
+			k--
+		}
+		i = k
+	}
+	return chunks
+}
+
+// measureSegments sizes text[start:end], splitting it by runes when it alone
+// exceeds cfg.Size in rune mode.
+func measureSegments(text string, start, end int, cfg ChunkConfig) []segment {
+	word := text[start:end]
+	if cfg.Tokenizer != nil {
+		return []segment{{start, end, cfg.Tokenizer.Count(word)}}
+	}
+	n := utf8.RuneCountInString(word)
+	if n <= cfg.Size {
+		return []segment{{start, end, n}}
+	}
+	var segs []segment
+	for pos, count, from := start, 0, start; pos < end; {
+		_, w := utf8.DecodeRuneInString(text[pos:end])
+		pos += w
+		count++
+		if count == cfg.Size || pos == end {
+			segs = append(segs, segment{from, pos, count})
+			from, count = pos, 0
+		}
+	}
+	return segs
+}
@@ -0,0 +1,104 @@
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// fakeEmbedder embeds a text as its rune count and records each batch.
+type fakeEmbedder struct {
+	maxBatch int
+	batches  [][]string
+}
+
+func (e *fakeEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	e.batches = append(e.batches, texts)
+	vecs := make([][]float32, len(texts))
+	for i, t := range texts {
+		vecs[i] = []float32{float32(utf8.RuneCountInString(t))}
+	}
+	return vecs, nil
+}
+
+func (e *fakeEmbedder) MaxBatchSize() int { return e.maxBatch }
+
+const chunkDoc = `Retrieval works best on small, focused passages. Each chunk should carry one idea.
+
+Overlap keeps sentences that straddle a boundary findable from either side.
+
+Batching matters because embedding APIs cap how many inputs one request may carry.`
+
+func TestChunkAndEmbed(t *testing.T) {
+	e := &fakeEmbedder{maxBatch: 2}
+	chunks, err := ChunkAndEmbed(context.Background(), e, chunkDoc, ChunkConfig{Size: 60, Overlap: 15})
+	if err != nil {
+		t.Fatalf("ChunkAndEmbed() error = %v", err)
+	}
+	if len(chunks) < 4 {
+		t.Fatalf("got %d chunks, want the document split into at least 4", len(chunks))
+	}
+
+	for i, c := range chunks {
+		if n := utf8.RuneCountInString(c.Text); n > 60 {
+			t.Errorf("chunk %d has %d runes, want at most 60", i, n)
+		}
+		if !strings.HasPrefix(chunkDoc[c.Offset:], c.Text) {
+			t.Errorf("chunk %d = %q does not occur at offset %d", i, c.Text, c.Offset)
+		}
+		if len(c.Embedding) != 1 || int(c.Embedding[0]) != utf8.RuneCountInString(c.Text) {
+			t.Errorf("chunk %d embedding = %v, want the vector for its own text", i, c.Embedding)
+		}
+		if i > 0 {
+			prev := chunks[i-1]
+			if c.Offset <= prev.Offset || c.Offset >= prev.Offset+len(prev.Text) {
+				t.Errorf("chunk %d at %d does not overlap chunk %d at %d", i, c.Offset, i-1, prev.Offset)
+			}
+		}
+	}
+	if last := chunks[len(chunks)-1]; !strings.HasSuffix(chunkDoc, last.Text) {
+		t.Errorf("last chunk = %q, want the end of the document", last.Text)
+	}
+
+	if want := (len(chunks) + 1) / 2; len(e.batches) != want {
+		t.Errorf("Embed called %d times, want %d", len(e.batches), want)
+	}
+	for i, b := range e.batches {
+		if len(b) > 2 {
+			t.Errorf("batch %d has %d texts, want at most 2", i, len(b))
+		}
+	}
+}
+
+func TestChunkAndEmbed_Tokens(t *testing.T) {
+	chunks, err := ChunkAndEmbed(context.Background(), &fakeEmbedder{}, chunkDoc, ChunkConfig{Size: 8, Tokenizer: WordTokenizer{}})
+	if err != nil {
+		t.Fatalf("ChunkAndEmbed() error = %v", err)
+	}
+	words := 0
+	for i, c := range chunks {
+		n := len(strings.Fields(c.Text))
+		if n > 8 {
+			t.Errorf("chunk %d has %d words, want at most 8", i, n)
+		}
+		words += n
+	}
+	if want := len(strings.Fields(chunkDoc)); words != want {
+		t.Errorf("chunks cover %d words, want %d without overlap", words, want)
+	}
+}
+
+func TestChunkAndEmbed_LongWordAndValidation(t *testing.T) {
+	chunks, err := ChunkAndEmbed(context.Background(), &fakeEmbedder{}, strings.Repeat("x", 25), ChunkConfig{Size: 10})
+	if err != nil {
+		t.Fatalf("ChunkAndEmbed() error = %v", err)
+	}
+	if len(chunks) != 3 || chunks[2].Text != "xxxxx" || chunks[2].Offset != 20 {
+		t.Errorf("chunks = %+v, want the word split into 10, 10 and 5 runes", chunks)
+	}
+
+	if _, err := ChunkAndEmbed(context.Background(), &fakeEmbedder{}, chunkDoc, ChunkConfig{Size: 10, Overlap: 10}); err == nil {
+		t.Error("ChunkAndEmbed() expected error for overlap >= size")
+	}
+}
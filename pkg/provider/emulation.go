@@ -0,0 +1,174 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"giai/pkg/types"
+)
+
+// Tool call directives emulated models are asked to write, one per call:
+//
+//	<tool_call>{"name": "get_weather", "arguments": {"city": "Paris"}}</tool_call>
+const (
+	toolCallOpen  = "<tool_call>"
+	toolCallClose = "</tool_call>"
+)
+
+var toolCallDirective = regexp.MustCompile(`(?s)<tool_call>(.*?)</tool_call>`)
+
+type toolEmulation struct {
+	inner ChatModel
+}
+
+// WithToolEmulation wraps inner so that a model without native tool calling
+// can still drive an agent's tools. When tools are requested and inner does
+// not report tool support, the tool schemas are described in a system message,
+// earlier tool calls and results are replayed as text, and <tool_call>
+// directives in the reply are parsed back into types.ToolCall values.
+// Models with native tool support are passed through unchanged.
+func WithToolEmulation(inner ChatModel) ChatModel {
+	return &toolEmulation{inner: inner}
+}
+
+func (e *toolEmulation) Name() string {
+	return e.inner.Name()
+}
+
+// Capabilities reports the inner model's capabilities with tool support added.
+func (e *toolEmulation) Capabilities() Capabilities {
+	c := CapabilitiesOf(e.inner)
+	c.Tools = true
+	return c
+}
+
+// emulating reports whether the call needs emulation, returning its tools.
+func (e *toolEmulation) emulating(opts []Option) ([]types.ToolDefinition, bool) {
+	if c := CapabilitiesOf(e.inner); c.Known && c.Tools {
+		return nil, false
+	}
+	tools := ResolveOptions(ChatOptions{}, opts...).Tools
+	return tools, len(tools) > 0
+}
+
+// Chat implements ChatModel.Chat
+func (e *toolEmulation) Chat(ctx context.Context, messages []types.Message, opts ...Option) (*types.ChatResponse, error) {
+	tools, ok := e.emulating(opts)
+	if !ok {
+		return e.inner.Chat(ctx, messages, opts...)
+	}
+
+	stripTools := func(o *ChatOptions) {
+		o.Tools = nil
+		o.ParallelToolCalls = nil
+		o.ToolChoice = nil
+		o.ServerTools = nil
+	}
+	// Clip so the append cannot write into the caller's backing array.
+	resp, err := e.inner.Chat(ctx, emulatedMessages(messages, tools), append(slices.Clip(opts), stripTools)...)
+	if err != nil {
+		return nil, err
+	}
+
+	content, calls := parseToolCalls(resp.Message.Content)
+	if len(calls) > 0 {
+		resp.Message.Content = content
+		resp.Message.ToolCalls = calls
+		resp.FinishReason = "tool_calls"
+	}
+	return resp, nil
+}
+
+// Stream implements ChatModel.Stream. Emulated calls are answered in one piece,
+// since directives can only be parsed from the complete reply.
+func (e *toolEmulation) Stream(ctx context.Context, messages []types.Message, opts ...Option) (<-chan ChatChunk, error) {
+	if _, ok := e.emulating(opts); !ok {
+		return e.inner.Stream(ctx, messages, opts...)
+	}
+	resp, err := e.Chat(ctx, messages, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return responseToStream(resp), nil
+}
+
+// emulatedMessages prepends tool instructions and rewrites tool traffic as
+// plain text the model can read.
+func emulatedMessages(messages []types.Message, tools []types.ToolDefinition) []types.Message {
+	out := make([]types.Message, 0, len(messages)+1)
+	out = append(out, types.SystemMessage(toolInstructions(tools)))
+	for _, msg := range messages {
+		switch {
+		case msg.Role == types.RoleAssistant && len(msg.ToolCalls) > 0:
+			var sb strings.Builder
+			sb.WriteString(msg.Content)
+			for _, call := range msg.ToolCalls {
+				if sb.Len() > 0 {
+					sb.WriteString("\n")
+				}
+				fmt.Fprintf(&sb, "%s{\"name\": %q, \"arguments\": %s}%s", toolCallOpen, call.Function.Name, argumentsOrEmpty(call.Function.Arguments), toolCallClose)
+			}
+			out = append(out, types.Message{Role: types.RoleAssistant, Content: sb.String()})
+		case msg.Role == types.RoleTool:
+			out = append(out, types.UserMessage(fmt.Sprintf("Result of tool call %s:\n%s", msg.ToolCallID, msg.Content)))
+		default:
+			out = append(out, msg)
+		}
+	}
+	return out
+}
+
+func toolInstructions(tools []types.ToolDefinition) string {
+	var sb strings.Builder
+	sb.WriteString("You can call the following tools:\n")
+	for _, t := range tools {
+		params, err := json.Marshal(t.Function.Parameters)
+		if err != nil || t.Function.Parameters == nil {
+			params = []byte("{}")
+		}
+		fmt.Fprintf(&sb, "\n- %s: %s\n  Parameters (JSON Schema): %s\n", t.Function.Name, t.Function.Description, params)
+	}
+	sb.WriteString("\nTo call a tool, reply with one line per call of the form\n")
+	sb.WriteString(toolCallOpen + `{"name": TOOL_NAME, "arguments": ARGUMENTS_OBJECT}` + toolCallClose + "\n")
+	sb.WriteString("where TOOL_NAME is a JSON string and ARGUMENTS_OBJECT a JSON object matching the tool's parameters. ")
+	sb.WriteString("Tool results are sent back to you as user messages. Answer without a tool call once you have what you need.")
+	return sb.String()
+}
+
+func argumentsOrEmpty(args string) string {
+	if strings.TrimSpace(args) == "" || !json.Valid([]byte(args)) {
+		return "{}"
+	}
+	return args
+}
+
+// parseToolCalls extracts well-formed tool call directives from text,
+// returning the remaining text and the calls. Malformed directives are left
+// in the text.
+func parseToolCalls(text string) (string, []types.ToolCall) {
+	var calls []types.ToolCall
+	content := toolCallDirective.ReplaceAllStringFunc(text, func(directive string) string {
+		body := toolCallDirective.FindStringSubmatch(directive)[1]
+		var d struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal([]byte(body), &d); err != nil || d.Name == "" {
+			return directive
+		}
+		args := "{}"
+		if len(d.Arguments) > 0 && string(d.Arguments) != "null" {
+			args = string(d.Arguments)
+		}
+		calls = append(calls, types.NewToolCall(types.NewToolCallID(), d.Name, args))
+		return ""
+	})
+	return strings.TrimSpace(content), calls
+}
+
+var _ ChatModel = (*toolEmulation)(nil)
+var _ CapabilityReporter = (*toolEmulation)(nil)
@@ -0,0 +1,134 @@
+package provider_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"giai/pkg/provider"
+	"giai/pkg/provider/echo"
+	"giai/pkg/types"
+)
+
+var weatherTool = types.NewToolDefinition("get_weather", "Current weather for a city.", map[string]any{
+	"type":       "object",
+	"properties": map[string]any{"city": map[string]any{"type": "string"}},
+})
+
+func withTools(defs ...types.ToolDefinition) provider.Option {
+	return func(o *provider.ChatOptions) { o.Tools = defs }
+}
+
+// nativeModel is echo claiming native tool support.
+type nativeModel struct{ provider.ChatModel }
+
+func (nativeModel) Capabilities() provider.Capabilities {
+	return provider.Capabilities{Tools: true, Known: true}
+}
+
+func TestWithToolEmulation(t *testing.T) {
+	ctx := context.Background()
+	directive := `Checking. <tool_call>{"name": "get_weather", "arguments": {"city": "Paris"}}</tool_call>`
+	msgs := []types.Message{types.UserMessage(directive)}
+
+	t.Run("Parses Directive", func(t *testing.T) {
+		m := provider.WithToolEmulation(echo.New(""))
+		if !provider.CapabilitiesOf(m).Tools {
+			t.Error("Capabilities().Tools = false, want true")
+		}
+		resp, err := m.Chat(ctx, msgs, withTools(weatherTool))
+		if err != nil {
+			t.Fatalf("Chat() error = %v", err)
+		}
+		calls := resp.Message.ToolCalls
+		if len(calls) != 1 || calls[0].Function.Name != "get_weather" || calls[0].Function.Arguments != `{"city": "Paris"}` || calls[0].ID == "" {
+			t.Fatalf("ToolCalls = %+v, want one get_weather call for Paris", calls)
+		}
+		if resp.FinishReason != "tool_calls" {
+			t.Errorf("FinishReason = %q, want tool_calls", resp.FinishReason)
+		}
+		// The echoed instructions prove the schema reached the model.
+		if !strings.Contains(resp.Message.Content, "- get_weather: Current weather for a city.") {
+			t.Errorf("Content = %q, want tool instructions", resp.Message.Content)
+		}
+		if strings.Contains(resp.Message.Content, `"city": "Paris"`) {
+			t.Errorf("Content = %q, want the directive removed", resp.Message.Content)
+		}
+	})
+
+	t.Run("Replays Tool Traffic", func(t *testing.T) {
+		m := provider.WithToolEmulation(echo.New(""))
+		history := []types.Message{
+			types.UserMessage("weather?"),
+			types.AssistantToolCall(types.NewToolCall("call_1", "get_weather", `{"city":"Paris"}`)),
+			types.ToolResultMessage("call_1", "sunny"),
+		}
+		resp, err := m.Chat(ctx, history, withTools(weatherTool))
+		if err != nil {
+			t.Fatalf("Chat() error = %v", err)
+		}
+		if !strings.Contains(resp.Message.Content, "Result of tool call call_1:\nsunny") {
+			t.Errorf("Content = %q, want the tool result as text", resp.Message.Content)
+		}
+	})
+
+	t.Run("Stream", func(t *testing.T) {
+		m := provider.WithToolEmulation(echo.New(""))
+		resp, err := provider.StreamAndCollect(ctx, m, msgs, nil, withTools(weatherTool))
+		if err != nil {
+			t.Fatalf("StreamAndCollect() error = %v", err)
+		}
+		if len(resp.Message.ToolCalls) != 1 {
+			t.Errorf("ToolCalls = %+v, want one call", resp.Message.ToolCalls)
+		}
+	})
+
+	t.Run("Caller Options Untouched", func(t *testing.T) {
+		m := provider.WithToolEmulation(echo.New(""))
+		// Spare capacity the wrapper must not append into.
+		opts := make([]provider.Option, 1, 2)
+		opts[0] = withTools(weatherTool)
+		spare := opts[:2]
+		spare[1] = provider.WithModel("kept")
+		if _, err := m.Chat(ctx, msgs, opts...); err != nil {
+			t.Fatalf("Chat() error = %v", err)
+		}
+		if got := provider.ResolveOptions(provider.ChatOptions{}, spare[1]); got.Model != "kept" {
+			t.Errorf("caller's spare option overwritten: Model = %q, want kept", got.Model)
+		}
+	})
+
+	t.Run("Pass Through", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			inner provider.ChatModel
+			opts  []provider.Option
+		}{
+			{name: "No Tools", inner: echo.New("")},
+			{name: "Native Tools", inner: nativeModel{echo.New("")}, opts: []provider.Option{withTools(weatherTool)}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				resp, err := provider.WithToolEmulation(tt.inner).Chat(ctx, msgs, tt.opts...)
+				if err != nil {
+					t.Fatalf("Chat() error = %v", err)
+				}
+				if resp.Message.Content != directive+"\n" || len(resp.Message.ToolCalls) != 0 {
+					t.Errorf("Chat() = %q with %d calls, want the inner reply unchanged", resp.Message.Content, len(resp.Message.ToolCalls))
+				}
+			})
+		}
+	})
+}
+
+func TestWithToolEmulation_MalformedDirectiveKept(t *testing.T) {
+	text := `<tool_call>{"name": oops}</tool_call>`
+	m := provider.WithToolEmulation(echo.New(""))
+	resp, err := m.Chat(context.Background(), []types.Message{types.UserMessage(text)}, withTools(weatherTool))
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if len(resp.Message.ToolCalls) != 0 || !strings.Contains(resp.Message.Content, text) {
+		t.Errorf("Chat() = %q with %d calls, want malformed directive left as text", resp.Message.Content, len(resp.Message.ToolCalls))
+	}
+}
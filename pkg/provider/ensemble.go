@@ -0,0 +1,186 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"giai/pkg/types"
+)
+
+// Candidate is one member's outcome in an ensemble call.
+type Candidate struct {
+	Member   ChatModel
+	Response *types.ChatResponse
+	Err      error
+	Latency  time.Duration
+}
+
+// Strategy selects the winning candidate of an ensemble call.
+type Strategy interface {
+	// Select is called every time a member finishes, with all candidates so far
+	// (in completion order) and the number of members still running.
+	// Returning true ends the call; members still running are cancelled.
+	Select(candidates []Candidate, pending int) (*Candidate, bool)
+}
+
+// StrategyFunc adapts a function into a Strategy.
+type StrategyFunc func(candidates []Candidate, pending int) (*Candidate, bool)
+
+func (f StrategyFunc) Select(candidates []Candidate, pending int) (*Candidate, bool) {
+	return f(candidates, pending)
+}
+
+// FirstSuccess picks the first member to answer without error,
+// which is also the lowest-latency successful member.
+func FirstSuccess() Strategy {
+	return StrategyFunc(func(candidates []Candidate, pending int) (*Candidate, bool) {
+		last := &candidates[len(candidates)-1]
+		if last.Err == nil {
+			return last, true
+		}
+		return nil, false
+	})
+}
+
+// MajorityVote groups successful responses with equal and picks the largest group,
+// deciding early once a group holds a strict majority of all members.
+// Ties go to the group that formed first.
+func MajorityVote(equal func(a, b *types.ChatResponse) bool) Strategy {
+	return StrategyFunc(func(candidates []Candidate, pending int) (*Candidate, bool) {
+		total := len(candidates) + pending
+
+		var best *Candidate
+		bestVotes := 0
+		for i := range candidates {
+			if candidates[i].Err != nil {
+				continue
+			}
+			votes := 0
+			for j := range candidates {
+				if candidates[j].Err == nil && equal(candidates[i].Response, candidates[j].Response) {
+					votes++
+				}
+			}
+			if votes > bestVotes {
+				best, bestVotes = &candidates[i], votes
+			}
+		}
+
+		if best != nil && (bestVotes*2 > total || pending == 0) {
+			return best, true
+		}
+		return nil, false
+	})
+}
+
+// BestScore waits for every member and picks the successful response with the highest score.
+func BestScore(score func(*types.ChatResponse) float64) Strategy {
+	return StrategyFunc(func(candidates []Candidate, pending int) (*Candidate, bool) {
+		if pending > 0 {
+			return nil, false
+		}
+		var best *Candidate
+		var bestScore float64
+		for i := range candidates {
+			if candidates[i].Err != nil {
+				continue
+			}
+			if s := score(candidates[i].Response); best == nil || s > bestScore {
+				best, bestScore = &candidates[i], s
+			}
+		}
+		return best, best != nil
+	})
+}
+
+type ensemble struct {
+	members  []ChatModel
+	strategy Strategy
+}
+
+// Ensemble queries all members concurrently and returns the response chosen by strategy.
+// A nil strategy defaults to FirstSuccess.
+func Ensemble(members []ChatModel, strategy Strategy) ChatModel {
+	if strategy == nil {
+		strategy = FirstSuccess()
+	}
+	return &ensemble{members: members, strategy: strategy}
+}
+
+func (e *ensemble) Name() string {
+	return "ensemble"
+}
+
+// Chat fans out to every member and cancels the losers once the strategy decides.
+func (e *ensemble) Chat(ctx context.Context, messages []types.Message, opts ...Option) (*types.ChatResponse, error) {
+	if len(e.members) == 0 {
+		return nil, errors.New("ensemble: no members")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan Candidate, len(e.members))
+	for _, m := range e.members {
+		m := m
+		go func() {
+			start := time.Now()
+			resp, err := m.Chat(ctx, messages, opts...)
+			results <- Candidate{Member: m, Response: resp, Err: err, Latency: time.Since(start)}
+		}()
+	}
+
+	candidates := make([]Candidate, 0, len(e.members))
+	for pending := len(e.members); pending > 0; {
+		select {
+		case c := <-results:
+			pending--
+			candidates = append(candidates, c)
+			if chosen, ok := e.strategy.Select(candidates, pending); ok && chosen != nil {
+				return chosen.Response, nil
+			}
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	errs := make([]error, 0, len(candidates))
+	for _, c := range candidates {
+		if c.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", c.Member.Name(), c.Err))
+		}
+	}
+	if len(errs) == 0 {
+		return nil, errors.New("ensemble: strategy selected no response")
+	}
+	return nil, fmt.Errorf("ensemble: all members failed: %w", errors.Join(errs...))
+}
+
+// Stream resolves the ensemble via Chat and replays the chosen response as a stream.
+func (e *ensemble) Stream(ctx context.Context, messages []types.Message, opts ...Option) (<-chan ChatChunk, error) {
+	resp, err := e.Chat(ctx, messages, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return responseToStream(resp), nil
+}
+
+// responseToStream replays a complete response as a stream of chunks.
+func responseToStream(resp *types.ChatResponse) <-chan ChatChunk {
+	ch := make(chan ChatChunk, len(resp.Message.ToolCalls)+2)
+	if resp.Message.Content != "" {
+		ch <- ChatChunk{Content: resp.Message.Content}
+	}
+	for i := range resp.Message.ToolCalls {
+		tc := resp.Message.ToolCalls[i]
+		ch <- ChatChunk{ToolCall: &tc}
+	}
+	usage := resp.Usage
+	ch <- ChatChunk{FinishReason: resp.FinishReason, Usage: &usage}
+	close(ch)
+	return ch
+}
+
+var _ ChatModel = (*ensemble)(nil)
@@ -0,0 +1,128 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"giai/pkg/types"
+)
+
+// fakeModel answers with a fixed response or error after an optional delay.
+type fakeModel struct {
+	name      string
+	delay     time.Duration
+	content   string
+	err       error
+	usage     types.Usage
+	calls     atomic.Int32
+	cancelled atomic.Bool
+}
+
+func (m *fakeModel) Name() string { return m.name }
+
+func (m *fakeModel) Chat(ctx context.Context, messages []types.Message, opts ...Option) (*types.ChatResponse, error) {
+	m.calls.Add(1)
+	if m.delay > 0 {
+		select {
+		case <-time.After(m.delay):
+		case <-ctx.Done():
+			m.cancelled.Store(true)
+			return nil, ctx.Err()
+		}
+	}
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &types.ChatResponse{
+		Message:      types.Message{Role: types.RoleAssistant, Content: m.content},
+		FinishReason: "stop",
+		Usage:        m.usage,
+	}, nil
+}
+
+func (m *fakeModel) Stream(ctx context.Context, messages []types.Message, opts ...Option) (<-chan ChatChunk, error) {
+	resp, err := m.Chat(ctx, messages, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return responseToStream(resp), nil
+}
+
+func TestEnsemble_FirstSuccess(t *testing.T) {
+	slow := &fakeModel{name: "slow", delay: 5 * time.Second, content: "slow answer"}
+	broken := &fakeModel{name: "broken", err: errors.New("boom")}
+	fast := &fakeModel{name: "fast", delay: 10 * time.Millisecond, content: "fast answer"}
+
+	m := Ensemble([]ChatModel{slow, broken, fast}, FirstSuccess())
+
+	start := time.Now()
+	resp, err := m.Chat(context.Background(), []types.Message{{Role: types.RoleUser, Content: "hi"}})
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if resp.Message.Content != "fast answer" {
+		t.Errorf("Content = %q, want %q", resp.Message.Content, "fast answer")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Chat() took %v, expected the slow member to be abandoned", elapsed)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for !slow.cancelled.Load() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !slow.cancelled.Load() {
+		t.Error("slow member was not cancelled")
+	}
+}
+
+func TestEnsemble_AllFail(t *testing.T) {
+	m := Ensemble([]ChatModel{
+		&fakeModel{name: "a", err: errors.New("a failed")},
+		&fakeModel{name: "b", err: errors.New("b failed")},
+	}, nil)
+
+	if _, err := m.Chat(context.Background(), nil); err == nil {
+		t.Fatal("Chat() expected error when every member fails")
+	}
+}
+
+func TestEnsemble_MajorityVote(t *testing.T) {
+	sameContent := func(a, b *types.ChatResponse) bool { return a.Message.Content == b.Message.Content }
+	m := Ensemble([]ChatModel{
+		&fakeModel{name: "a", content: "42"},
+		&fakeModel{name: "b", delay: 5 * time.Millisecond, content: "41"},
+		&fakeModel{name: "c", delay: 10 * time.Millisecond, content: "42"},
+		&fakeModel{name: "d", err: errors.New("boom")},
+	}, MajorityVote(sameContent))
+
+	resp, err := m.Chat(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if resp.Message.Content != "42" {
+		t.Errorf("Content = %q, want majority answer %q", resp.Message.Content, "42")
+	}
+}
+
+func TestEnsemble_Stream(t *testing.T) {
+	m := Ensemble([]ChatModel{&fakeModel{name: "a", content: "streamed"}}, nil)
+
+	ch, err := m.Stream(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+	var content, finish string
+	for c := range ch {
+		content += c.Content
+		if c.FinishReason != "" {
+			finish = c.FinishReason
+		}
+	}
+	if content != "streamed" || finish != "stop" {
+		t.Errorf("stream = (%q, %q), want (%q, %q)", content, finish, "streamed", "stop")
+	}
+}
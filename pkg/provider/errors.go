@@ -0,0 +1,140 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ErrStreamingNotSupported may be returned (or wrapped) by a model or endpoint
+// that cannot stream responses.
+var ErrStreamingNotSupported = errors.New("streaming is not supported")
+
+// Error is a normalized provider API failure.
+// Providers wrap their SDK/network errors into it so callers can classify
+// failures uniformly; the original error stays reachable via errors.Unwrap.
+type Error struct {
+	Provider   string // e.g. "openai"
+	StatusCode int    // HTTP status, 0 when the request never got a response
+	Code       string // Provider-specific error code, e.g. "rate_limit_exceeded"
+	Message    string
+	Retryable  bool // True for transient failures (rate limits, 5xx, network)
+	Err        error
+}
+
+func (e *Error) Error() string {
+	if e.StatusCode > 0 {
+		return fmt.Sprintf("%s: status %d: %s", e.Provider, e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("%s: %s", e.Provider, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// IsRetryable reports whether err is (or wraps) a retryable provider error.
+func IsRetryable(err error) bool {
+	var pe *Error
+	if errors.As(err, &pe) {
+		return pe.Retryable
+	}
+	return false
+}
+
+// IsStreamingUnsupported reports whether err says the model cannot stream:
+// either ErrStreamingNotSupported, or a non-retryable provider error whose
+// message rejects the stream parameter (e.g. "'stream' does not support true
+// with this model").
+func IsStreamingUnsupported(err error) bool {
+	if errors.Is(err, ErrStreamingNotSupported) {
+		return true
+	}
+	var pe *Error
+	if !errors.As(err, &pe) || pe.Retryable {
+		return false
+	}
+	msg := strings.ToLower(pe.Message)
+	if !strings.Contains(msg, "stream") {
+		return false
+	}
+	for _, phrase := range []string{"not supported", "unsupported", "does not support", "not available"} {
+		if strings.Contains(msg, phrase) {
+			return true
+		}
+	}
+	return false
+}
+
+// Codes that are never worth retrying even when the HTTP status suggests otherwise
+// (e.g. OpenAI reports an exhausted quota as 429).
+var nonRetryableCodes = map[string]bool{
+	"insufficient_quota":      true,
+	"invalid_api_key":         true,
+	"context_length_exceeded": true,
+}
+
+// Codes that indicate a transient failure regardless of status.
+var retryableCodes = map[string]bool{
+	"rate_limit_exceeded": true,
+	"server_error":        true,
+	"overloaded_error":    true,
+}
+
+// IsRetryableStatus reports whether an HTTP status code indicates a transient failure.
+func IsRetryableStatus(status int) bool {
+	switch status {
+	case http.StatusRequestTimeout, http.StatusConflict, http.StatusTooManyRequests:
+		return true
+	}
+	return status >= 500
+}
+
+// Classify decides whether a failure with the given status, API code and
+// cause is retryable. A zero status means no response arrived: that is
+// retried only for network failures (a net.Error or a response cut short),
+// not for local ones such as encoding errors or malformed stream lines.
+func Classify(status int, code string, err error) bool {
+	if nonRetryableCodes[code] {
+		return false
+	}
+	if retryableCodes[code] {
+		return true
+	}
+	if status == 0 {
+		return isNetworkError(err)
+	}
+	return IsRetryableStatus(status)
+}
+
+// isNetworkError reports whether err is a transport failure worth retrying.
+func isNetworkError(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// NewError wraps err into an *Error, classifying it from status and code.
+// Context cancellation is returned unchanged so callers can still match it directly.
+func NewError(providerName string, status int, code, message string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	if message == "" {
+		message = err.Error()
+	}
+	return &Error{
+		Provider:   providerName,
+		StatusCode: status,
+		Code:       code,
+		Message:    message,
+		Retryable:  Classify(status, code, err),
+		Err:        err,
+	}
+}
@@ -0,0 +1,82 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+)
+
+func TestNewError_Classification(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		code          string
+		cause         error
+		wantRetryable bool
+	}{
+		{name: "Rate Limited", status: 429, wantRetryable: true},
+		{name: "Unauthorized", status: 401, wantRetryable: false},
+		{name: "Server Error", status: 500, wantRetryable: true},
+		{name: "Bad Request", status: 400, wantRetryable: false},
+		{name: "Quota Exhausted", status: 429, code: "insufficient_quota", wantRetryable: false},
+		{name: "Network Failure", status: 0, cause: &net.OpError{Op: "dial", Err: errors.New("connection refused")}, wantRetryable: true},
+		{name: "Truncated Response", status: 0, cause: fmt.Errorf("read body: %w", io.ErrUnexpectedEOF), wantRetryable: true},
+		{name: "Local Failure", status: 0, cause: errors.New("invalid character 'x'"), wantRetryable: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cause := tt.cause
+			if cause == nil {
+				cause = errors.New("boom")
+			}
+			err := NewError("test", tt.status, tt.code, "", cause)
+
+			var pe *Error
+			if !errors.As(err, &pe) {
+				t.Fatalf("NewError() = %T, want *Error", err)
+			}
+			if pe.Retryable != tt.wantRetryable {
+				t.Errorf("Retryable = %v, want %v", pe.Retryable, tt.wantRetryable)
+			}
+			if IsRetryable(err) != tt.wantRetryable {
+				t.Errorf("IsRetryable() = %v, want %v", IsRetryable(err), tt.wantRetryable)
+			}
+			if !errors.Is(err, cause) {
+				t.Error("original error is not reachable via errors.Unwrap")
+			}
+		})
+	}
+}
+
+func TestNewError_ContextPassthrough(t *testing.T) {
+	if err := NewError("test", 0, "", "", context.Canceled); err != context.Canceled {
+		t.Errorf("NewError(context.Canceled) = %v, want context.Canceled unchanged", err)
+	}
+}
+
+func TestIsStreamingUnsupported(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "Sentinel", err: fmt.Errorf("fake: %w", ErrStreamingNotSupported), want: true},
+		{name: "Provider Message", err: NewError("openai", 400, "unsupported_value", "Unsupported value: 'stream' does not support true with this model.", errors.New("x")), want: true},
+		{name: "Other Bad Request", err: NewError("openai", 400, "", "invalid temperature", errors.New("x")), want: false},
+		{name: "Retryable", err: NewError("openai", 503, "", "streaming not available right now", errors.New("x")), want: false},
+		{name: "Plain Error", err: errors.New("stream not supported"), want: false},
+		{name: "Nil", err: nil, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsStreamingUnsupported(tt.err); got != tt.want {
+				t.Errorf("IsStreamingUnsupported() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
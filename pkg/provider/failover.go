@@ -0,0 +1,126 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"giai/pkg/types"
+)
+
+type failover struct {
+	members []ChatModel
+}
+
+// Failover tries primary first and then each fallback in order, returning the
+// first success. It only moves on for errors another member could plausibly
+// avoid (transient or auth failures), never for bad requests.
+// The serving member's name is recorded on ChatResponse.Provider.
+func Failover(primary ChatModel, fallbacks ...ChatModel) ChatModel {
+	return &failover{members: append([]ChatModel{primary}, fallbacks...)}
+}
+
+func (f *failover) Name() string {
+	return "failover"
+}
+
+// Chat implements ChatModel.Chat
+func (f *failover) Chat(ctx context.Context, messages []types.Message, opts ...Option) (*types.ChatResponse, error) {
+	var lastErr error
+	for _, m := range f.members {
+		resp, err := m.Chat(ctx, messages, opts...)
+		if err == nil {
+			out := *resp
+			out.Provider = m.Name()
+			return &out, nil
+		}
+		lastErr = fmt.Errorf("%s: %w", m.Name(), err)
+		if !shouldFailover(err) {
+			return nil, lastErr
+		}
+	}
+	return nil, lastErr
+}
+
+// Stream implements ChatModel.Stream. Failover only happens before the first
+// chunk is delivered; once content has flowed, errors are passed through.
+func (f *failover) Stream(ctx context.Context, messages []types.Message, opts ...Option) (<-chan ChatChunk, error) {
+	var lastErr error
+	for _, m := range f.members {
+		stream, err := m.Stream(ctx, messages, opts...)
+		if err != nil {
+			lastErr = fmt.Errorf("%s: %w", m.Name(), err)
+			if !shouldFailover(err) {
+				return nil, lastErr
+			}
+			continue
+		}
+
+		first, ok := <-stream
+		if !ok {
+			out := make(chan ChatChunk)
+			close(out)
+			return out, nil
+		}
+		if first.Error != nil && shouldFailover(first.Error) {
+			lastErr = fmt.Errorf("%s: %w", m.Name(), first.Error)
+			go drain(stream)
+			continue
+		}
+
+		out := make(chan ChatChunk)
+		go func() {
+			defer close(out)
+			if !forward(ctx, out, first) {
+				drain(stream)
+				return
+			}
+			for chunk := range stream {
+				if !forward(ctx, out, chunk) {
+					drain(stream)
+					return
+				}
+			}
+		}()
+		return out, nil
+	}
+	return nil, lastErr
+}
+
+// shouldFailover reports whether err is worth retrying on a different member.
+func shouldFailover(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var pe *Error
+	if !errors.As(err, &pe) {
+		return true // Unclassified (e.g. network) failure
+	}
+	if pe.Retryable {
+		return true
+	}
+	switch pe.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound:
+		return true // Credentials or model availability differ per member
+	}
+	return false
+}
+
+// forward sends chunk on out, giving up when ctx is done so the sender does
+// not block forever on a consumer that stopped reading.
+func forward(ctx context.Context, out chan<- ChatChunk, chunk ChatChunk) bool {
+	select {
+	case out <- chunk:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func drain(ch <-chan ChatChunk) {
+	for range ch {
+	}
+}
+
+var _ ChatModel = (*failover)(nil)
@@ -0,0 +1,77 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestFailover_Chat(t *testing.T) {
+	tests := []struct {
+		name         string
+		primaryErr   error
+		wantErr      bool
+		wantProvider string
+	}{
+		{
+			name:         "Primary Succeeds",
+			wantProvider: "primary",
+		},
+		{
+			name:         "Transient Failure",
+			primaryErr:   &Error{Provider: "primary", StatusCode: 503, Retryable: true},
+			wantProvider: "fallback",
+		},
+		{
+			name:         "Auth Failure",
+			primaryErr:   &Error{Provider: "primary", StatusCode: 401},
+			wantProvider: "fallback",
+		},
+		{
+			name:       "Bad Request",
+			primaryErr: &Error{Provider: "primary", StatusCode: 400},
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primary := &fakeModel{name: "primary", content: "from primary", err: tt.primaryErr}
+			fallback := &fakeModel{name: "fallback", content: "from fallback"}
+
+			resp, err := Failover(primary, fallback).Chat(context.Background(), nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Chat() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if fallback.calls.Load() != 0 {
+					t.Error("fallback was called for a non-failover error")
+				}
+				return
+			}
+			if resp.Provider != tt.wantProvider {
+				t.Errorf("Provider = %q, want %q", resp.Provider, tt.wantProvider)
+			}
+		})
+	}
+}
+
+func TestFailover_Stream(t *testing.T) {
+	primary := &fakeModel{name: "primary", err: errors.New("connection refused")}
+	fallback := &fakeModel{name: "fallback", content: "from fallback"}
+
+	ch, err := Failover(primary, fallback).Stream(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+	var content string
+	for c := range ch {
+		if c.Error != nil {
+			t.Fatalf("stream error = %v", c.Error)
+		}
+		content += c.Content
+	}
+	if content != "from fallback" {
+		t.Errorf("content = %q, want %q", content, "from fallback")
+	}
+}
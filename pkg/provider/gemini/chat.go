@@ -2,11 +2,14 @@ package gemini
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
 
 	"github.com/google/generative-ai-go/genai"
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
 
@@ -19,6 +22,10 @@ type Config struct {
 	APIKey      string
 	Model       string // e.g., "gemini-pro"
 	Temperature float64
+	// StrictSampling rejects an out-of-range temperature or top_p with a
+	// *provider.ParamRangeError instead of clamping it into range.
+	StrictSampling bool
+	Logger         provider.Logger // Optional: notified when a parameter is clamped
 }
 
 // ChatModel implements provider.ChatModel using Google Gemini.
@@ -26,6 +33,8 @@ type ChatModel struct {
 	client             *genai.Client
 	defaultModel       string
 	defaultTemperature float64
+	strictSampling     bool
+	logger             provider.Logger
 }
 
 const (
@@ -33,6 +42,9 @@ const (
 	defaultTemperature = 0.5
 )
 
+// samplingRange is the temperature and top_p range the API accepts.
+var samplingRange = provider.SamplingRange{MaxTemperature: 2, MaxTopP: 1}
+
 // NewChatModel builds a Gemini chat provider.
 func NewChatModel(ctx context.Context, cfg Config) (provider.ChatModel, error) {
 	if cfg.APIKey == "" {
@@ -58,6 +70,8 @@ func NewChatModel(ctx context.Context, cfg Config) (provider.ChatModel, error) {
 		client:             client,
 		defaultModel:       modelName,
 		defaultTemperature: temp,
+		strictSampling:     cfg.StrictSampling,
+		logger:             cfg.Logger,
 	}, nil
 }
 
@@ -65,33 +79,23 @@ func (m *ChatModel) Name() string {
 	return "gemini"
 }
 
+// Capabilities reports what the configured default model supports.
+func (m *ChatModel) Capabilities() provider.Capabilities {
+	return provider.CapabilitiesForModel(m.defaultModel)
+}
+
 // Chat implements provider.ChatModel.Chat
 func (m *ChatModel) Chat(ctx context.Context, messages []types.Message, opts ...provider.Option) (*types.ChatResponse, error) {
-	model, cs, err := m.prepareSession(messages, opts)
+	// In the Gemini SDK, History holds the earlier messages and SendMessage
+	// takes the new parts: the last message, or every trailing tool result.
+	cs, parts, err := m.prepareSession(messages, opts)
 	if err != nil {
 		return nil, err
 	}
 
-	// Send the last message. In Gemini SDK, History contains previous messages,
-	// and SendMessage takes the new parts.
-	// However, our types.Message list includes the last message.
-	// So we need to pop the last message content.
-	if len(messages) == 0 {
-		return nil, errors.New("no messages to send")
-	}
-
-	lastMsg := messages[len(messages)-1]
-	if lastMsg.Role != types.RoleUser {
-		// Gemini chat usually expects User input to drive the turn.
-		// But if it's tool output, we also send it.
-	}
-
-	// Convert the last message to parts
-	parts := toGeminiParts(lastMsg)
-
 	resp, err := cs.SendMessage(ctx, parts...)
 	if err != nil {
-		return nil, err
+		return nil, wrapError(err)
 	}
 
 	return toChatResponse(resp), nil
@@ -99,48 +103,69 @@ func (m *ChatModel) Chat(ctx context.Context, messages []types.Message, opts ...
 
 // Stream implements provider.ChatModel.Stream
 func (m *ChatModel) Stream(ctx context.Context, messages []types.Message, opts ...provider.Option) (<-chan provider.ChatChunk, error) {
-	_, cs, err := m.prepareSession(messages, opts)
+	cs, parts, err := m.prepareSession(messages, opts)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(messages) == 0 {
-		return nil, errors.New("no messages to send")
-	}
-	lastMsg := messages[len(messages)-1]
-	parts := toGeminiParts(lastMsg)
-
 	iter := cs.SendMessageStream(ctx, parts...)
 	ch := make(chan provider.ChatChunk)
 
 	go func() {
 		defer close(ch)
+		calls := 0 // Function calls arrive whole; Index numbers them across chunks.
+		id := types.NewResponseID()
+		var finish string
+		var usage *types.Usage
 		for {
 			resp, err := iter.Next()
 			if err == iterator.Done {
+				// Like the other providers, end with the finish reason and usage.
+				if calls > 0 {
+					finish = "tool_calls"
+				}
+				ch <- provider.ChatChunk{FinishReason: finish, Usage: usage, ID: id}
 				return
 			}
 			if err != nil {
-				ch <- provider.ChatChunk{Error: err}
+				ch <- provider.ChatChunk{Error: wrapError(err), FinishReason: finish, Usage: usage}
 				return
 			}
+			// Usage is cumulative, so the latest report covers the whole response.
+			if resp.UsageMetadata != nil {
+				u := toUsage(resp.UsageMetadata)
+				usage = &u
+			}
 
 			// Convert Gemini response chunk to our ChatChunk
 			// Gemini chunks can contain multiple candidates/parts
 			if len(resp.Candidates) > 0 {
 				cand := resp.Candidates[0]
+				if cand.FinishReason != genai.FinishReasonUnspecified {
+					finish = toFinishReason(cand.FinishReason)
+				}
 				if cand.Content != nil {
 					var sb strings.Builder
+					var toolCalls []types.ToolCall
 					for _, part := range cand.Content.Parts {
-						if txt, ok := part.(genai.Text); ok {
-							sb.WriteString(string(txt))
+						switch p := part.(type) {
+						case genai.Text:
+							sb.WriteString(string(p))
+						case genai.FunctionCall:
+							call := toToolCall(p)
+							call.Index = calls
+							toolCalls = append(toolCalls, call)
+							calls++
 						}
 					}
 					chunk := provider.ChatChunk{
 						Content: sb.String(),
+						ID:      id,
 					}
-					// TODO: Handle ToolCalls in stream if Gemini supports it this way
 					ch <- chunk
+					for i := range toolCalls {
+						ch <- provider.ChatChunk{ToolCall: &toolCalls[i]}
+					}
 				}
 			}
 		}
@@ -149,18 +174,31 @@ func (m *ChatModel) Stream(ctx context.Context, messages []types.Message, opts .
 	return ch, nil
 }
 
-// prepareSession creates a ChatSession with history populated.
-func (m *ChatModel) prepareSession(messages []types.Message, opts []provider.Option) (*genai.GenerativeModel, *genai.ChatSession, error) {
+// prepareSession creates a ChatSession with history populated and returns the
+// parts to send: those of the last message, or of every trailing tool result
+// so that parallel function calls are answered together.
+func (m *ChatModel) prepareSession(messages []types.Message, opts []provider.Option) (*genai.ChatSession, []genai.Part, error) {
+	if len(messages) == 0 {
+		return nil, nil, errors.New("no messages to send")
+	}
+
 	// 1. Apply options
-	options := &provider.ChatOptions{
+	options := provider.ResolveOptions(provider.ChatOptions{
 		Model:       m.defaultModel,
 		Temperature: m.defaultTemperature,
+	}, opts...)
+	if err := provider.ClampSampling(&options, samplingRange, m.strictSampling, m.logger); err != nil {
+		return nil, nil, err
+	}
+	if err := provider.ValidateToolChoice(options.ToolChoice); err != nil {
+		return nil, nil, err
 	}
-	for _, o := range opts {
-		o(options)
+	if len(options.ServerTools) > 0 {
+		return nil, nil, fmt.Errorf("gemini: server tools %v are not supported", options.ServerTools)
 	}
 
 	// 2. Configure Model
+	// ThinkingBudget is ignored: this SDK has no thinking configuration.
 	gm := m.client.GenerativeModel(options.Model)
 	gm.SetTemperature(float32(options.Temperature))
 	if options.MaxTokens > 0 {
@@ -168,64 +206,154 @@ func (m *ChatModel) prepareSession(messages []types.Message, opts []provider.Opt
 	}
 	// Handle Tools
 	if len(options.Tools) > 0 {
-		// Mapping types.ToolDefinition to gemini.Tool is non-trivial due to schema differences.
-		// For now, we leave this as a TODO or implement basic FunctionDeclaration mapping.
-		// gm.Tools = convertToGeminiTools(options.Tools)
+		tools, err := convertToGeminiTools(options.Tools)
+		if err != nil {
+			return nil, nil, err
+		}
+		gm.Tools = tools
+		gm.ToolConfig = convertToolChoice(options.ToolChoice)
 	}
 
 	// 3. Build History
-	// Gemini ChatSession manages history. We need to feed all BUT the last message as history.
-	cs := gm.StartChat()
-	
-	if len(messages) > 1 {
-		history := messages[:len(messages)-1]
-		geminiHistory := make([]*genai.Content, 0, len(history))
-		
-		for _, msg := range history {
-			role := "user"
-			if msg.Role == types.RoleAssistant {
-				role = "model" // Gemini uses "model" instead of "assistant"
-			} else if msg.Role == types.RoleTool {
-				role = "function" // or user? Tool outputs are tricky in Gemini
-			} else if msg.Role == types.RoleSystem {
-				// Gemini Pro doesn't strictly have "system" role in Chat History yet, 
-				// usually passed as SystemInstruction in model config or merged into first user message.
-				// Recent SDKs added SystemInstruction support.
-				gm.SystemInstruction = &genai.Content{
-					Parts: []genai.Part{genai.Text(msg.Content)},
-				}
-				continue // Don't add to chat history
-			}
+	// Gemini ChatSession manages history; everything before the parts to send goes there.
+	names := toolCallNames(messages)
+	last := len(messages) - 1
+	for last > 0 && messages[last].Role == types.RoleTool && messages[last-1].Role == types.RoleTool {
+		last--
+	}
 
-			content := &genai.Content{
-				Role: role,
-				Parts: toGeminiParts(msg),
-			}
-			geminiHistory = append(geminiHistory, content)
+	gm.SystemInstruction = systemInstruction(messages[:last])
+	var history []*genai.Content
+	for _, msg := range messages[:last] {
+		role := "user"
+		switch msg.Role {
+		case types.RoleAssistant:
+			role = "model" // Gemini uses "model" instead of "assistant"
+		case types.RoleTool:
+			role = "function"
+		case types.RoleSystem:
+			// Gemini takes the system prompt as a model setting, not a chat turn.
+			continue
 		}
-		cs.History = geminiHistory
+
+		parts, err := toGeminiParts(msg, names)
+		if err != nil {
+			return nil, nil, err
+		}
+		// Results of parallel calls share one turn, like the calls themselves.
+		if n := len(history); n > 0 && role == "function" && history[n-1].Role == "function" {
+			history[n-1].Parts = append(history[n-1].Parts, parts...)
+			continue
+		}
+		history = append(history, &genai.Content{Role: role, Parts: parts})
+	}
+
+	var send []genai.Part
+	for _, msg := range messages[last:] {
+		parts, err := toGeminiParts(msg, names)
+		if err != nil {
+			return nil, nil, err
+		}
+		send = append(send, parts...)
 	}
 
-	return gm, cs, nil
+	cs := gm.StartChat()
+	cs.History = history
+	return cs, send, nil
 }
 
 // Helpers
 
-func toGeminiParts(msg types.Message) []genai.Part {
+// systemInstruction joins every system message into one instruction, so a
+// later one (e.g. an injected time note) adds to the system prompt instead of
+// replacing it. It returns nil when there are none.
+func systemInstruction(messages []types.Message) *genai.Content {
 	var parts []genai.Part
-	if msg.Content != "" {
+	for _, msg := range messages {
+		if msg.Role == types.RoleSystem {
+			parts = append(parts, genai.Text(msg.Content))
+		}
+	}
+	if len(parts) == 0 {
+		return nil
+	}
+	return &genai.Content{Parts: parts}
+}
+
+// toGeminiParts converts a message to parts. names maps tool call IDs to
+// function names, which Gemini uses to pair a response with its call.
+func toGeminiParts(msg types.Message, names map[string]string) ([]genai.Part, error) {
+	var parts []genai.Part
+	if msg.Role == types.RoleTool {
+		parts = append(parts, genai.FunctionResponse{
+			Name:     names[msg.ToolCallID],
+			Response: functionResponse(msg),
+		})
+	} else if msg.Content != "" {
 		parts = append(parts, genai.Text(msg.Content))
 	}
-	// TODO: Handle msg.ToolCalls -> genai.FunctionCall
-	// TODO: Handle msg.ToolCallID/Content -> genai.FunctionResponse
-	return parts
+	// Gemini accepts inline images in any turn, tool results included.
+	for _, p := range msg.Parts {
+		switch {
+		case p.Type == types.PartText:
+			parts = append(parts, genai.Text(p.Text))
+		case p.Type == types.PartImage:
+			blob, err := imageBlob(p)
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, blob)
+		}
+	}
+	for _, tc := range msg.ToolCalls {
+		var args map[string]any
+		// Arguments the model produced are JSON objects; anything else is sent without args.
+		_ = json.Unmarshal([]byte(tc.Function.Arguments), &args)
+		parts = append(parts, genai.FunctionCall{Name: tc.Function.Name, Args: args})
+	}
+	return parts, nil
 }
 
+// imageBlob returns an image part as inline data. Gemini's inline parts take
+// bytes only, so data: URLs are decoded and remote URLs are rejected.
+func imageBlob(p types.ContentPart) (genai.Blob, error) {
+	if p.ImageURL == "" {
+		return genai.Blob{MIMEType: p.MIMEType, Data: p.Data}, nil
+	}
+	if rest, ok := strings.CutPrefix(p.ImageURL, "data:"); ok {
+		if mime, encoded, ok := strings.Cut(rest, ";base64,"); ok {
+			data, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				return genai.Blob{}, fmt.Errorf("gemini: invalid image data URL: %w", err)
+			}
+			if p.MIMEType != "" {
+				mime = p.MIMEType
+			}
+			return genai.Blob{MIMEType: mime, Data: data}, nil
+		}
+	}
+	return genai.Blob{}, fmt.Errorf("gemini: image URLs are not supported, pass the image bytes instead: %s", p.ImageURL)
+}
+
+// toChatResponse converts a complete response. Gemini reports no response ID,
+// so one is minted.
 func toChatResponse(resp *genai.GenerateContentResponse) *types.ChatResponse {
+	id := types.NewResponseID()
+	var usage types.Usage
+	if resp.UsageMetadata != nil {
+		usage = toUsage(resp.UsageMetadata)
+	}
 	if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil {
-		return &types.ChatResponse{
+		// A blocked candidate has no content but still reports why.
+		out := &types.ChatResponse{
+			ID:      id,
 			Message: types.Message{Role: types.RoleAssistant, Content: ""},
+			Usage:   usage,
 		}
+		if len(resp.Candidates) > 0 {
+			out.FinishReason = toFinishReason(resp.Candidates[0].FinishReason)
+		}
+		return out
 	}
 
 	cand := resp.Candidates[0]
@@ -244,27 +372,94 @@ func toChatResponse(resp *genai.GenerateContentResponse) *types.ChatResponse {
 		case genai.Text:
 			sb.WriteString(string(p))
 		case genai.FunctionCall:
-			// Convert to types.ToolCall
-			// tc := types.ToolCall{ ... }
-			// msg.ToolCalls = append(msg.ToolCalls, tc)
+			msg.ToolCalls = append(msg.ToolCalls, toToolCall(p))
 		}
 	}
 	msg.Content = sb.String()
 
+	finish := toFinishReason(cand.FinishReason)
+	if len(msg.ToolCalls) > 0 {
+		finish = "tool_calls"
+	}
 	return &types.ChatResponse{
+		ID:           id,
 		Message:      msg,
-		FinishReason: toFinishReason(cand.FinishReason),
-		// Usage: Usage is not always available in standard response struct easily?
-		// It is in resp.UsageMetadata
+		FinishReason: finish,
+		Usage:        usage,
+	}
+}
+
+// toToolCall converts a function call into an OpenAI-shaped tool call. Gemini
+// calls carry no ID, so one is minted.
+func toToolCall(fc genai.FunctionCall) types.ToolCall {
+	args := "{}"
+	if len(fc.Args) > 0 {
+		if b, err := json.Marshal(fc.Args); err == nil {
+			args = string(b)
+		}
+	}
+	return types.NewToolCall(types.NewToolCallID(), fc.Name, args)
+}
+
+// toolCallNames maps the ID of every tool call in messages to its function name.
+func toolCallNames(messages []types.Message) map[string]string {
+	names := make(map[string]string)
+	for _, msg := range messages {
+		for _, tc := range msg.ToolCalls {
+			names[tc.ID] = tc.Function.Name
+		}
+	}
+	return names
+}
+
+// functionResponse wraps a tool result for a FunctionResponse part. A JSON
+// object is passed through; other output is wrapped under "content", or
+// "error" for a failed call.
+func functionResponse(msg types.Message) map[string]any {
+	var obj map[string]any
+	if json.Unmarshal([]byte(msg.Content), &obj) == nil && obj != nil {
+		return obj
+	}
+	key := "content"
+	if msg.Metadata["error"] == true {
+		key = "error"
+	}
+	return map[string]any{key: msg.Content}
+}
+
+// wrapError normalizes Google API failures into *provider.Error.
+func wrapError(err error) error {
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		return provider.NewError("gemini", apiErr.Code, "", apiErr.Message, err)
+	}
+	return provider.NewError("gemini", 0, "", "", err)
+}
+
+// toUsage converts Gemini token counts into types.Usage.
+func toUsage(m *genai.UsageMetadata) types.Usage {
+	return types.Usage{
+		PromptTokens:     int(m.PromptTokenCount),
+		CompletionTokens: int(m.CandidatesTokenCount),
+		TotalTokens:      int(m.TotalTokenCount),
 	}
 }
 
+// toFinishReason maps Gemini finish reasons onto the OpenAI-style values used
+// elsewhere. Recitation, output withheld for reproducing source material, has
+// no OpenAI equivalent and keeps its own name.
 func toFinishReason(fr genai.FinishReason) string {
 	switch fr {
 	case genai.FinishReasonStop:
 		return "stop"
 	case genai.FinishReasonMaxTokens:
 		return "length"
+	case genai.FinishReasonSafety:
+		return "content_filter"
+	case genai.FinishReasonRecitation:
+		return "recitation"
+	case genai.FinishReasonOther:
+		return "other"
 	default:
 		return fmt.Sprintf("unknown:%d", fr)
 	}
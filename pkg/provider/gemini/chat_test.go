@@ -0,0 +1,241 @@
+package gemini
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/google/generative-ai-go/genai"
+
+	"giai/pkg/provider"
+	"giai/pkg/types"
+)
+
+func TestConvertToGeminiTools(t *testing.T) {
+	def := types.NewToolDefinition("search", "Search the web.", map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"query": map[string]any{"type": "string", "description": "Search terms"},
+			"limit": map[string]any{"type": []any{"integer", "null"}},
+			"tags":  map[string]any{"type": "array", "items": map[string]any{"type": "string", "enum": []any{"a", "b"}}},
+		},
+		"required": []any{"query"},
+	})
+
+	tools, err := convertToGeminiTools([]types.ToolDefinition{def, types.NewToolDefinition("clock", "Current time.", nil)})
+	if err != nil {
+		t.Fatalf("convertToGeminiTools() error = %v", err)
+	}
+	if len(tools) != 1 || len(tools[0].FunctionDeclarations) != 2 {
+		t.Fatalf("tools = %+v, want one tool with two declarations", tools)
+	}
+	decl := tools[0].FunctionDeclarations[0]
+	params := decl.Parameters
+	if decl.Name != "search" || params.Type != genai.TypeObject || len(params.Required) != 1 || params.Required[0] != "query" {
+		t.Fatalf("declaration = %+v, parameters = %+v", decl, params)
+	}
+	if q := params.Properties["query"]; q.Type != genai.TypeString || q.Description != "Search terms" {
+		t.Errorf("query = %+v", q)
+	}
+	if l := params.Properties["limit"]; l.Type != genai.TypeInteger || !l.Nullable {
+		t.Errorf("limit = %+v, want a nullable integer", l)
+	}
+	if tags := params.Properties["tags"]; tags.Type != genai.TypeArray || tags.Items.Type != genai.TypeString || len(tags.Items.Enum) != 2 {
+		t.Errorf("tags = %+v", tags)
+	}
+	if clock := tools[0].FunctionDeclarations[1]; clock.Parameters != nil {
+		t.Errorf("clock parameters = %+v, want none", clock.Parameters)
+	}
+
+	bad := types.NewToolDefinition("bad", "", map[string]any{"properties": map[string]any{"x": map[string]any{"anyOf": []any{}}}})
+	if _, err := convertToGeminiTools([]types.ToolDefinition{bad}); err == nil {
+		t.Error("convertToGeminiTools() expected error for an unsupported schema")
+	}
+}
+
+func TestToChatResponse_FunctionCalls(t *testing.T) {
+	resp := &genai.GenerateContentResponse{Candidates: []*genai.Candidate{{
+		Content: &genai.Content{Parts: []genai.Part{
+			genai.Text("Checking."),
+			genai.FunctionCall{Name: "search", Args: map[string]any{"query": "go"}},
+			genai.FunctionCall{Name: "clock"},
+		}},
+		FinishReason: genai.FinishReasonStop,
+	}}}
+
+	got := toChatResponse(resp)
+	calls := got.Message.ToolCalls
+	if got.Message.Content != "Checking." || got.FinishReason != "tool_calls" || len(calls) != 2 {
+		t.Fatalf("toChatResponse() = %+v", got)
+	}
+	if calls[0].Function.Name != "search" || calls[0].Function.Arguments != `{"query":"go"}` || calls[1].Function.Arguments != "{}" {
+		t.Errorf("tool calls = %+v", calls)
+	}
+	if calls[0].ID == "" || calls[0].ID == calls[1].ID {
+		t.Errorf("tool call IDs = %q, %q, want unique non-empty IDs", calls[0].ID, calls[1].ID)
+	}
+	// The same call made again later in a conversation needs a fresh ID.
+	again := toChatResponse(resp)
+	if again.Message.ToolCalls[0].ID == calls[0].ID {
+		t.Errorf("repeated call reused ID %q", calls[0].ID)
+	}
+	if got.ID == "" || again.ID == got.ID {
+		t.Errorf("response IDs = %q, %q, want unique non-empty IDs", got.ID, again.ID)
+	}
+}
+
+func TestPrepareSession_ToolRoundTrip(t *testing.T) {
+	m, err := NewChatModel(context.Background(), Config{APIKey: "test-key"})
+	if err != nil {
+		t.Fatalf("NewChatModel() error = %v", err)
+	}
+	msgs := []types.Message{
+		types.SystemMessage("be brief"),
+		types.UserMessage("weather and time?"),
+		types.AssistantToolCall(
+			types.NewToolCall("call_1", "weather", `{"city":"Oslo"}`),
+			types.NewToolCall("call_2", "clock", `{}`),
+		),
+		types.ToolResultMessage("call_1", `{"temp":3}`),
+		types.ToolResultMessage("call_2", "12:00"),
+	}
+
+	cs, parts, err := m.(*ChatModel).prepareSession(msgs, []provider.Option{provider.WithToolChoice("required")})
+	if err != nil {
+		t.Fatalf("prepareSession() error = %v", err)
+	}
+
+	if len(cs.History) != 2 || cs.History[1].Role != "model" {
+		t.Fatalf("history = %+v, want the user turn and the model's calls", cs.History)
+	}
+	call, ok := cs.History[1].Parts[0].(genai.FunctionCall)
+	if !ok || call.Name != "weather" || call.Args["city"] != "Oslo" {
+		t.Errorf("model turn = %+v, want the weather function call", cs.History[1].Parts)
+	}
+
+	if len(parts) != 2 {
+		t.Fatalf("parts = %+v, want both tool results sent together", parts)
+	}
+	first, _ := parts[0].(genai.FunctionResponse)
+	second, _ := parts[1].(genai.FunctionResponse)
+	if first.Name != "weather" || first.Response["temp"] != float64(3) {
+		t.Errorf("first response = %+v", parts[0])
+	}
+	if second.Name != "clock" || second.Response["content"] != "12:00" {
+		t.Errorf("second response = %+v", parts[1])
+	}
+}
+
+func TestToGeminiParts_Images(t *testing.T) {
+	tests := []struct {
+		name    string
+		part    types.ContentPart
+		want    genai.Blob
+		wantErr bool
+	}{
+		{"Inline Bytes", types.ImagePart("image/png", []byte("png")), genai.Blob{MIMEType: "image/png", Data: []byte("png")}, false},
+		{"Data URL", types.ImageURLPart("data:image/jpeg;base64,anBn"), genai.Blob{MIMEType: "image/jpeg", Data: []byte("jpg")}, false},
+		{"Remote URL", types.ImageURLPart("https://example.com/cat.png"), genai.Blob{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := types.Message{Role: types.RoleUser, Content: "what is this?", Parts: []types.ContentPart{tt.part}}
+			parts, err := toGeminiParts(msg, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("toGeminiParts() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(parts) != 2 || !reflect.DeepEqual(parts[1], tt.want) {
+				t.Errorf("toGeminiParts() = %+v, want the text and %+v", parts, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrepareSession_ServerTools(t *testing.T) {
+	m, err := NewChatModel(context.Background(), Config{APIKey: "test-key"})
+	if err != nil {
+		t.Fatalf("NewChatModel() error = %v", err)
+	}
+	if _, _, err := m.(*ChatModel).prepareSession([]types.Message{types.UserMessage("hi")}, []provider.Option{
+		provider.WithServerTools("web_search_20250305"),
+	}); err == nil {
+		t.Error("prepareSession() expected error for server tools")
+	}
+}
+
+func TestPrepareSession_IgnoresThinkingBudget(t *testing.T) {
+	m, err := NewChatModel(context.Background(), Config{APIKey: "test-key"})
+	if err != nil {
+		t.Fatalf("NewChatModel() error = %v", err)
+	}
+	msgs := []types.Message{types.UserMessage("hi")}
+
+	plain, _, err := m.(*ChatModel).prepareSession(msgs, nil)
+	if err != nil {
+		t.Fatalf("prepareSession() error = %v", err)
+	}
+	thinking, _, err := m.(*ChatModel).prepareSession(msgs, []provider.Option{provider.WithThinkingBudget(1024)})
+	if err != nil {
+		t.Fatalf("prepareSession() with ThinkingBudget error = %v", err)
+	}
+	if !reflect.DeepEqual(plain, thinking) {
+		t.Errorf("prepareSession() with ThinkingBudget = %+v, want the same session as without", thinking)
+	}
+}
+
+func TestSystemInstruction(t *testing.T) {
+	msgs := []types.Message{
+		types.SystemMessage("be brief"),
+		types.UserMessage("earlier"),
+		types.AssistantMessage("ok"),
+		types.SystemMessage("Current date and time: Monday."),
+	}
+
+	got := systemInstruction(msgs)
+	want := []genai.Part{genai.Text("be brief"), genai.Text("Current date and time: Monday.")}
+	if got == nil || !reflect.DeepEqual(got.Parts, want) {
+		t.Errorf("systemInstruction() = %+v, want both system messages in order", got)
+	}
+	if got := systemInstruction(msgs[1:3]); got != nil {
+		t.Errorf("systemInstruction() = %+v, want nil without system messages", got)
+	}
+}
+
+func TestToChatResponse_UsageAndFinishReason(t *testing.T) {
+	resp := &genai.GenerateContentResponse{
+		Candidates: []*genai.Candidate{{
+			Content:      &genai.Content{Parts: []genai.Part{genai.Text("hi")}},
+			FinishReason: genai.FinishReasonStop,
+		}},
+		UsageMetadata: &genai.UsageMetadata{PromptTokenCount: 7, CandidatesTokenCount: 2, TotalTokenCount: 9},
+	}
+	got := toChatResponse(resp)
+	if got.Usage != (types.Usage{PromptTokens: 7, CompletionTokens: 2, TotalTokens: 9}) {
+		t.Errorf("Usage = %+v", got.Usage)
+	}
+
+	blocked := &genai.GenerateContentResponse{Candidates: []*genai.Candidate{{FinishReason: genai.FinishReasonSafety}}}
+	if got := toChatResponse(blocked).FinishReason; got != "content_filter" {
+		t.Errorf("blocked FinishReason = %q, want content_filter", got)
+	}
+
+	tests := []struct {
+		reason genai.FinishReason
+		want   string
+	}{
+		{genai.FinishReasonStop, "stop"},
+		{genai.FinishReasonMaxTokens, "length"},
+		{genai.FinishReasonSafety, "content_filter"},
+		{genai.FinishReasonRecitation, "recitation"},
+		{genai.FinishReasonOther, "other"},
+	}
+	for _, tt := range tests {
+		if got := toFinishReason(tt.reason); got != tt.want {
+			t.Errorf("toFinishReason(%v) = %q, want %q", tt.reason, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package gemini
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/generative-ai-go/genai"
+
+	"giai/pkg/types"
+)
+
+// convertToGeminiTools maps OpenAI-style function definitions to a single
+// Gemini tool holding one declaration per function.
+func convertToGeminiTools(defs []types.ToolDefinition) ([]*genai.Tool, error) {
+	decls := make([]*genai.FunctionDeclaration, 0, len(defs))
+	for _, def := range defs {
+		decl := &genai.FunctionDeclaration{
+			Name:        def.Function.Name,
+			Description: def.Function.Description,
+		}
+		params, err := toSchemaMap(def.Function.Parameters)
+		if err != nil {
+			return nil, fmt.Errorf("gemini: tool %q: %w", def.Function.Name, err)
+		}
+		// Gemini rejects an object schema without properties; omit it instead.
+		if props, _ := params["properties"].(map[string]any); len(props) > 0 {
+			decl.Parameters, err = convertSchema(params, "parameters")
+			if err != nil {
+				return nil, fmt.Errorf("gemini: tool %q: %w", def.Function.Name, err)
+			}
+		}
+		decls = append(decls, decl)
+	}
+	return []*genai.Tool{{FunctionDeclarations: decls}}, nil
+}
+
+// toSchemaMap normalizes a JSON Schema given as a map, raw JSON or any
+// marshalable value into a generic map.
+func toSchemaMap(schema any) (map[string]any, error) {
+	switch s := schema.(type) {
+	case nil:
+		return nil, nil
+	case map[string]any:
+		return s, nil
+	}
+	raw, ok := schema.(json.RawMessage)
+	if !ok {
+		var err error
+		if raw, err = json.Marshal(schema); err != nil {
+			return nil, fmt.Errorf("encode parameters: %w", err)
+		}
+	}
+	var m map[string]any
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, fmt.Errorf("parameters must be a JSON object: %w", err)
+	}
+	return m, nil
+}
+
+var schemaTypes = map[string]genai.Type{
+	"string":  genai.TypeString,
+	"number":  genai.TypeNumber,
+	"integer": genai.TypeInteger,
+	"boolean": genai.TypeBoolean,
+	"array":   genai.TypeArray,
+	"object":  genai.TypeObject,
+}
+
+// convertSchema translates the subset of JSON Schema Gemini understands.
+// path names the schema in errors.
+func convertSchema(m map[string]any, path string) (*genai.Schema, error) {
+	s := &genai.Schema{}
+	s.Description, _ = m["description"].(string)
+	s.Format, _ = m["format"].(string)
+
+	// "type" is a name, or a list of names where "null" marks the value nullable.
+	var typeName string
+	switch t := m["type"].(type) {
+	case string:
+		typeName = t
+	case []any:
+		for _, v := range t {
+			if name, _ := v.(string); name == "null" {
+				s.Nullable = true
+			} else if typeName == "" {
+				typeName = name
+			}
+		}
+	case nil:
+		// Infer the obvious cases rather than reject them.
+		if _, ok := m["properties"]; ok {
+			typeName = "object"
+		} else if _, ok := m["items"]; ok {
+			typeName = "array"
+		}
+	}
+	typ, ok := schemaTypes[typeName]
+	if !ok {
+		return nil, fmt.Errorf("%s: unsupported schema type %v", path, m["type"])
+	}
+	s.Type = typ
+
+	if enum, ok := m["enum"].([]any); ok {
+		for _, v := range enum {
+			s.Enum = append(s.Enum, fmt.Sprint(v))
+		}
+	}
+	if items, ok := m["items"].(map[string]any); ok {
+		item, err := convertSchema(items, path+".items")
+		if err != nil {
+			return nil, err
+		}
+		s.Items = item
+	}
+	if props, ok := m["properties"].(map[string]any); ok {
+		s.Properties = make(map[string]*genai.Schema, len(props))
+		for name, v := range props {
+			prop, _ := v.(map[string]any)
+			ps, err := convertSchema(prop, path+"."+name)
+			if err != nil {
+				return nil, err
+			}
+			s.Properties[name] = ps
+		}
+	}
+	if required, ok := m["required"].([]any); ok {
+		for _, v := range required {
+			if name, ok := v.(string); ok {
+				s.Required = append(s.Required, name)
+			}
+		}
+	} else if required, ok := m["required"].([]string); ok {
+		s.Required = required
+	}
+	return s, nil
+}
+
+// convertToolChoice maps an OpenAI-style tool choice onto Gemini's function
+// calling config. nil leaves the choice to the model.
+func convertToolChoice(choice any) *genai.ToolConfig {
+	cfg := &genai.FunctionCallingConfig{}
+	switch c := choice.(type) {
+	case string:
+		switch c {
+		case "none":
+			cfg.Mode = genai.FunctionCallingNone
+		case "required":
+			cfg.Mode = genai.FunctionCallingAny
+		default:
+			return nil
+		}
+	case map[string]any:
+		fn, _ := c["function"].(map[string]any)
+		name, _ := fn["name"].(string)
+		cfg.Mode = genai.FunctionCallingAny
+		cfg.AllowedFunctionNames = []string{name}
+	default:
+		return nil
+	}
+	return &genai.ToolConfig{FunctionCallingConfig: cfg}
+}
@@ -0,0 +1,56 @@
+package provider
+
+import (
+	"fmt"
+
+	"giai/pkg/types"
+)
+
+// RouteToolImages prepares tool results carrying images for APIs whose tool
+// messages are text-only. With vision, each run of tool messages is followed by
+// one user message holding their images, labelled by tool call ID; without
+// it, the images are dropped and only the text description is sent. Tool
+// messages always keep their text and lose their parts. messages is not modified.
+func RouteToolImages(messages []types.Message, vision bool) []types.Message {
+	routed := false
+	for _, msg := range messages {
+		if msg.Role == types.RoleTool && msg.HasImages() {
+			routed = true
+			break
+		}
+	}
+	if !routed {
+		return messages
+	}
+
+	out := make([]types.Message, 0, len(messages)+1)
+	var images []types.ContentPart
+	flush := func() {
+		if len(images) > 0 {
+			out = append(out, types.Message{Role: types.RoleUser, Parts: images})
+			images = nil
+		}
+	}
+	for _, msg := range messages {
+		if msg.Role != types.RoleTool {
+			flush()
+			out = append(out, msg)
+			continue
+		}
+		if vision && msg.HasImages() {
+			images = append(images, types.ContentPart{
+				Type: types.PartText,
+				Text: fmt.Sprintf("Image returned by tool call %s:", msg.ToolCallID),
+			})
+			for _, p := range msg.Parts {
+				if p.Type == types.PartImage {
+					images = append(images, p)
+				}
+			}
+		}
+		msg.Parts = nil
+		out = append(out, msg)
+	}
+	flush()
+	return out
+}
@@ -0,0 +1,445 @@
+package ollama
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"giai/pkg/provider"
+	"giai/pkg/types"
+)
+
+// Config contains Ollama runtime options. No credentials are needed.
+type Config struct {
+	BaseURL     string // Defaults to http://localhost:11434
+	Model       string
+	HTTPClient  *http.Client
+	Temperature float64 // Default temperature; zero keeps the model's own default
+}
+
+// ChatModel implements provider.ChatModel using Ollama's /api/chat endpoint.
+type ChatModel struct {
+	baseURL            string
+	client             *http.Client
+	defaultModel       string
+	defaultTemperature float64
+}
+
+const (
+	defaultBaseURL = "http://localhost:11434"
+	defaultModel   = "llama3.1"
+)
+
+// NewChatModel builds an Ollama chat provider.
+func NewChatModel(cfg Config) (provider.ChatModel, error) {
+	baseURL := strings.TrimRight(cfg.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	client := cfg.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	modelName := cfg.Model
+	if strings.TrimSpace(modelName) == "" {
+		modelName = defaultModel
+	}
+
+	return &ChatModel{
+		baseURL:            baseURL,
+		client:             client,
+		defaultModel:       modelName,
+		defaultTemperature: cfg.Temperature,
+	}, nil
+}
+
+func (m *ChatModel) Name() string {
+	return "ollama"
+}
+
+// Capabilities reports what the configured default model supports.
+func (m *ChatModel) Capabilities() provider.Capabilities {
+	return provider.CapabilitiesForModel(m.defaultModel)
+}
+
+// Wire types of the chat API.
+
+type chatRequest struct {
+	Model    string          `json:"model"`
+	Messages []message       `json:"messages"`
+	Tools    []toolSpec      `json:"tools,omitempty"`
+	Format   json.RawMessage `json:"format,omitempty"`
+	Options  *modelOptions   `json:"options,omitempty"`
+	Stream   bool            `json:"stream"`
+}
+
+type message struct {
+	Role      string     `json:"role"`
+	Content   string     `json:"content"`
+	Images    []string   `json:"images,omitempty"` // Base64 encoded
+	ToolCalls []toolCall `json:"tool_calls,omitempty"`
+	ToolName  string     `json:"tool_name,omitempty"` // Tool results: the function that produced them
+}
+
+type toolCall struct {
+	Function struct {
+		Name      string         `json:"name"`
+		Arguments map[string]any `json:"arguments"`
+	} `json:"function"`
+}
+
+type toolSpec struct {
+	Type     string       `json:"type"`
+	Function toolFunction `json:"function"`
+}
+
+type toolFunction struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Parameters  any    `json:"parameters,omitempty"`
+}
+
+// modelOptions are the sampling parameters Ollama takes under "options".
+type modelOptions struct {
+	Temperature      float64  `json:"temperature,omitempty"`
+	TopP             float64  `json:"top_p,omitempty"`
+	NumPredict       int      `json:"num_predict,omitempty"`
+	Stop             []string `json:"stop,omitempty"`
+	Seed             int      `json:"seed,omitempty"`
+	PresencePenalty  float64  `json:"presence_penalty,omitempty"`
+	FrequencyPenalty float64  `json:"frequency_penalty,omitempty"`
+}
+
+// chatResponse is a complete response, or one line of a streamed one.
+type chatResponse struct {
+	Model           string  `json:"model"`
+	CreatedAt       string  `json:"created_at"`
+	Message         message `json:"message"`
+	Done            bool    `json:"done"`
+	DoneReason      string  `json:"done_reason"`
+	PromptEvalCount int     `json:"prompt_eval_count"`
+	EvalCount       int     `json:"eval_count"`
+	Error           string  `json:"error"`
+}
+
+func (m *ChatModel) prepareRequest(messages []types.Message, opts []provider.Option) (chatRequest, error) {
+	// 1. Apply options
+	options := provider.ResolveOptions(provider.ChatOptions{
+		Model:       m.defaultModel,
+		Temperature: m.defaultTemperature,
+	}, opts...)
+	if len(options.ServerTools) > 0 {
+		return chatRequest{}, fmt.Errorf("ollama: server tools %v are not supported", options.ServerTools)
+	}
+
+	// 2. Convert Messages
+	names := toolCallNames(messages)
+	req := chatRequest{Model: options.Model}
+	for _, msg := range messages {
+		oMsg, err := convertMessage(msg, names)
+		if err != nil {
+			return chatRequest{}, err
+		}
+		req.Messages = append(req.Messages, oMsg)
+	}
+
+	// 3. Sampling and format
+	mo := modelOptions{
+		Temperature:      options.Temperature,
+		TopP:             options.TopP,
+		NumPredict:       options.MaxTokens,
+		Stop:             options.Stop,
+		Seed:             options.Seed,
+		PresencePenalty:  options.PresencePenalty,
+		FrequencyPenalty: options.FrequencyPenalty,
+	}
+	if mo.Temperature != 0 || mo.TopP != 0 || mo.NumPredict != 0 || len(mo.Stop) > 0 || mo.Seed != 0 || mo.PresencePenalty != 0 || mo.FrequencyPenalty != 0 {
+		req.Options = &mo
+	}
+	if f := options.ResponseFormat; f != nil {
+		format, err := convertResponseFormat(f)
+		if err != nil {
+			return chatRequest{}, err
+		}
+		req.Format = format
+	}
+
+	// 4. Handle Tools
+	for _, t := range options.Tools {
+		req.Tools = append(req.Tools, toolSpec{
+			Type: "function",
+			Function: toolFunction{
+				Name:        t.Function.Name,
+				Description: t.Function.Description,
+				Parameters:  t.Function.Parameters,
+			},
+		})
+	}
+
+	return req, nil
+}
+
+// convertMessage maps a message onto Ollama's format. names maps tool call
+// IDs to function names, which Ollama uses to match results to calls.
+func convertMessage(msg types.Message, names map[string]string) (message, error) {
+	out := message{Role: string(msg.Role), Content: msg.Content}
+	switch msg.Role {
+	case types.RoleSystem, types.RoleUser, types.RoleAssistant:
+	case types.RoleTool:
+		out.ToolName = names[msg.ToolCallID]
+	default:
+		out.Role = "user" // Fallback
+	}
+
+	for _, tc := range msg.ToolCalls {
+		var call toolCall
+		call.Function.Name = tc.Function.Name
+		if strings.TrimSpace(tc.Function.Arguments) != "" {
+			if err := json.Unmarshal([]byte(tc.Function.Arguments), &call.Function.Arguments); err != nil {
+				return message{}, fmt.Errorf("ollama: tool call %s has invalid JSON arguments: %w", tc.ID, err)
+			}
+		}
+		out.ToolCalls = append(out.ToolCalls, call)
+	}
+
+	for _, p := range msg.Parts {
+		switch p.Type {
+		case types.PartText:
+			if out.Content != "" {
+				out.Content += "\n"
+			}
+			out.Content += p.Text
+		case types.PartImage:
+			img, err := imageData(p)
+			if err != nil {
+				return message{}, err
+			}
+			out.Images = append(out.Images, img)
+		}
+	}
+	return out, nil
+}
+
+// imageData returns an image part as base64. Ollama takes inline images only,
+// so remote URLs are rejected.
+func imageData(p types.ContentPart) (string, error) {
+	if p.ImageURL == "" {
+		return base64.StdEncoding.EncodeToString(p.Data), nil
+	}
+	if rest, ok := strings.CutPrefix(p.ImageURL, "data:"); ok {
+		if _, data, ok := strings.Cut(rest, ";base64,"); ok {
+			return data, nil
+		}
+	}
+	return "", fmt.Errorf("ollama: image URLs are not supported, pass the image bytes instead: %s", p.ImageURL)
+}
+
+// convertResponseFormat maps a response format onto Ollama's "format" field:
+// "json" for JSON mode, or the schema itself.
+func convertResponseFormat(f *provider.ResponseFormat) (json.RawMessage, error) {
+	switch f.Type {
+	case provider.ResponseFormatJSONObject:
+		return json.RawMessage(`"json"`), nil
+	case provider.ResponseFormatJSONSchema:
+		schema, err := json.Marshal(f.Schema)
+		if err != nil {
+			return nil, fmt.Errorf("ollama: encode response schema: %w", err)
+		}
+		return schema, nil
+	}
+	return nil, fmt.Errorf("ollama: unsupported response format %q", f.Type)
+}
+
+// toolCallNames maps the ID of every tool call in messages to its function name.
+func toolCallNames(messages []types.Message) map[string]string {
+	names := make(map[string]string)
+	for _, msg := range messages {
+		for _, tc := range msg.ToolCalls {
+			names[tc.ID] = tc.Function.Name
+		}
+	}
+	return names
+}
+
+// toToolCall converts the index-th call of the response. Ollama does not
+// assign IDs, so one is minted.
+func toToolCall(tc toolCall, index int) types.ToolCall {
+	args := "{}"
+	if len(tc.Function.Arguments) > 0 {
+		if b, err := json.Marshal(tc.Function.Arguments); err == nil {
+			args = string(b)
+		}
+	}
+	call := types.NewToolCall(types.NewToolCallID(), tc.Function.Name, args)
+	call.Index = index
+	return call
+}
+
+// finishReason maps done_reason onto the OpenAI-style values used elsewhere.
+func finishReason(reason string, hasCalls bool) string {
+	if hasCalls {
+		return "tool_calls"
+	}
+	return reason
+}
+
+func toUsage(r chatResponse) types.Usage {
+	return types.Usage{
+		PromptTokens:     r.PromptEvalCount,
+		CompletionTokens: r.EvalCount,
+		TotalTokens:      r.PromptEvalCount + r.EvalCount,
+	}
+}
+
+// Chat implements provider.ChatModel.Chat
+func (m *ChatModel) Chat(ctx context.Context, messages []types.Message, opts ...provider.Option) (*types.ChatResponse, error) {
+	req, err := m.prepareRequest(messages, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := m.do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var resp chatResponse
+	if err := json.NewDecoder(body).Decode(&resp); err != nil {
+		return nil, provider.NewError("ollama", 0, "", "invalid response", err)
+	}
+	if resp.Error != "" {
+		return nil, provider.NewError("ollama", 0, "", resp.Error, errors.New(resp.Error))
+	}
+
+	chatMsg := types.Message{Role: types.RoleAssistant, Content: resp.Message.Content}
+	for i, tc := range resp.Message.ToolCalls {
+		chatMsg.ToolCalls = append(chatMsg.ToolCalls, toToolCall(tc, i))
+	}
+
+	// Ollama reports no response ID, so one is minted.
+	out := &types.ChatResponse{
+		ID:           types.NewResponseID(),
+		Message:      chatMsg,
+		FinishReason: finishReason(resp.DoneReason, len(chatMsg.ToolCalls) > 0),
+		Model:        resp.Model,
+		Usage:        toUsage(resp),
+	}
+	if provider.ResolveOptions(provider.ChatOptions{}, opts...).IncludeRaw {
+		out.Raw = resp
+	}
+	return out, nil
+}
+
+// Stream implements provider.ChatModel.Stream
+func (m *ChatModel) Stream(ctx context.Context, messages []types.Message, opts ...provider.Option) (<-chan provider.ChatChunk, error) {
+	req, err := m.prepareRequest(messages, opts)
+	if err != nil {
+		return nil, err
+	}
+	req.Stream = true
+
+	body, err := m.do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan provider.ChatChunk)
+	go func() {
+		defer close(ch)
+		defer body.Close()
+
+		// The stream is newline-delimited JSON; the object with done:true
+		// carries the finish reason and usage.
+		calls := 0
+		id := types.NewResponseID()
+		scanner := bufio.NewScanner(body)
+		scanner.Buffer(make([]byte, 64*1024), 1<<20)
+		for scanner.Scan() {
+			line := bytes.TrimSpace(scanner.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+			var resp chatResponse
+			if err := json.Unmarshal(line, &resp); err != nil {
+				ch <- provider.ChatChunk{Error: provider.NewError("ollama", 0, "", "invalid stream line", err)}
+				return
+			}
+			if resp.Error != "" {
+				ch <- provider.ChatChunk{Error: provider.NewError("ollama", 0, "", resp.Error, errors.New(resp.Error))}
+				return
+			}
+
+			if resp.Message.Content != "" {
+				ch <- provider.ChatChunk{Content: resp.Message.Content, ID: id}
+			}
+			for _, tc := range resp.Message.ToolCalls {
+				call := toToolCall(tc, calls)
+				calls++
+				ch <- provider.ChatChunk{ToolCall: &call, ID: id}
+			}
+			if resp.Done {
+				usage := toUsage(resp)
+				ch <- provider.ChatChunk{FinishReason: finishReason(resp.DoneReason, calls > 0), Usage: &usage, ID: id}
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			ch <- provider.ChatChunk{Error: provider.NewError("ollama", 0, "", "", err)}
+		} else {
+			ch <- provider.ChatChunk{Error: provider.NewError("ollama", 0, "", "stream ended before done", io.ErrUnexpectedEOF)}
+		}
+	}()
+
+	return ch, nil
+}
+
+// do sends req and returns the response body, normalizing API failures.
+func (m *ChatModel) do(ctx context.Context, req chatRequest) (io.ReadCloser, error) {
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("ollama: encode request: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, m.baseURL+"/api/chat", bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("ollama: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := m.client.Do(httpReq)
+	if err != nil {
+		return nil, provider.NewError("ollama", 0, "", "", err)
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return resp.Body, nil
+	}
+	defer resp.Body.Close()
+	return nil, wrapError(resp.StatusCode, resp.Body)
+}
+
+// Helpers
+
+// wrapError normalizes an Ollama error response, {"error": "..."}, into *provider.Error.
+func wrapError(status int, body io.Reader) error {
+	raw, _ := io.ReadAll(io.LimitReader(body, 1<<16))
+	var apiErr struct {
+		Error string `json:"error"`
+	}
+	msg := strings.TrimSpace(string(raw))
+	if json.Unmarshal(raw, &apiErr) == nil && apiErr.Error != "" {
+		msg = apiErr.Error
+	}
+	if msg == "" {
+		msg = http.StatusText(status)
+	}
+	return provider.NewError("ollama", status, "", msg, errors.New(msg))
+}
+
+var _ provider.ChatModel = (*ChatModel)(nil)
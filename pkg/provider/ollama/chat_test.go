@@ -0,0 +1,170 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"giai/pkg/provider"
+	"giai/pkg/types"
+)
+
+func TestPrepareRequest_Messages(t *testing.T) {
+	m, err := NewChatModel(Config{})
+	if err != nil {
+		t.Fatalf("NewChatModel() error = %v", err)
+	}
+	msgs := []types.Message{
+		types.SystemMessage("be brief"),
+		types.UserMessage("weather?"),
+		types.AssistantToolCall(types.NewToolCall("call_1", "weather", `{"city":"Oslo"}`)),
+		types.ToolResultMessage("call_1", "3C"),
+		types.UserImageMessage("and this?", "data:image/png;base64,AAAA"),
+	}
+	def := types.NewToolDefinition("weather", "Get the weather.", map[string]any{"type": "object"})
+
+	req, err := m.(*ChatModel).prepareRequest(msgs, []provider.Option{provider.WithTools(def), provider.WithSeed(7)})
+	if err != nil {
+		t.Fatalf("prepareRequest() error = %v", err)
+	}
+	if req.Model != defaultModel || len(req.Messages) != 5 {
+		t.Fatalf("request = %+v", req)
+	}
+	call := req.Messages[2].ToolCalls
+	if len(call) != 1 || call[0].Function.Name != "weather" || call[0].Function.Arguments["city"] != "Oslo" {
+		t.Errorf("assistant tool calls = %+v", call)
+	}
+	if res := req.Messages[3]; res.Role != "tool" || res.ToolName != "weather" || res.Content != "3C" {
+		t.Errorf("tool result = %+v", res)
+	}
+	if img := req.Messages[4]; len(img.Images) != 1 || img.Images[0] != "AAAA" {
+		t.Errorf("image message = %+v, want the base64 data", img)
+	}
+	if len(req.Tools) != 1 || req.Tools[0].Type != "function" || req.Tools[0].Function.Name != "weather" {
+		t.Errorf("Tools = %+v", req.Tools)
+	}
+	if req.Options == nil || req.Options.Seed != 7 || req.Options.Temperature != 0 {
+		t.Errorf("Options = %+v, want only the seed", req.Options)
+	}
+
+	if _, err := m.(*ChatModel).prepareRequest([]types.Message{types.UserImageMessage("hi", "https://example.com/a.png")}, nil); err == nil {
+		t.Error("prepareRequest() expected error for a remote image URL")
+	}
+}
+
+func TestPrepareRequest_ServerTools(t *testing.T) {
+	m, err := NewChatModel(Config{})
+	if err != nil {
+		t.Fatalf("NewChatModel() error = %v", err)
+	}
+	if _, err := m.(*ChatModel).prepareRequest([]types.Message{types.UserMessage("hi")}, []provider.Option{
+		provider.WithServerTools("web_search_20250305"),
+	}); err == nil {
+		t.Error("prepareRequest() expected error for server tools")
+	}
+}
+
+func TestChat(t *testing.T) {
+	var got chatRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("path = %s, want /api/chat", r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		fmt.Fprint(w, `{"model":"llama3.1","message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"weather","arguments":{"city":"Oslo"}}}]},"done":true,"done_reason":"stop","prompt_eval_count":12,"eval_count":5}`)
+	}))
+	defer srv.Close()
+
+	m, _ := NewChatModel(Config{BaseURL: srv.URL, Temperature: 0.3})
+	resp, err := m.Chat(context.Background(), []types.Message{types.UserMessage("weather?")})
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if got.Stream || got.Options == nil || got.Options.Temperature != 0.3 {
+		t.Errorf("request = %+v, want a non-streaming request with the default temperature", got)
+	}
+	calls := resp.Message.ToolCalls
+	if len(calls) != 1 || calls[0].ID == "" || calls[0].Function.Name != "weather" || calls[0].Function.Arguments != `{"city":"Oslo"}` {
+		t.Errorf("ToolCalls = %+v", calls)
+	}
+	if resp.FinishReason != "tool_calls" || resp.Usage != (types.Usage{PromptTokens: 12, CompletionTokens: 5, TotalTokens: 17}) {
+		t.Errorf("finish = %q, usage = %+v", resp.FinishReason, resp.Usage)
+	}
+
+	// Ollama sends no call IDs; asking again must not reproduce the same one.
+	again, err := m.Chat(context.Background(), []types.Message{types.UserMessage("weather?")})
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if len(calls) == 1 && again.Message.ToolCalls[0].ID == calls[0].ID {
+		t.Errorf("repeated call reused ID %q", calls[0].ID)
+	}
+	if resp.ID == "" || again.ID == resp.ID {
+		t.Errorf("response IDs = %q, %q, want unique non-empty IDs", resp.ID, again.ID)
+	}
+}
+
+func TestChat_Error(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error":"model \"nope\" not found, try pulling it first"}`)
+	}))
+	defer srv.Close()
+
+	m, _ := NewChatModel(Config{BaseURL: srv.URL, Model: "nope"})
+	_, err := m.Chat(context.Background(), []types.Message{types.UserMessage("hi")})
+	var perr *provider.Error
+	if !errors.As(err, &perr) || perr.StatusCode != http.StatusNotFound || perr.Message != `model "nope" not found, try pulling it first` {
+		t.Errorf("Chat() error = %v, want the API message with status 404", err)
+	}
+}
+
+const streamBody = `{"model":"llama3.1","message":{"role":"assistant","content":"Hel"},"done":false}
+{"model":"llama3.1","message":{"role":"assistant","content":"lo"},"done":false}
+{"model":"llama3.1","message":{"role":"assistant","content":""},"done":true,"done_reason":"length","prompt_eval_count":4,"eval_count":2}
+`
+
+func TestStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req chatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if !req.Stream {
+			t.Error("request did not ask for a stream")
+		}
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		fmt.Fprint(w, streamBody)
+	}))
+	defer srv.Close()
+
+	m, _ := NewChatModel(Config{BaseURL: srv.URL})
+	var deltas string
+	resp, err := provider.StreamAndCollect(context.Background(), m, []types.Message{types.UserMessage("hi")}, func(d string) { deltas += d })
+	if err != nil {
+		t.Fatalf("StreamAndCollect() error = %v", err)
+	}
+	if deltas != "Hello" || resp.Message.Content != "Hello" {
+		t.Errorf("content = %q (deltas %q), want Hello", resp.Message.Content, deltas)
+	}
+	if resp.FinishReason != "length" || resp.Usage.TotalTokens != 6 {
+		t.Errorf("finish = %q, usage = %+v", resp.FinishReason, resp.Usage)
+	}
+	if resp.ID == "" {
+		t.Error("ID is empty, want a minted response ID")
+	}
+}
+
+func TestStream_Truncated(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"message":{"role":"assistant","content":"Hel"},"done":false}`+"\n")
+	}))
+	defer srv.Close()
+
+	m, _ := NewChatModel(Config{BaseURL: srv.URL})
+	if _, err := provider.StreamAndCollect(context.Background(), m, []types.Message{types.UserMessage("hi")}, nil); err == nil {
+		t.Error("StreamAndCollect() expected error for a stream without done")
+	}
+}
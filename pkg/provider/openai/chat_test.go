@@ -2,9 +2,18 @@ package openai
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 
+	goopenai "github.com/sashabaranov/go-openai"
+
 	"giai/pkg/provider"
 	"giai/pkg/types"
 )
@@ -41,6 +50,498 @@ func TestNewChatModel(t *testing.T) {
 	}
 }
 
+func TestPrepareRequest_User(t *testing.T) {
+	m, err := NewChatModel(Config{APIKey: "test-key"})
+	if err != nil {
+		t.Fatalf("NewChatModel() error = %v", err)
+	}
+
+	msgs := []types.Message{{Role: types.RoleUser, Content: "hi"}}
+
+	req, err := m.(*ChatModel).prepareRequest(msgs, []provider.Option{provider.WithUser("user-123")})
+	if err != nil {
+		t.Fatalf("prepareRequest() error = %v", err)
+	}
+	if req.User != "user-123" {
+		t.Errorf("req.User = %q, want %q", req.User, "user-123")
+	}
+
+	req, err = m.(*ChatModel).prepareRequest(msgs, nil)
+	if err != nil {
+		t.Fatalf("prepareRequest() error = %v", err)
+	}
+	if req.User != "" {
+		t.Errorf("req.User = %q, want empty when unset", req.User)
+	}
+}
+
+func TestPrepareRequest_ReasoningEffort(t *testing.T) {
+	m, err := NewChatModel(Config{APIKey: "test-key"})
+	if err != nil {
+		t.Fatalf("NewChatModel() error = %v", err)
+	}
+	msgs := []types.Message{{Role: types.RoleUser, Content: "hi"}}
+
+	req, err := m.(*ChatModel).prepareRequest(msgs, []provider.Option{provider.WithReasoningEffort("high")})
+	if err != nil {
+		t.Fatalf("prepareRequest() error = %v", err)
+	}
+	if req.ReasoningEffort != "high" {
+		t.Errorf("req.ReasoningEffort = %q, want %q", req.ReasoningEffort, "high")
+	}
+
+	if _, err := m.(*ChatModel).prepareRequest(msgs, []provider.Option{provider.WithReasoningEffort("extreme")}); err == nil {
+		t.Error("prepareRequest() expected error for invalid reasoning effort")
+	}
+}
+
+func TestPrepareRequest_ReasoningModel(t *testing.T) {
+	m, err := NewChatModel(Config{APIKey: "test-key", Model: "o1-mini"})
+	if err != nil {
+		t.Fatalf("NewChatModel() error = %v", err)
+	}
+	msgs := []types.Message{
+		types.SystemMessage("Be terse."),
+		types.UserMessage("hi"),
+	}
+
+	req, err := m.(*ChatModel).prepareRequest(msgs, []provider.Option{func(o *provider.ChatOptions) { o.MaxTokens = 100 }})
+	if err != nil {
+		t.Fatalf("prepareRequest() error = %v", err)
+	}
+	for _, msg := range req.Messages {
+		if msg.Role == goopenai.ChatMessageRoleSystem {
+			t.Errorf("request contains a system message: %+v", msg)
+		}
+	}
+	if len(req.Messages) != 1 || req.Messages[0].Content != "Be terse.\n\nhi" {
+		t.Errorf("req.Messages = %+v, want system prompt folded into user message", req.Messages)
+	}
+	if req.Temperature != 0 {
+		t.Errorf("req.Temperature = %v, want unset", req.Temperature)
+	}
+	if req.MaxTokens != 0 || req.MaxCompletionTokens != 100 {
+		t.Errorf("MaxTokens = %d, MaxCompletionTokens = %d, want 0 and 100", req.MaxTokens, req.MaxCompletionTokens)
+	}
+
+	// Regular models are untouched.
+	req, err = m.(*ChatModel).prepareRequest(msgs, []provider.Option{provider.WithModel("gpt-4o")})
+	if err != nil {
+		t.Fatalf("prepareRequest() error = %v", err)
+	}
+	if len(req.Messages) != 2 || req.Temperature == 0 {
+		t.Errorf("gpt-4o request was modified: %d messages, temperature %v", len(req.Messages), req.Temperature)
+	}
+}
+
+func TestPrepareRequest_ParallelToolCalls(t *testing.T) {
+	m, err := NewChatModel(Config{APIKey: "test-key"})
+	if err != nil {
+		t.Fatalf("NewChatModel() error = %v", err)
+	}
+	msgs := []types.Message{{Role: types.RoleUser, Content: "hi"}}
+	withTools := func(o *provider.ChatOptions) {
+		o.Tools = []types.ToolDefinition{types.NewToolDefinition("echo", "Echo.", map[string]any{"type": "object"})}
+	}
+
+	req, err := m.(*ChatModel).prepareRequest(msgs, []provider.Option{withTools, provider.WithParallelToolCalls(false)})
+	if err != nil {
+		t.Fatalf("prepareRequest() error = %v", err)
+	}
+	if req.ParallelToolCalls != false {
+		t.Errorf("req.ParallelToolCalls = %v, want false", req.ParallelToolCalls)
+	}
+
+	req, err = m.(*ChatModel).prepareRequest(msgs, []provider.Option{withTools})
+	if err != nil {
+		t.Fatalf("prepareRequest() error = %v", err)
+	}
+	if req.ParallelToolCalls != nil {
+		t.Errorf("req.ParallelToolCalls = %v, want unset by default", req.ParallelToolCalls)
+	}
+}
+
+func TestPrepareRequest_UserImageMessage(t *testing.T) {
+	m, err := NewChatModel(Config{APIKey: "test-key", Model: "gpt-4o"})
+	if err != nil {
+		t.Fatalf("NewChatModel() error = %v", err)
+	}
+	msgs := []types.Message{
+		types.UserMessage("hello"),
+		types.UserImageMessage("What is in this picture?", "https://example.com/cat.png"),
+	}
+	req, err := m.(*ChatModel).prepareRequest(msgs, nil)
+	if err != nil {
+		t.Fatalf("prepareRequest() error = %v", err)
+	}
+
+	// Text-only messages keep the plain string form on the wire.
+	raw, err := json.Marshal(req.Messages[0])
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if want := `{"role":"user","content":"hello"}`; string(raw) != want {
+		t.Errorf("text message = %s, want %s", raw, want)
+	}
+
+	img := req.Messages[1]
+	if img.Content != "" || len(img.MultiContent) != 2 {
+		t.Fatalf("image message = %+v, want text and image parts", img)
+	}
+	if part := img.MultiContent[0]; part.Type != goopenai.ChatMessagePartTypeText || part.Text != "What is in this picture?" {
+		t.Errorf("text part = %+v", part)
+	}
+	if part := img.MultiContent[1]; part.Type != goopenai.ChatMessagePartTypeImageURL || part.ImageURL.URL != "https://example.com/cat.png" {
+		t.Errorf("image part = %+v", part)
+	}
+}
+
+func TestPrepareRequest_ToolImageResult(t *testing.T) {
+	msgs := []types.Message{
+		types.UserMessage("Take a screenshot."),
+		types.AssistantToolCall(types.NewToolCall("call_1", "screenshot", `{}`)),
+		types.ToolImageResult("call_1", "image/png", []byte("png-bytes")),
+	}
+	wantURL := "data:image/png;base64," + base64.StdEncoding.EncodeToString([]byte("png-bytes"))
+
+	m, err := NewChatModel(Config{APIKey: "test-key", Model: "gpt-4o"})
+	if err != nil {
+		t.Fatalf("NewChatModel() error = %v", err)
+	}
+	req, err := m.(*ChatModel).prepareRequest(msgs, nil)
+	if err != nil {
+		t.Fatalf("prepareRequest() error = %v", err)
+	}
+	if len(req.Messages) != 4 {
+		t.Fatalf("got %d messages, want the tool result followed by a user message with the image", len(req.Messages))
+	}
+	if tool := req.Messages[2]; tool.Role != goopenai.ChatMessageRoleTool || tool.ToolCallID != "call_1" || tool.Content == "" {
+		t.Errorf("tool message = %+v, want text reply to call_1", tool)
+	}
+	images := req.Messages[3]
+	if images.Role != goopenai.ChatMessageRoleUser || len(images.MultiContent) != 2 {
+		t.Fatalf("follow-up message = %+v, want a user message with a label and the image", images)
+	}
+	if part := images.MultiContent[1]; part.ImageURL == nil || part.ImageURL.URL != wantURL {
+		t.Errorf("image part = %+v, want data URL %q", part, wantURL)
+	}
+
+	// Models without vision get the text description only.
+	m, err = NewChatModel(Config{APIKey: "test-key", Model: "gpt-3.5-turbo"})
+	if err != nil {
+		t.Fatalf("NewChatModel() error = %v", err)
+	}
+	req, err = m.(*ChatModel).prepareRequest(msgs, nil)
+	if err != nil {
+		t.Fatalf("prepareRequest() error = %v", err)
+	}
+	if len(req.Messages) != 3 || req.Messages[2].Content != msgs[2].Content {
+		t.Errorf("req.Messages = %+v, want the image dropped for a text-only model", req.Messages)
+	}
+
+	// Unknown models may see; their images are routed rather than dropped.
+	m, err = NewChatModel(Config{APIKey: "test-key", Model: "my-finetune"})
+	if err != nil {
+		t.Fatalf("NewChatModel() error = %v", err)
+	}
+	req, err = m.(*ChatModel).prepareRequest(msgs, nil)
+	if err != nil {
+		t.Fatalf("prepareRequest() error = %v", err)
+	}
+	if len(req.Messages) != 4 {
+		t.Errorf("got %d messages, want the image routed for an unknown model", len(req.Messages))
+	}
+}
+
+type recordingLogger struct{ msgs []string }
+
+func (l *recordingLogger) Info(msg string, keysAndValues ...any) { l.msgs = append(l.msgs, msg) }
+
+func TestPrepareRequest_ClampsTemperature(t *testing.T) {
+	logger := &recordingLogger{}
+	m, err := NewChatModel(Config{APIKey: "test-key", Logger: logger})
+	if err != nil {
+		t.Fatalf("NewChatModel() error = %v", err)
+	}
+	msgs := []types.Message{{Role: types.RoleUser, Content: "hi"}}
+
+	req, err := m.(*ChatModel).prepareRequest(msgs, []provider.Option{provider.WithTemperature(3.0)})
+	if err != nil {
+		t.Fatalf("prepareRequest() error = %v", err)
+	}
+	if req.Temperature != 2 {
+		t.Errorf("req.Temperature = %v, want clamped to 2", req.Temperature)
+	}
+	if len(logger.msgs) != 1 {
+		t.Errorf("logged %v, want one clamping message", logger.msgs)
+	}
+
+	strict, err := NewChatModel(Config{APIKey: "test-key", StrictSampling: true})
+	if err != nil {
+		t.Fatalf("NewChatModel() error = %v", err)
+	}
+	_, err = strict.(*ChatModel).prepareRequest(msgs, []provider.Option{provider.WithTemperature(3.0)})
+	var rangeErr *provider.ParamRangeError
+	if !errors.As(err, &rangeErr) || rangeErr.Param != "temperature" {
+		t.Errorf("prepareRequest() error = %v, want *provider.ParamRangeError for temperature", err)
+	}
+}
+
+func TestPrepareRequest_SamplingParams(t *testing.T) {
+	m, err := NewChatModel(Config{APIKey: "test-key", Model: "gpt-4o"})
+	if err != nil {
+		t.Fatalf("NewChatModel() error = %v", err)
+	}
+	msgs := []types.Message{{Role: types.RoleUser, Content: "hi"}}
+
+	req, err := m.(*ChatModel).prepareRequest(msgs, []provider.Option{
+		provider.WithTopP(0.9),
+		provider.WithPresencePenalty(0.5),
+		provider.WithFrequencyPenalty(-0.5),
+		provider.WithSeed(42),
+	})
+	if err != nil {
+		t.Fatalf("prepareRequest() error = %v", err)
+	}
+	if req.TopP != 0.9 || req.PresencePenalty != 0.5 || req.FrequencyPenalty != -0.5 {
+		t.Errorf("top_p, presence, frequency = %v, %v, %v, want 0.9, 0.5, -0.5", req.TopP, req.PresencePenalty, req.FrequencyPenalty)
+	}
+	if req.Seed == nil || *req.Seed != 42 {
+		t.Errorf("req.Seed = %v, want 42", req.Seed)
+	}
+
+	// Unset values are omitted so the model defaults apply.
+	req, err = m.(*ChatModel).prepareRequest(msgs, nil)
+	if err != nil {
+		t.Fatalf("prepareRequest() error = %v", err)
+	}
+	raw, _ := json.Marshal(req)
+	for _, key := range []string{"top_p", "presence_penalty", "frequency_penalty", "seed"} {
+		if strings.Contains(string(raw), `"`+key+`"`) {
+			t.Errorf("request = %s, want no %s", raw, key)
+		}
+	}
+}
+
+func TestPrepareRequest_ResponseFormat(t *testing.T) {
+	m, err := NewChatModel(Config{APIKey: "test-key", Model: "gpt-4o"})
+	if err != nil {
+		t.Fatalf("NewChatModel() error = %v", err)
+	}
+	msgs := []types.Message{types.UserMessage("Answer in JSON.")}
+	schema := map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"answer": map[string]any{"type": "string"}},
+	}
+
+	tests := []struct {
+		name string
+		opt  provider.Option
+		want string
+	}{
+		{"JSON Mode", provider.WithJSONMode(), `{"type":"json_object"}`},
+		{"JSON Schema", provider.WithJSONSchema("reply", schema, true), `{"type":"json_schema","json_schema":{"name":"reply","schema":{"properties":{"answer":{"type":"string"}},"type":"object"},"strict":true}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := m.(*ChatModel).prepareRequest(msgs, []provider.Option{tt.opt})
+			if err != nil {
+				t.Fatalf("prepareRequest() error = %v", err)
+			}
+			got, _ := json.Marshal(req.ResponseFormat)
+			if string(got) != tt.want {
+				t.Errorf("response_format = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	req, err := m.(*ChatModel).prepareRequest(msgs, nil)
+	if err != nil {
+		t.Fatalf("prepareRequest() error = %v", err)
+	}
+	if req.ResponseFormat != nil {
+		t.Errorf("response_format = %+v, want unset by default", req.ResponseFormat)
+	}
+
+	if _, err := m.(*ChatModel).prepareRequest(msgs, []provider.Option{
+		provider.WithModel("gpt-3.5-turbo"),
+		provider.WithJSONSchema("reply", schema, true),
+	}); err == nil {
+		t.Error("prepareRequest() expected error for a model without JSON schema support")
+	}
+}
+
+func TestPrepareRequest_ToolChoice(t *testing.T) {
+	m, err := NewChatModel(Config{APIKey: "test-key"})
+	if err != nil {
+		t.Fatalf("NewChatModel() error = %v", err)
+	}
+	msgs := []types.Message{{Role: types.RoleUser, Content: "hi"}}
+	echo := types.NewToolDefinition("echo", "Echo.", map[string]any{"type": "object"})
+
+	req, err := m.(*ChatModel).prepareRequest(msgs, []provider.Option{
+		provider.WithTools(echo),
+		provider.WithToolChoice(provider.ForceTool("echo")),
+	})
+	if err != nil {
+		t.Fatalf("prepareRequest() error = %v", err)
+	}
+	if len(req.Tools) != 1 || req.Tools[0].Function.Name != "echo" {
+		t.Errorf("req.Tools = %+v, want echo", req.Tools)
+	}
+	got, _ := json.Marshal(req.ToolChoice)
+	if want := `{"function":{"name":"echo"},"type":"function"}`; string(got) != want {
+		t.Errorf("tool_choice = %s, want %s", got, want)
+	}
+
+	if _, err := m.(*ChatModel).prepareRequest(msgs, []provider.Option{
+		provider.WithTools(echo),
+		provider.WithToolChoice("always"),
+	}); err == nil {
+		t.Error("prepareRequest() expected error for invalid tool choice")
+	}
+}
+
+func TestPrepareRequest_ServerTools(t *testing.T) {
+	m, err := NewChatModel(Config{APIKey: "test-key"})
+	if err != nil {
+		t.Fatalf("NewChatModel() error = %v", err)
+	}
+	msgs := []types.Message{{Role: types.RoleUser, Content: "hi"}}
+
+	if _, err := m.(*ChatModel).prepareRequest(msgs, []provider.Option{
+		provider.WithTools(types.NewToolDefinition("echo", "Echo.", map[string]any{"type": "object"})),
+		provider.WithServerTools("web_search_preview"),
+	}); err == nil {
+		t.Error("prepareRequest() expected error for server tools")
+	}
+}
+
+func TestStream_ErrorKeepsFinishReason(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req goopenai.ChatCompletionRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.StreamOptions == nil || !req.StreamOptions.IncludeUsage {
+			t.Errorf("stream_options = %+v, want usage requested", req.StreamOptions)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, `data: {"id":"1","choices":[{"index":0,"delta":{"content":"Hello"}}]}`+"\n\n")
+		fmt.Fprint(w, `data: {"id":"1","choices":[{"index":0,"delta":{},"finish_reason":"stop"}],"usage":{"prompt_tokens":3,"completion_tokens":1,"total_tokens":4}}`+"\n\n")
+		fmt.Fprint(w, `data: {"error":{"message":"upstream reset","type":"server_error"}}`+"\n\n")
+	}))
+	defer srv.Close()
+
+	m, err := NewChatModel(Config{APIKey: "test-key", BaseURL: srv.URL})
+	if err != nil {
+		t.Fatalf("NewChatModel() error = %v", err)
+	}
+	stream, err := m.Stream(context.Background(), []types.Message{types.UserMessage("hi")})
+	if err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+
+	var content string
+	var last provider.ChatChunk
+	for chunk := range stream {
+		content += chunk.Content
+		last = chunk
+	}
+	if content != "Hello" {
+		t.Errorf("content = %q, want %q", content, "Hello")
+	}
+	if last.Error == nil {
+		t.Fatal("last chunk has no error")
+	}
+	if last.FinishReason != "stop" {
+		t.Errorf("error chunk FinishReason = %q, want stop", last.FinishReason)
+	}
+	if last.Usage == nil || last.Usage.TotalTokens != 4 {
+		t.Errorf("error chunk Usage = %+v, want total 4", last.Usage)
+	}
+}
+
+func TestChat_IncludeRaw(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"chatcmpl-1","model":"gpt-4o","system_fingerprint":"fp_123","choices":[{"index":0,"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}]}`)
+	}))
+	defer srv.Close()
+
+	m, err := NewChatModel(Config{APIKey: "test-key", BaseURL: srv.URL})
+	if err != nil {
+		t.Fatalf("NewChatModel() error = %v", err)
+	}
+	msgs := []types.Message{types.UserMessage("hi")}
+
+	resp, err := m.Chat(context.Background(), msgs)
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if resp.Raw != nil {
+		t.Errorf("Raw = %v, want nil by default", resp.Raw)
+	}
+
+	resp, err = m.Chat(context.Background(), msgs, provider.WithIncludeRaw())
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	raw, ok := resp.Raw.(goopenai.ChatCompletionResponse)
+	if !ok {
+		t.Fatalf("Raw = %T, want goopenai.ChatCompletionResponse", resp.Raw)
+	}
+	if raw.SystemFingerprint != "fp_123" {
+		t.Errorf("Raw.SystemFingerprint = %q, want fp_123", raw.SystemFingerprint)
+	}
+}
+
+func TestWrapError(t *testing.T) {
+	tests := []struct {
+		name          string
+		err           error
+		wantStatus    int
+		wantRetryable bool
+	}{
+		{
+			name:          "Rate Limited",
+			err:           &goopenai.APIError{HTTPStatusCode: 429, Code: "rate_limit_exceeded", Message: "slow down"},
+			wantStatus:    429,
+			wantRetryable: true,
+		},
+		{
+			name:          "Unauthorized",
+			err:           &goopenai.APIError{HTTPStatusCode: 401, Code: "invalid_api_key", Message: "bad key"},
+			wantStatus:    401,
+			wantRetryable: false,
+		},
+		{
+			name:          "Server Error",
+			err:           &goopenai.RequestError{HTTPStatusCode: 500, Err: errors.New("internal")},
+			wantStatus:    500,
+			wantRetryable: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := wrapError(tt.err)
+
+			var pe *provider.Error
+			if !errors.As(err, &pe) {
+				t.Fatalf("wrapError() = %T, want *provider.Error", err)
+			}
+			if pe.StatusCode != tt.wantStatus {
+				t.Errorf("StatusCode = %d, want %d", pe.StatusCode, tt.wantStatus)
+			}
+			if pe.Retryable != tt.wantRetryable {
+				t.Errorf("Retryable = %v, want %v", pe.Retryable, tt.wantRetryable)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Error("original error is not reachable via errors.Unwrap")
+			}
+		})
+	}
+}
+
 // --- Live Tests below ---
 
 func getLiveClient(t *testing.T) provider.ChatModel {
@@ -122,31 +623,24 @@ func TestLive_ToolCall(t *testing.T) {
 
 	// Define a dummy weather tool
 	tools := []types.ToolDefinition{
-		{
-			Type: "function",
-			Function: struct {
-				Name        string `json:"name"`
-				Description string `json:"description,omitempty"`
-				Parameters  any    `json:"parameters"`
-			}{
-				Name:        "get_current_weather",
-				Description: "Get the current weather in a given location",
-				Parameters: map[string]interface{}{
-					"type": "object",
-					"properties": map[string]interface{}{
-						"location": map[string]interface{}{
-							"type":        "string",
-							"description": "The city and state, e.g. San Francisco, CA",
-						},
-						"unit": map[string]interface{}{
-							"type": "string",
-							"enum": []string{"celsius", "fahrenheit"},
-						},
+		types.NewToolDefinition(
+			"get_current_weather",
+			"Get the current weather in a given location",
+			map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"location": map[string]interface{}{
+						"type":        "string",
+						"description": "The city and state, e.g. San Francisco, CA",
+					},
+					"unit": map[string]interface{}{
+						"type": "string",
+						"enum": []string{"celsius", "fahrenheit"},
 					},
-					"required": []string{"location"},
 				},
+				"required": []string{"location"},
 			},
-		},
+		),
 	}
 
 	msgs := []types.Message{
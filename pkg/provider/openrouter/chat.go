@@ -2,6 +2,7 @@ package openrouter
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -21,6 +22,10 @@ type Config struct {
 	Model       string
 	HTTPClient  *http.Client
 	Temperature float64 // Default temperature
+	// StrictSampling rejects an out-of-range temperature or top_p with a
+	// *provider.ParamRangeError instead of clamping it into range.
+	StrictSampling bool
+	Logger         provider.Logger // Optional: notified when a parameter is clamped
 	Referer     string  // Optional: HTTP-Referer header required by OpenRouter when set in dashboard
 	AppName     string  // Optional: X-Title header recommended by OpenRouter
 }
@@ -30,6 +35,8 @@ type ChatModel struct {
 	client             *goopenai.Client
 	defaultModel       string
 	defaultTemperature float64
+	strictSampling     bool
+	logger             provider.Logger
 }
 
 const (
@@ -40,6 +47,9 @@ const (
 	appNameHeaderKey    = "X-Title"
 )
 
+// samplingRange is the temperature and top_p range the API accepts.
+var samplingRange = provider.SamplingRange{MaxTemperature: 2, MaxTopP: 1}
+
 // NewChatModel builds a chat completion provider for OpenRouter.
 func NewChatModel(cfg Config) (provider.ChatModel, error) {
 	if strings.TrimSpace(cfg.APIKey) == "" {
@@ -77,6 +87,8 @@ func NewChatModel(cfg Config) (provider.ChatModel, error) {
 		client:             goopenai.NewClientWithConfig(apiCfg),
 		defaultModel:       modelName,
 		defaultTemperature: temp,
+		strictSampling:     cfg.StrictSampling,
+		logger:             cfg.Logger,
 	}, nil
 }
 
@@ -84,17 +96,40 @@ func (m *ChatModel) Name() string {
 	return "openrouter"
 }
 
+// Capabilities reports what the configured default model supports.
+func (m *ChatModel) Capabilities() provider.Capabilities {
+	return provider.CapabilitiesForModel(m.defaultModel)
+}
+
 func (m *ChatModel) prepareRequest(messages []types.Message, opts []provider.Option) (goopenai.ChatCompletionRequest, error) {
 	// 1. Apply options
-	options := &provider.ChatOptions{
+	options := provider.ResolveOptions(provider.ChatOptions{
 		Model:       m.defaultModel,
 		Temperature: m.defaultTemperature,
+	}, opts...)
+	if err := provider.ValidateReasoningEffort(options.ReasoningEffort); err != nil {
+		return goopenai.ChatCompletionRequest{}, err
+	}
+	if err := provider.ClampSampling(&options, samplingRange, m.strictSampling, m.logger); err != nil {
+		return goopenai.ChatCompletionRequest{}, err
+	}
+	if err := provider.ValidateToolChoice(options.ToolChoice); err != nil {
+		return goopenai.ChatCompletionRequest{}, err
+	}
+	if err := provider.ValidateResponseFormat(options.Model, options.ResponseFormat); err != nil {
+		return goopenai.ChatCompletionRequest{}, err
 	}
-	for _, o := range opts {
-		o(options)
+	// Chat Completions only accepts function tools; built-in tools such as
+	// web_search_preview are a Responses API feature and would be refused.
+	if len(options.ServerTools) > 0 {
+		return goopenai.ChatCompletionRequest{}, fmt.Errorf("openrouter: server tools %v are not supported by the chat completions API", options.ServerTools)
 	}
 
 	// 2. Convert Messages
+	// Tool messages are text-only, so images in tool results move to a user message.
+	// Images are dropped only for models known to lack vision.
+	caps := provider.CapabilitiesForModel(options.Model)
+	messages = provider.RouteToolImages(messages, !caps.Known || caps.Vision)
 	openrouterMsgs := make([]goopenai.ChatCompletionMessage, len(messages))
 	for i, msg := range messages {
 		oMsg := goopenai.ChatCompletionMessage{
@@ -118,6 +153,10 @@ func (m *ChatModel) prepareRequest(messages []types.Message, opts []provider.Opt
 		default:
 			oMsg.Role = goopenai.ChatMessageRoleUser // Fallback
 		}
+		if len(msg.Parts) > 0 {
+			oMsg.Content = ""
+			oMsg.MultiContent = convertToOpenAIParts(msg)
+		}
 		openrouterMsgs[i] = oMsg
 	}
 
@@ -126,8 +165,25 @@ func (m *ChatModel) prepareRequest(messages []types.Message, opts []provider.Opt
 		Model:       options.Model,
 		Messages:    openrouterMsgs,
 		Temperature: float32(options.Temperature),
+		TopP:        float32(options.TopP),
 		MaxTokens:   options.MaxTokens,
 		Stop:        options.Stop,
+		User:        options.User,
+
+		PresencePenalty:  float32(options.PresencePenalty),
+		FrequencyPenalty: float32(options.FrequencyPenalty),
+
+		ReasoningEffort: options.ReasoningEffort,
+	}
+	if options.Seed != 0 {
+		req.Seed = &options.Seed
+	}
+	if options.ResponseFormat != nil {
+		format, err := convertResponseFormat(options.ResponseFormat)
+		if err != nil {
+			return goopenai.ChatCompletionRequest{}, err
+		}
+		req.ResponseFormat = format
 	}
 
 	// 4. Handle Tools
@@ -143,6 +199,13 @@ func (m *ChatModel) prepareRequest(messages []types.Message, opts []provider.Opt
 				},
 			}
 		}
+		// Only meaningful (and only accepted) alongside tools.
+		if options.ParallelToolCalls != nil {
+			req.ParallelToolCalls = *options.ParallelToolCalls
+		}
+		if options.ToolChoice != nil {
+			req.ToolChoice = options.ToolChoice
+		}
 	}
 
 	return req, nil
@@ -157,7 +220,7 @@ func (m *ChatModel) Chat(ctx context.Context, messages []types.Message, opts ...
 
 	resp, err := m.client.CreateChatCompletion(ctx, req)
 	if err != nil {
-		return nil, err
+		return nil, wrapError(err)
 	}
 
 	if len(resp.Choices) == 0 {
@@ -174,15 +237,21 @@ func (m *ChatModel) Chat(ctx context.Context, messages []types.Message, opts ...
 		chatMsg.ToolCalls = convertFromOpenAIToolCalls(choice.Message.ToolCalls)
 	}
 
-	return &types.ChatResponse{
+	out := &types.ChatResponse{
+		ID:           resp.ID,
 		Message:      chatMsg,
 		FinishReason: string(choice.FinishReason),
+		Model:        resp.Model,
 		Usage: types.Usage{
 			PromptTokens:     resp.Usage.PromptTokens,
 			CompletionTokens: resp.Usage.CompletionTokens,
 			TotalTokens:      resp.Usage.TotalTokens,
 		},
-	}, nil
+	}
+	if provider.ResolveOptions(provider.ChatOptions{}, opts...).IncludeRaw {
+		out.Raw = resp
+	}
+	return out, nil
 }
 
 // Stream implements provider.ChatModel.Stream
@@ -192,10 +261,12 @@ func (m *ChatModel) Stream(ctx context.Context, messages []types.Message, opts .
 		return nil, err
 	}
 	req.Stream = true
+	// Ask for a final usage chunk, which token accounting depends on.
+	req.StreamOptions = &goopenai.StreamOptions{IncludeUsage: true}
 
 	stream, err := m.client.CreateChatCompletionStream(ctx, req)
 	if err != nil {
-		return nil, err
+		return nil, wrapError(err)
 	}
 
 	ch := make(chan provider.ChatChunk)
@@ -203,16 +274,34 @@ func (m *ChatModel) Stream(ctx context.Context, messages []types.Message, opts .
 		defer close(ch)
 		defer stream.Close()
 
+		// The latest finish reason and usage ride along on an error chunk, so
+		// collectors can tell a failure after a finished answer from one
+		// before any result.
+		var (
+			finishReason string
+			usage        *types.Usage
+		)
 		for {
 			resp, err := stream.Recv()
 			if errors.Is(err, io.EOF) {
 				return
 			}
 			if err != nil {
-				ch <- provider.ChatChunk{Error: err}
+				ch <- provider.ChatChunk{Error: wrapError(err), FinishReason: finishReason, Usage: usage}
 				return
 			}
 
+			if resp.Usage != nil {
+				usage = &types.Usage{
+					PromptTokens:     resp.Usage.PromptTokens,
+					CompletionTokens: resp.Usage.CompletionTokens,
+					TotalTokens:      resp.Usage.TotalTokens,
+				}
+				if len(resp.Choices) == 0 {
+					ch <- provider.ChatChunk{ID: resp.ID, Usage: usage}
+				}
+			}
+
 			if len(resp.Choices) > 0 {
 				choice := resp.Choices[0]
 				chunk := provider.ChatChunk{
@@ -220,23 +309,34 @@ func (m *ChatModel) Stream(ctx context.Context, messages []types.Message, opts .
 					ID:           resp.ID,
 					FinishReason: string(choice.FinishReason),
 				}
+				if resp.Usage != nil {
+					chunk.Usage = usage
+				}
+				if chunk.FinishReason != "" {
+					finishReason = chunk.FinishReason
+				}
 
-				if len(choice.Delta.ToolCalls) > 0 {
-					tc := choice.Delta.ToolCalls[0]
-					chunk.ToolCall = &types.ToolCall{
-						ID:   tc.ID,
-						Type: string(tc.Type),
-						Function: struct {
-							Name      string `json:"name"`
-							Arguments string `json:"arguments"`
-						}{
-							Name:      tc.Function.Name,
-							Arguments: tc.Function.Arguments,
-						},
+				// Tool calls arrive as fragments, one chunk each; StreamAndCollect
+				// reassembles them by ID and Index.
+				var more []provider.ChatChunk
+				for j, tc := range choice.Delta.ToolCalls {
+					call := &types.ToolCall{ID: tc.ID, Type: string(tc.Type)}
+					call.Function.Name = tc.Function.Name
+					call.Function.Arguments = tc.Function.Arguments
+					if tc.Index != nil {
+						call.Index = *tc.Index
+					}
+					if j == 0 {
+						chunk.ToolCall = call
+						continue
 					}
+					more = append(more, provider.ChatChunk{ToolCall: call, ID: resp.ID})
 				}
 
 				ch <- chunk
+				for _, c := range more {
+					ch <- c
+				}
 			}
 		}
 	}()
@@ -285,6 +385,59 @@ func withHeaders(client *http.Client, headers map[string]string) *http.Client {
 	return &clone
 }
 
+// wrapError normalizes go-openai failures into *provider.Error.
+func wrapError(err error) error {
+	var apiErr *goopenai.APIError
+	if errors.As(err, &apiErr) {
+		code, _ := apiErr.Code.(string)
+		return provider.NewError("openrouter", apiErr.HTTPStatusCode, code, apiErr.Message, err)
+	}
+	var reqErr *goopenai.RequestError
+	if errors.As(err, &reqErr) {
+		return provider.NewError("openrouter", reqErr.HTTPStatusCode, "", "", err)
+	}
+	return provider.NewError("openrouter", 0, "", "", err)
+}
+
+// convertToOpenAIParts renders a message with parts as content parts, its
+// text first.
+func convertToOpenAIParts(msg types.Message) []goopenai.ChatMessagePart {
+	var parts []goopenai.ChatMessagePart
+	if msg.Content != "" {
+		parts = append(parts, goopenai.ChatMessagePart{Type: goopenai.ChatMessagePartTypeText, Text: msg.Content})
+	}
+	for _, p := range msg.Parts {
+		switch p.Type {
+		case types.PartText:
+			parts = append(parts, goopenai.ChatMessagePart{Type: goopenai.ChatMessagePartTypeText, Text: p.Text})
+		case types.PartImage:
+			parts = append(parts, goopenai.ChatMessagePart{
+				Type:     goopenai.ChatMessagePartTypeImageURL,
+				ImageURL: &goopenai.ChatMessageImageURL{URL: p.URL()},
+			})
+		}
+	}
+	return parts
+}
+
+// convertResponseFormat maps a provider.ResponseFormat onto the request's
+// response_format field.
+func convertResponseFormat(f *provider.ResponseFormat) (*goopenai.ChatCompletionResponseFormat, error) {
+	out := &goopenai.ChatCompletionResponseFormat{Type: goopenai.ChatCompletionResponseFormatType(f.Type)}
+	if f.Type == provider.ResponseFormatJSONSchema {
+		schema, err := json.Marshal(f.Schema)
+		if err != nil {
+			return nil, fmt.Errorf("encode response schema: %w", err)
+		}
+		out.JSONSchema = &goopenai.ChatCompletionResponseFormatJSONSchema{
+			Name:   f.Name,
+			Schema: json.RawMessage(schema),
+			Strict: f.Strict,
+		}
+	}
+	return out, nil
+}
+
 func convertToOpenAIToolCalls(tcs []types.ToolCall) []goopenai.ToolCall {
 	res := make([]goopenai.ToolCall, len(tcs))
 	for i, tc := range tcs {
@@ -303,20 +456,12 @@ func convertToOpenAIToolCalls(tcs []types.ToolCall) []goopenai.ToolCall {
 func convertFromOpenAIToolCalls(tcs []goopenai.ToolCall) []types.ToolCall {
 	res := make([]types.ToolCall, len(tcs))
 	for i, tc := range tcs {
-		res[i] = types.ToolCall{
-			ID:   tc.ID,
-			Type: string(tc.Type),
-			Function: struct {
-				Name      string `json:"name"`
-				Arguments string `json:"arguments"`
-			}{
-				Name:      tc.Function.Name,
-				Arguments: tc.Function.Arguments,
-			},
-		}
+		res[i] = types.NewToolCall(tc.ID, tc.Function.Name, tc.Function.Arguments)
+		res[i].Type = string(tc.Type)
 	}
 	return res
 }
 
 // Ensure interface compliance
 var _ provider.ChatModel = (*ChatModel)(nil)
+var _ provider.CapabilityReporter = (*ChatModel)(nil)
@@ -0,0 +1,57 @@
+package provider
+
+import "giai/pkg/types"
+
+// Pricing is a model's list price in USD per million tokens.
+type Pricing struct {
+	InputPerMillion  float64
+	OutputPerMillion float64
+}
+
+// modelPricing maps model-name prefixes to list prices. Prices change; treat
+// estimates as approximate and keep this table in sync with provider pages.
+var modelPricing = map[string]Pricing{
+	"gpt-3.5-turbo":     {0.50, 1.50},
+	"gpt-4":             {30.00, 60.00},
+	"gpt-4-turbo":       {10.00, 30.00},
+	"gpt-4o":            {2.50, 10.00},
+	"gpt-4o-mini":       {0.15, 0.60},
+	"gpt-4.1":           {2.00, 8.00},
+	"gpt-4.1-mini":      {0.40, 1.60},
+	"gpt-4.1-nano":      {0.10, 0.40},
+	"o1":                {15.00, 60.00},
+	"o1-mini":           {1.10, 4.40},
+	"o3":                {2.00, 8.00},
+	"o3-mini":           {1.10, 4.40},
+	"o4-mini":           {1.10, 4.40},
+	"gemini-1.5-pro":    {1.25, 5.00},
+	"gemini-1.5-flash":  {0.075, 0.30},
+	"gemini-2.0-flash":  {0.10, 0.40},
+	"claude-3-opus":     {15.00, 75.00},
+	"claude-3-5-sonnet": {3.00, 15.00},
+	"claude-3-5-haiku":  {0.80, 4.00},
+	"claude-3-haiku":    {0.25, 1.25},
+}
+
+var pricingPrefixes = sortedPrefixes(modelPricing)
+
+// PricingForModel returns the list price for model, matched by longest prefix.
+func PricingForModel(model string) (Pricing, bool) {
+	prefix, ok := matchModelPrefix(model, pricingPrefixes)
+	if !ok {
+		return Pricing{}, false
+	}
+	return modelPricing[prefix], true
+}
+
+// EstimateCost returns the estimated USD cost of usage on model.
+// The boolean is false when the model has no known pricing.
+func EstimateCost(model string, usage types.Usage) (float64, bool) {
+	p, ok := PricingForModel(model)
+	if !ok {
+		return 0, false
+	}
+	cost := float64(usage.PromptTokens)*p.InputPerMillion/1e6 +
+		float64(usage.CompletionTokens)*p.OutputPerMillion/1e6
+	return cost, true
+}
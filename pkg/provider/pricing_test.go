@@ -0,0 +1,32 @@
+package provider
+
+import (
+	"math"
+	"testing"
+
+	"giai/pkg/types"
+)
+
+func TestEstimateCost(t *testing.T) {
+	usage := types.Usage{PromptTokens: 1_000_000, CompletionTokens: 500_000}
+
+	tests := []struct {
+		model  string
+		want   float64
+		wantOK bool
+	}{
+		{"gpt-4o-2024-08-06", 2.50 + 5.00, true},
+		{"gpt-4o-mini", 0.15 + 0.30, true},
+		{"openai/gpt-4.1", 2.00 + 4.00, true},
+		{"unknown-model", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			got, ok := EstimateCost(tt.model, usage)
+			if ok != tt.wantOK || math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("EstimateCost(%q) = %v, %v; want %v, %v", tt.model, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -2,6 +2,10 @@ package provider
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
 	"giai/pkg/types"
 )
 
@@ -14,11 +18,84 @@ type ChatOptions struct {
 	Stop        []string
 	Tools       []types.ToolDefinition
 	Stream      bool
+	User        string // Stable end-user identifier for abuse monitoring
+
+	// PresencePenalty and FrequencyPenalty discourage repeating topics and
+	// tokens. Zero leaves the model default.
+	PresencePenalty  float64
+	FrequencyPenalty float64
+	// Seed requests best-effort deterministic sampling, e.g. for reproducible
+	// regression tests. Zero leaves it unset.
+	Seed int
+
+	// ReasoningEffort hints how much reasoning a reasoning model should do: "low", "medium" or "high".
+	ReasoningEffort string
+	// ThinkingBudget caps the tokens a model may spend on extended thinking.
+	// Only the Anthropic provider honors it; others ignore it. Zero leaves
+	// thinking disabled.
+	ThinkingBudget int
+	// ToolChoice controls whether the model calls tools: "auto", "none",
+	// "required", or a specific function as returned by ForceTool. Nil leaves
+	// the provider default ("auto" when tools are present).
+	ToolChoice any
+	// ServerTools enables provider built-in tools executed server-side, by
+	// versioned type, e.g. Anthropic's "web_search_20250305". They are sent
+	// with their own tool type rather than as function definitions, and never
+	// run locally. Only the Anthropic provider supports them; the others
+	// reject requests that set them.
+	ServerTools []string
+	// ParallelToolCalls, when set to false, asks the model for at most one tool
+	// call per response. Nil leaves the provider default (parallel calls allowed).
+	ParallelToolCalls *bool
+	// IncludeRaw attaches the decoded provider response to ChatResponse.Raw.
+	// Off by default to avoid retaining large payloads.
+	IncludeRaw bool
+	// ResponseFormat constrains the answer to JSON, see WithJSONMode and
+	// WithJSONSchema. Nil leaves the answer free-form.
+	ResponseFormat *ResponseFormat
+}
+
+// Response format types for ResponseFormat.Type.
+const (
+	ResponseFormatJSONObject = "json_object"
+	ResponseFormatJSONSchema = "json_schema"
+)
+
+// ResponseFormat asks the model for a JSON answer: any JSON object
+// ("json_object"), or one matching Schema ("json_schema").
+type ResponseFormat struct {
+	Type   string
+	Name   string         // Schema name, for "json_schema"
+	Schema map[string]any // JSON Schema, for "json_schema"
+	Strict bool           // Enforce the schema exactly rather than best effort
 }
 
 // Option is a functional option for configuring ChatOptions.
 type Option func(*ChatOptions)
 
+// ResolveOptions returns the effective options for a call: opts are applied to
+// an empty ChatOptions and every non-zero field (non-empty for slices) then overrides defaults.
+// Zero values never clobber a default, so an option cannot reset a field to its
+// zero value (e.g. Temperature 0 keeps the default temperature).
+func ResolveOptions(defaults ChatOptions, opts ...Option) ChatOptions {
+	var set ChatOptions
+	for _, opt := range opts {
+		opt(&set)
+	}
+
+	out := defaults
+	src := reflect.ValueOf(set)
+	dst := reflect.ValueOf(&out).Elem()
+	for i := 0; i < src.NumField(); i++ {
+		f := src.Field(i)
+		if f.IsZero() || f.Kind() == reflect.Slice && f.Len() == 0 {
+			continue
+		}
+		dst.Field(i).Set(f)
+	}
+	return out
+}
+
 func WithTemperature(t float64) Option {
 	return func(o *ChatOptions) {
 		o.Temperature = t
@@ -31,6 +108,176 @@ func WithModel(m string) Option {
 	}
 }
 
+// WithTopP sets nucleus sampling: only tokens within the top p probability mass are considered.
+func WithTopP(p float64) Option {
+	return func(o *ChatOptions) {
+		o.TopP = p
+	}
+}
+
+// WithPresencePenalty penalizes tokens that already appeared, nudging the model toward new topics.
+func WithPresencePenalty(p float64) Option {
+	return func(o *ChatOptions) {
+		o.PresencePenalty = p
+	}
+}
+
+// WithFrequencyPenalty penalizes tokens by how often they already appeared.
+func WithFrequencyPenalty(p float64) Option {
+	return func(o *ChatOptions) {
+		o.FrequencyPenalty = p
+	}
+}
+
+// WithSeed asks for deterministic sampling with the given seed where the provider supports it.
+func WithSeed(seed int) Option {
+	return func(o *ChatOptions) {
+		o.Seed = seed
+	}
+}
+
+// WithUser sets a stable end-user identifier that providers forward for abuse monitoring.
+func WithUser(id string) Option {
+	return func(o *ChatOptions) {
+		o.User = id
+	}
+}
+
+// WithReasoningEffort sets the reasoning effort for reasoning models ("low", "medium" or "high").
+func WithReasoningEffort(effort string) Option {
+	return func(o *ChatOptions) {
+		o.ReasoningEffort = effort
+	}
+}
+
+// WithThinkingBudget sets the extended-thinking token budget (Anthropic only).
+func WithThinkingBudget(tokens int) Option {
+	return func(o *ChatOptions) {
+		o.ThinkingBudget = tokens
+	}
+}
+
+// WithTools attaches tool definitions the model may call.
+func WithTools(defs ...types.ToolDefinition) Option {
+	return func(o *ChatOptions) {
+		o.Tools = defs
+	}
+}
+
+// WithToolChoice sets ChatOptions.ToolChoice: "auto", "none", "required", or
+// ForceTool(name) to make the model call that function.
+func WithToolChoice(choice any) Option {
+	return func(o *ChatOptions) {
+		o.ToolChoice = choice
+	}
+}
+
+// ForceTool returns the tool choice that makes the model call the named function.
+func ForceTool(name string) map[string]any {
+	return map[string]any{
+		"type":     "function",
+		"function": map[string]any{"name": name},
+	}
+}
+
+// WithServerTools enables provider built-in tools by type, e.g. "web_search_20250305".
+func WithServerTools(names ...string) Option {
+	return func(o *ChatOptions) {
+		o.ServerTools = names
+	}
+}
+
+// WithParallelToolCalls allows or forbids multiple tool calls in one response.
+func WithParallelToolCalls(enabled bool) Option {
+	return func(o *ChatOptions) {
+		o.ParallelToolCalls = &enabled
+	}
+}
+
+// WithIncludeRaw attaches the decoded provider response to ChatResponse.Raw,
+// for debugging fields the normalized response does not model.
+func WithIncludeRaw() Option {
+	return func(o *ChatOptions) {
+		o.IncludeRaw = true
+	}
+}
+
+// WithJSONMode asks the model to answer with a JSON object. The prompt should
+// still mention JSON; OpenAI rejects JSON mode otherwise.
+func WithJSONMode() Option {
+	return func(o *ChatOptions) {
+		o.ResponseFormat = &ResponseFormat{Type: ResponseFormatJSONObject}
+	}
+}
+
+// WithJSONSchema asks the model to answer with JSON matching schema, pairing
+// with parser.JSONParser on the way back. strict enables exact schema
+// adherence, which restricts the schema features allowed.
+func WithJSONSchema(name string, schema map[string]any, strict bool) Option {
+	return func(o *ChatOptions) {
+		o.ResponseFormat = &ResponseFormat{Type: ResponseFormatJSONSchema, Name: name, Schema: schema, Strict: strict}
+	}
+}
+
+// ValidateResponseFormat reports an error when format is malformed or asks
+// model for a mode it lacks. JSON mode is refused only for models known to
+// lack it; JSON schema mode needs a model known to support it.
+func ValidateResponseFormat(model string, format *ResponseFormat) error {
+	if format == nil {
+		return nil
+	}
+	caps := CapabilitiesForModel(model)
+	switch format.Type {
+	case ResponseFormatJSONObject:
+		if caps.Known && !caps.JSONMode {
+			return fmt.Errorf("model %q does not support JSON mode", model)
+		}
+	case ResponseFormatJSONSchema:
+		if format.Name == "" || format.Schema == nil {
+			return errors.New("json_schema response format needs a name and a schema")
+		}
+		if !caps.StructuredOutputs {
+			return fmt.Errorf("model %q does not support JSON schema response format", model)
+		}
+	default:
+		return fmt.Errorf("invalid response format type %q: must be json_object or json_schema", format.Type)
+	}
+	return nil
+}
+
+// ValidateReasoningEffort reports an error when effort is set to an unsupported value.
+func ValidateReasoningEffort(effort string) error {
+	switch effort {
+	case "", "low", "medium", "high":
+		return nil
+	default:
+		return fmt.Errorf("invalid reasoning effort %q: must be one of low, medium, high", effort)
+	}
+}
+
+// ValidateToolChoice reports an error when choice is neither a known mode nor
+// a function choice naming a function.
+func ValidateToolChoice(choice any) error {
+	switch c := choice.(type) {
+	case nil:
+		return nil
+	case string:
+		switch c {
+		case "auto", "none", "required":
+			return nil
+		}
+		return fmt.Errorf("invalid tool choice %q: must be one of auto, none, required or a function choice", c)
+	case map[string]any:
+		fn, _ := c["function"].(map[string]any)
+		if name, _ := fn["name"].(string); c["type"] == "function" && name != "" {
+			return nil
+		}
+		return fmt.Errorf("invalid tool choice %v: want {\"type\":\"function\",\"function\":{\"name\":...}}", c)
+	default:
+		return fmt.Errorf("invalid tool choice of type %T", choice)
+	}
+}
+
 // ChatChunk represents a piece of a streamed response.
 type ChatChunk struct {
 	Content      string
@@ -38,7 +285,8 @@ type ChatChunk struct {
 	FinishReason string
 	Usage        *types.Usage // Usually only available in the last chunk
 	ID           string
-	Error        error // To handle stream errors gracefully
+	Metadata     map[string]any // Merged into the message metadata, e.g. provider reasoning state
+	Error        error          // To handle stream errors gracefully
 }
 
 // ChatModel defines the interface for interacting with Chat LLMs.
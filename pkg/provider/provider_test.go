@@ -0,0 +1,93 @@
+package provider
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResolveOptions(t *testing.T) {
+	defaults := ChatOptions{Model: "gpt-4o", Temperature: 0.7, MaxTokens: 512}
+
+	tests := []struct {
+		name string
+		opts []Option
+		want ChatOptions
+	}{
+		{
+			name: "No Options",
+			want: defaults,
+		},
+		{
+			name: "Override",
+			opts: []Option{WithModel("gpt-4o-mini"), WithTemperature(0.2), WithUser("u1")},
+			want: ChatOptions{Model: "gpt-4o-mini", Temperature: 0.2, MaxTokens: 512, User: "u1"},
+		},
+		{
+			name: "Zero Values Keep Defaults",
+			opts: []Option{WithModel(""), WithTemperature(0), func(o *ChatOptions) { o.Stop = []string{} }},
+			want: defaults,
+		},
+		{
+			name: "Later Options Win",
+			opts: []Option{WithModel("a"), WithModel("b")},
+			want: ChatOptions{Model: "b", Temperature: 0.7, MaxTokens: 512},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ResolveOptions(defaults, tt.opts...); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ResolveOptions() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateToolChoice(t *testing.T) {
+	tests := []struct {
+		name    string
+		choice  any
+		wantErr bool
+	}{
+		{"unset", nil, false},
+		{"auto", "auto", false},
+		{"required", "required", false},
+		{"forced", ForceTool("search"), false},
+		{"unknown mode", "always", true},
+		{"function without name", map[string]any{"type": "function"}, true},
+		{"wrong type", 3, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateToolChoice(tt.choice); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateToolChoice() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateResponseFormat(t *testing.T) {
+	schema := map[string]any{"type": "object"}
+	tests := []struct {
+		name    string
+		model   string
+		format  *ResponseFormat
+		wantErr bool
+	}{
+		{"unset", "gpt-4", nil, false},
+		{"json mode", "gpt-4o", &ResponseFormat{Type: ResponseFormatJSONObject}, false},
+		{"json mode unsupported", "gpt-4", &ResponseFormat{Type: ResponseFormatJSONObject}, true},
+		{"json mode unknown model", "mistral-large", &ResponseFormat{Type: ResponseFormatJSONObject}, false},
+		{"schema", "openai/gpt-4o-mini", &ResponseFormat{Type: ResponseFormatJSONSchema, Name: "out", Schema: schema}, false},
+		{"schema unsupported", "gpt-3.5-turbo", &ResponseFormat{Type: ResponseFormatJSONSchema, Name: "out", Schema: schema}, true},
+		{"schema without name", "gpt-4o", &ResponseFormat{Type: ResponseFormatJSONSchema, Schema: schema}, true},
+		{"unknown type", "gpt-4o", &ResponseFormat{Type: "xml"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateResponseFormat(tt.model, tt.format); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateResponseFormat() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package provider
+
+import (
+	"context"
+
+	"golang.org/x/time/rate"
+
+	"giai/pkg/types"
+)
+
+type rateLimited struct {
+	inner   ChatModel
+	limiter *rate.Limiter
+}
+
+// WithRateLimit wraps inner so that calls wait for a token from a bucket
+// refilled at rps per second and holding up to burst tokens. The bucket is
+// shared by every call through the returned model, so concurrent agents using
+// it stay under one budget. A Stream consumes one token when it starts, not one
+// per chunk. Waiting ends with the context's error when ctx is done first.
+// Wrap it in WithRetry to have every retry attempt wait its turn too.
+// A non-positive rps returns inner unchanged.
+func WithRateLimit(inner ChatModel, rps float64, burst int) ChatModel {
+	if rps <= 0 {
+		return inner
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimited{inner: inner, limiter: rate.NewLimiter(rate.Limit(rps), burst)}
+}
+
+func (r *rateLimited) Name() string {
+	return r.inner.Name()
+}
+
+// Capabilities reports the inner model's capabilities.
+func (r *rateLimited) Capabilities() Capabilities {
+	return CapabilitiesOf(r.inner)
+}
+
+// Chat implements ChatModel.Chat
+func (r *rateLimited) Chat(ctx context.Context, messages []types.Message, opts ...Option) (*types.ChatResponse, error) {
+	if err := r.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	return r.inner.Chat(ctx, messages, opts...)
+}
+
+// Stream implements ChatModel.Stream
+func (r *rateLimited) Stream(ctx context.Context, messages []types.Message, opts ...Option) (<-chan ChatChunk, error) {
+	if err := r.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	return r.inner.Stream(ctx, messages, opts...)
+}
+
+var _ ChatModel = (*rateLimited)(nil)
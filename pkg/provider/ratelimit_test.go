@@ -0,0 +1,49 @@
+package provider
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWithRateLimit(t *testing.T) {
+	inner := &fakeModel{name: "inner", content: "ok"}
+	m := WithRateLimit(inner, 50, 2)
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if _, err := m.Chat(context.Background(), nil); err != nil {
+			t.Fatalf("Chat() error = %v", err)
+		}
+	}
+	// Two calls ride the burst; the other two wait 20ms each.
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf("4 calls took %v, want the limiter to delay them", elapsed)
+	}
+	if m.Name() != "inner" {
+		t.Errorf("Name() = %q, want the inner model's name", m.Name())
+	}
+}
+
+func TestWithRateLimit_StreamAndCancel(t *testing.T) {
+	inner := &fakeModel{name: "inner", content: "one two three"}
+	m := WithRateLimit(inner, 0.001, 1)
+
+	// The stream takes the only token, however many chunks it delivers.
+	resp, err := StreamAndCollect(context.Background(), m, nil, nil)
+	if err != nil || resp.Message.Content != "one two three" {
+		t.Fatalf("StreamAndCollect() = %+v, %v", resp, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := m.Chat(ctx, nil); err == nil {
+		t.Fatal("Chat() expected an error while waiting past the deadline")
+	}
+	if inner.calls.Load() != 1 {
+		t.Errorf("inner calls = %d, want 1", inner.calls.Load())
+	}
+	if _, err := m.Stream(ctx, nil); err == nil {
+		t.Error("Stream() expected an error while waiting past the deadline")
+	}
+}
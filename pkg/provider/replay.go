@@ -0,0 +1,251 @@
+package provider
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"giai/pkg/types"
+)
+
+// recording is one provider call stored as a JSON line.
+type recording struct {
+	Key      string              `json:"key"`
+	Stream   bool                `json:"stream"`
+	Messages []types.Message     `json:"messages"`
+	Options  *ChatOptions        `json:"options"`
+	Response *types.ChatResponse `json:"response,omitempty"`
+	Chunks   []recordedChunk     `json:"chunks,omitempty"`
+	Error    string              `json:"error,omitempty"`
+}
+
+// recordedChunk is a ChatChunk with its error flattened to a string.
+type recordedChunk struct {
+	Content      string          `json:"content,omitempty"`
+	ToolCall     *types.ToolCall `json:"tool_call,omitempty"`
+	FinishReason string          `json:"finish_reason,omitempty"`
+	Usage        *types.Usage    `json:"usage,omitempty"`
+	ID           string          `json:"id,omitempty"`
+	Metadata     map[string]any  `json:"metadata,omitempty"`
+	Error        string          `json:"error,omitempty"`
+}
+
+// requestKey hashes the messages and resolved options of a call, so identical
+// requests map to the same recording. Chat and Stream calls are keyed separately.
+func requestKey(stream bool, messages []types.Message, opts *ChatOptions) string {
+	raw, _ := json.Marshal(struct {
+		Stream   bool
+		Messages []types.Message
+		Options  *ChatOptions
+	}{stream, messages, opts})
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])
+}
+
+func applyOptions(opts []Option) *ChatOptions {
+	o := ResolveOptions(ChatOptions{}, opts...)
+	return &o
+}
+
+type recorder struct {
+	inner ChatModel
+	path  string
+	mu    sync.Mutex
+}
+
+// Recorder wraps inner and appends every request and its response (or streamed
+// chunks) to the JSON-lines file at path, for later offline use with Replayer.
+func Recorder(inner ChatModel, path string) ChatModel {
+	return &recorder{inner: inner, path: path}
+}
+
+func (r *recorder) Name() string {
+	return r.inner.Name()
+}
+
+func (r *recorder) Chat(ctx context.Context, messages []types.Message, opts ...Option) (*types.ChatResponse, error) {
+	resp, err := r.inner.Chat(ctx, messages, opts...)
+
+	options := applyOptions(opts)
+	rec := recording{
+		Key:      requestKey(false, messages, options),
+		Messages: messages,
+		Options:  options,
+		Response: resp,
+	}
+	if err != nil {
+		rec.Error = err.Error()
+	}
+	if werr := r.append(rec); werr != nil && err == nil {
+		return resp, werr
+	}
+	return resp, err
+}
+
+func (r *recorder) Stream(ctx context.Context, messages []types.Message, opts ...Option) (<-chan ChatChunk, error) {
+	options := applyOptions(opts)
+	rec := recording{
+		Key:      requestKey(true, messages, options),
+		Stream:   true,
+		Messages: messages,
+		Options:  options,
+	}
+
+	chunks, err := r.inner.Stream(ctx, messages, opts...)
+	if err != nil {
+		rec.Error = err.Error()
+		r.append(rec)
+		return nil, err
+	}
+
+	out := make(chan ChatChunk)
+	go func() {
+		defer close(out)
+		// A consumer that stops reading is no longer sent chunks, but the
+		// rest of the stream is still recorded.
+		sending := true
+		for c := range chunks {
+			rc := recordedChunk{Content: c.Content, ToolCall: c.ToolCall, FinishReason: c.FinishReason, Usage: c.Usage, ID: c.ID, Metadata: c.Metadata}
+			if c.Error != nil {
+				rc.Error = c.Error.Error()
+			}
+			rec.Chunks = append(rec.Chunks, rc)
+			if sending {
+				sending = forward(ctx, out, c)
+			}
+		}
+		// The recording is written once the stream ends.
+		r.append(rec)
+	}()
+	return out, nil
+}
+
+func (r *recorder) append(rec recording) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("recorder: encode: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("recorder: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("recorder: %w", err)
+	}
+	return nil
+}
+
+type replayer struct {
+	path string
+
+	once    sync.Once
+	loadErr error
+
+	mu      sync.Mutex
+	entries map[string][]recording
+}
+
+// Replayer serves responses recorded by Recorder, matching each call by a hash
+// of its messages and options. Repeated identical requests replay their
+// recordings in order, then keep returning the last one.
+// The file is read on first use; unmatched requests fail.
+func Replayer(path string) ChatModel {
+	return &replayer{path: path}
+}
+
+func (r *replayer) Name() string {
+	return "replay"
+}
+
+func (r *replayer) load() error {
+	r.once.Do(func() {
+		f, err := os.Open(r.path)
+		if err != nil {
+			r.loadErr = fmt.Errorf("replayer: %w", err)
+			return
+		}
+		defer f.Close()
+
+		r.entries = make(map[string][]recording)
+		sc := bufio.NewScanner(f)
+		sc.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+		for sc.Scan() {
+			if len(sc.Bytes()) == 0 {
+				continue
+			}
+			var rec recording
+			if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+				r.loadErr = fmt.Errorf("replayer: decode %s: %w", r.path, err)
+				return
+			}
+			r.entries[rec.Key] = append(r.entries[rec.Key], rec)
+		}
+		if err := sc.Err(); err != nil {
+			r.loadErr = fmt.Errorf("replayer: %w", err)
+		}
+	})
+	return r.loadErr
+}
+
+// next pops the recording for key, keeping the last one for repeated requests.
+func (r *replayer) next(key string) (recording, error) {
+	if err := r.load(); err != nil {
+		return recording{}, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	queue := r.entries[key]
+	if len(queue) == 0 {
+		return recording{}, fmt.Errorf("replayer: no recording for request %s", key[:12])
+	}
+	rec := queue[0]
+	if len(queue) > 1 {
+		r.entries[key] = queue[1:]
+	}
+	return rec, nil
+}
+
+func (r *replayer) Chat(ctx context.Context, messages []types.Message, opts ...Option) (*types.ChatResponse, error) {
+	rec, err := r.next(requestKey(false, messages, applyOptions(opts)))
+	if err != nil {
+		return nil, err
+	}
+	if rec.Error != "" {
+		return nil, errors.New(rec.Error)
+	}
+	return rec.Response, nil
+}
+
+func (r *replayer) Stream(ctx context.Context, messages []types.Message, opts ...Option) (<-chan ChatChunk, error) {
+	rec, err := r.next(requestKey(true, messages, applyOptions(opts)))
+	if err != nil {
+		return nil, err
+	}
+	if rec.Error != "" {
+		return nil, errors.New(rec.Error)
+	}
+
+	ch := make(chan ChatChunk, len(rec.Chunks))
+	for _, c := range rec.Chunks {
+		chunk := ChatChunk{Content: c.Content, ToolCall: c.ToolCall, FinishReason: c.FinishReason, Usage: c.Usage, ID: c.ID, Metadata: c.Metadata}
+		if c.Error != "" {
+			chunk.Error = errors.New(c.Error)
+		}
+		ch <- chunk
+	}
+	close(ch)
+	return ch, nil
+}
+
+var _ ChatModel = (*recorder)(nil)
+var _ ChatModel = (*replayer)(nil)
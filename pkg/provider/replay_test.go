@@ -0,0 +1,134 @@
+package provider_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"giai/pkg/provider"
+	"giai/pkg/provider/echo"
+	"giai/pkg/types"
+)
+
+func collect(t *testing.T, ch <-chan provider.ChatChunk) string {
+	t.Helper()
+	var out string
+	for c := range ch {
+		if c.Error != nil {
+			t.Fatalf("stream error: %v", c.Error)
+		}
+		out += c.Content
+	}
+	return out
+}
+
+func TestRecorderReplayer(t *testing.T) {
+	dir, err := os.MkdirTemp("", "replay_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "session.jsonl")
+
+	ctx := context.Background()
+	msgs := []types.Message{types.UserMessage("hello replay")}
+	opts := []provider.Option{provider.WithModel("echo-1")}
+
+	rec := provider.Recorder(echo.New("bot"), path)
+	wantChat, err := rec.Chat(ctx, msgs, opts...)
+	if err != nil {
+		t.Fatalf("Recorder Chat() error = %v", err)
+	}
+	stream, err := rec.Stream(ctx, msgs, opts...)
+	if err != nil {
+		t.Fatalf("Recorder Stream() error = %v", err)
+	}
+	wantStream := collect(t, stream)
+
+	rep := provider.Replayer(path)
+	gotChat, err := rep.Chat(ctx, msgs, opts...)
+	if err != nil {
+		t.Fatalf("Replayer Chat() error = %v", err)
+	}
+	if gotChat.Message.Content != wantChat.Message.Content || gotChat.Usage != wantChat.Usage {
+		t.Errorf("Replayer Chat() = %+v, want %+v", gotChat, wantChat)
+	}
+
+	stream, err = rep.Stream(ctx, msgs, opts...)
+	if err != nil {
+		t.Fatalf("Replayer Stream() error = %v", err)
+	}
+	if got := collect(t, stream); got != wantStream {
+		t.Errorf("Replayer Stream() = %q, want %q", got, wantStream)
+	}
+
+	// A different request has no recording.
+	if _, err := rep.Chat(ctx, []types.Message{types.UserMessage("other")}, opts...); err == nil {
+		t.Error("Replayer Chat() expected error for unrecorded request")
+	}
+}
+
+// thinkingModel is echo ending its stream with provider reasoning state.
+type thinkingModel struct{ provider.ChatModel }
+
+func (thinkingModel) Stream(ctx context.Context, messages []types.Message, opts ...provider.Option) (<-chan provider.ChatChunk, error) {
+	ch := make(chan provider.ChatChunk, 2)
+	ch <- provider.ChatChunk{Content: "answer"}
+	ch <- provider.ChatChunk{FinishReason: "stop", Metadata: map[string]any{"thinking": []any{"step one"}}}
+	close(ch)
+	return ch, nil
+}
+
+func TestRecorderReplayer_StreamMetadata(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	ctx := context.Background()
+	msgs := []types.Message{types.UserMessage("think")}
+
+	stream, err := provider.Recorder(thinkingModel{echo.New("bot")}, path).Stream(ctx, msgs)
+	if err != nil {
+		t.Fatalf("Recorder Stream() error = %v", err)
+	}
+	collect(t, stream)
+
+	stream, err = provider.Replayer(path).Stream(ctx, msgs)
+	if err != nil {
+		t.Fatalf("Replayer Stream() error = %v", err)
+	}
+	var metadata map[string]any
+	for c := range stream {
+		if c.Metadata != nil {
+			metadata = c.Metadata
+		}
+	}
+	if want := map[string]any{"thinking": []any{"step one"}}; !reflect.DeepEqual(metadata, want) {
+		t.Errorf("replayed Metadata = %v, want %v", metadata, want)
+	}
+}
+
+func TestRecorder_StreamAbandoned(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	ctx, cancel := context.WithCancel(context.Background())
+
+	stream, err := provider.Recorder(echo.New("bot"), path).Stream(ctx, []types.Message{types.UserMessage(strings.Repeat("word ", 50))})
+	if err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+	<-stream
+	cancel()
+
+	// The recorder keeps consuming the inner stream, so the recording is written.
+	deadline := time.Now().Add(time.Second)
+	for {
+		if data, _ := os.ReadFile(path); len(data) > 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("recording not written after the consumer went away")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,173 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	goopenai "github.com/sashabaranov/go-openai"
+
+	"giai/pkg/types"
+)
+
+// RetryPolicy configures WithRetry. Zero fields take the DefaultRetryPolicy values.
+type RetryPolicy struct {
+	MaxRetries        int // Retries after the first attempt
+	InitialBackoff    time.Duration
+	MaxBackoff        time.Duration
+	BackoffMultiplier float64
+	// RetryableErrors lists substrings that mark an otherwise unclassified
+	// error as transient, for providers that do not return *Error.
+	RetryableErrors []string
+}
+
+// DefaultRetryPolicy returns a standard provider retry configuration.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxRetries:        3,
+		InitialBackoff:    500 * time.Millisecond,
+		MaxBackoff:        10 * time.Second,
+		BackoffMultiplier: 2,
+	}
+}
+
+type retrying struct {
+	inner  ChatModel
+	policy RetryPolicy
+}
+
+// WithRetry wraps inner so that Chat calls, and Stream calls that fail before
+// delivering anything, are retried on transient errors with jittered
+// exponential backoff. Once a stream has delivered a chunk, later errors are
+// passed through, since a retry would repeat content. Cancelling the context
+// ends the wait between attempts. The final attempt's response, usage
+// included, is returned unchanged.
+func WithRetry(inner ChatModel, policy RetryPolicy) ChatModel {
+	def := DefaultRetryPolicy()
+	if policy.MaxRetries <= 0 {
+		policy.MaxRetries = def.MaxRetries
+	}
+	if policy.InitialBackoff <= 0 {
+		policy.InitialBackoff = def.InitialBackoff
+	}
+	if policy.MaxBackoff <= 0 {
+		policy.MaxBackoff = def.MaxBackoff
+	}
+	if policy.BackoffMultiplier < 1 {
+		policy.BackoffMultiplier = def.BackoffMultiplier
+	}
+	return &retrying{inner: inner, policy: policy}
+}
+
+func (r *retrying) Name() string {
+	return r.inner.Name()
+}
+
+// Capabilities reports the inner model's capabilities.
+func (r *retrying) Capabilities() Capabilities {
+	return CapabilitiesOf(r.inner)
+}
+
+// Chat implements ChatModel.Chat
+func (r *retrying) Chat(ctx context.Context, messages []types.Message, opts ...Option) (*types.ChatResponse, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := r.inner.Chat(ctx, messages, opts...)
+		if err == nil || !r.wait(ctx, attempt, err) {
+			return resp, err
+		}
+	}
+}
+
+// Stream implements ChatModel.Stream
+func (r *retrying) Stream(ctx context.Context, messages []types.Message, opts ...Option) (<-chan ChatChunk, error) {
+	for attempt := 0; ; attempt++ {
+		stream, err := r.inner.Stream(ctx, messages, opts...)
+		if err != nil {
+			if r.wait(ctx, attempt, err) {
+				continue
+			}
+			return nil, err
+		}
+
+		first, ok := <-stream
+		if !ok {
+			out := make(chan ChatChunk)
+			close(out)
+			return out, nil
+		}
+		if first.Error != nil && r.wait(ctx, attempt, first.Error) {
+			go drain(stream)
+			continue
+		}
+
+		out := make(chan ChatChunk)
+		go func() {
+			defer close(out)
+			if !forward(ctx, out, first) {
+				drain(stream)
+				return
+			}
+			for chunk := range stream {
+				if !forward(ctx, out, chunk) {
+					drain(stream)
+					return
+				}
+			}
+		}()
+		return out, nil
+	}
+}
+
+// wait reports whether a failed attempt should be retried, after sleeping its
+// backoff. Fatal errors, exhausted retries and a cancelled context end the call.
+func (r *retrying) wait(ctx context.Context, attempt int, err error) bool {
+	if attempt >= r.policy.MaxRetries || !r.retryable(err) {
+		return false
+	}
+	select {
+	case <-time.After(r.backoff(attempt)):
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// retryable classifies err: normalized provider errors by their Retryable
+// flag, raw OpenAI API errors by status code, anything else by the policy's
+// substrings.
+func (r *retrying) retryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var pe *Error
+	if errors.As(err, &pe) {
+		return pe.Retryable
+	}
+	var apiErr *goopenai.APIError
+	if errors.As(err, &apiErr) {
+		return IsRetryableStatus(apiErr.HTTPStatusCode)
+	}
+	msg := err.Error()
+	for _, s := range r.policy.RetryableErrors {
+		if s != "" && strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the delay before retry attempt+1: the exponential delay
+// capped at MaxBackoff, with up to half of it removed at random so that
+// clients failing together do not retry in lockstep.
+func (r *retrying) backoff(attempt int) time.Duration {
+	d := float64(r.policy.InitialBackoff)
+	for i := 0; i < attempt && d < float64(r.policy.MaxBackoff); i++ {
+		d *= r.policy.BackoffMultiplier
+	}
+	d = min(d, float64(r.policy.MaxBackoff))
+	return time.Duration(d/2 + rand.Float64()*d/2)
+}
+
+var _ ChatModel = (*retrying)(nil)
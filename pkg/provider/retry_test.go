@@ -0,0 +1,141 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	goopenai "github.com/sashabaranov/go-openai"
+
+	"giai/pkg/types"
+)
+
+// flakyModel fails with errs in turn, then answers with usage.
+type flakyModel struct {
+	errs  []error
+	usage types.Usage
+	calls int
+}
+
+func (m *flakyModel) Name() string { return "flaky" }
+
+func (m *flakyModel) Chat(ctx context.Context, messages []types.Message, opts ...Option) (*types.ChatResponse, error) {
+	m.calls++
+	if m.calls <= len(m.errs) {
+		return nil, m.errs[m.calls-1]
+	}
+	return &types.ChatResponse{
+		Message:      types.AssistantMessage("ok"),
+		FinishReason: "stop",
+		Usage:        m.usage,
+	}, nil
+}
+
+func (m *flakyModel) Stream(ctx context.Context, messages []types.Message, opts ...Option) (<-chan ChatChunk, error) {
+	resp, err := m.Chat(ctx, messages, opts...)
+	if err != nil {
+		// Report the failure in-stream, as providers do for errors after connecting.
+		ch := make(chan ChatChunk, 1)
+		ch <- ChatChunk{Error: err}
+		close(ch)
+		return ch, nil
+	}
+	return responseToStream(resp), nil
+}
+
+var fastRetry = RetryPolicy{MaxRetries: 2, InitialBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond}
+
+func TestWithRetry_Chat(t *testing.T) {
+	tests := []struct {
+		name      string
+		errs      []error
+		policy    RetryPolicy
+		wantErr   bool
+		wantCalls int
+	}{
+		{
+			name:      "Rate Limited Then Succeeds",
+			errs:      []error{&Error{Provider: "openai", StatusCode: 429, Retryable: true}},
+			wantCalls: 2,
+		},
+		{
+			name:      "Raw OpenAI 503",
+			errs:      []error{&goopenai.APIError{HTTPStatusCode: 503}},
+			wantCalls: 2,
+		},
+		{
+			name:      "Bad Request",
+			errs:      []error{&Error{Provider: "openai", StatusCode: 400}},
+			wantErr:   true,
+			wantCalls: 1,
+		},
+		{
+			name:      "Matching Substring",
+			errs:      []error{errors.New("upstream overloaded")},
+			policy:    RetryPolicy{RetryableErrors: []string{"overloaded"}},
+			wantCalls: 2,
+		},
+		{
+			name:      "Unclassified",
+			errs:      []error{errors.New("boom")},
+			wantErr:   true,
+			wantCalls: 1,
+		},
+		{
+			name: "Retries Exhausted",
+			errs: []error{
+				&Error{StatusCode: 500, Retryable: true},
+				&Error{StatusCode: 500, Retryable: true},
+				&Error{StatusCode: 500, Retryable: true},
+			},
+			wantErr:   true,
+			wantCalls: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := fastRetry
+			policy.RetryableErrors = tt.policy.RetryableErrors
+			inner := &flakyModel{errs: tt.errs, usage: types.Usage{PromptTokens: 3, CompletionTokens: 1, TotalTokens: 4}}
+
+			resp, err := WithRetry(inner, policy).Chat(context.Background(), nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Chat() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if inner.calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", inner.calls, tt.wantCalls)
+			}
+			if !tt.wantErr && resp.Usage != inner.usage {
+				t.Errorf("Usage = %+v, want the final attempt's %+v", resp.Usage, inner.usage)
+			}
+		})
+	}
+}
+
+func TestWithRetry_Stream(t *testing.T) {
+	inner := &flakyModel{errs: []error{&Error{StatusCode: 502, Retryable: true}}}
+
+	resp, err := StreamAndCollect(context.Background(), WithRetry(inner, fastRetry), nil, nil)
+	if err != nil {
+		t.Fatalf("StreamAndCollect() error = %v", err)
+	}
+	if resp.Message.Content != "ok" || inner.calls != 2 {
+		t.Errorf("content = %q after %d calls, want ok after 2", resp.Message.Content, inner.calls)
+	}
+}
+
+func TestWithRetry_CancelledDuringBackoff(t *testing.T) {
+	inner := &flakyModel{errs: []error{&Error{StatusCode: 503, Retryable: true}}}
+	policy := RetryPolicy{InitialBackoff: time.Hour}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := WithRetry(inner, policy).Chat(ctx, nil); err == nil {
+		t.Fatal("Chat() expected the attempt's error after cancellation")
+	}
+	if inner.calls != 1 {
+		t.Errorf("calls = %d, want 1", inner.calls)
+	}
+}
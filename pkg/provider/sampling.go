@@ -0,0 +1,55 @@
+package provider
+
+import "fmt"
+
+// Logger receives provider diagnostics. *slog.Logger and tool.Logger satisfy it.
+type Logger interface {
+	Info(msg string, keysAndValues ...any)
+}
+
+// SamplingRange is the inclusive range a provider accepts for the sampling
+// parameters; the lower bound of both is 0.
+type SamplingRange struct {
+	MaxTemperature float64
+	MaxTopP        float64
+}
+
+// ParamRangeError reports a sampling parameter outside the provider's range.
+type ParamRangeError struct {
+	Param string
+	Value float64
+	Min   float64
+	Max   float64
+}
+
+func (e *ParamRangeError) Error() string {
+	return fmt.Sprintf("%s %g is outside the supported range [%g, %g]", e.Param, e.Value, e.Min, e.Max)
+}
+
+// ClampSampling brings options.Temperature and options.TopP into r. Out-of-range
+// values are clamped to the nearest bound and logged, or, when strict is set,
+// rejected with a *ParamRangeError. logger may be nil.
+func ClampSampling(options *ChatOptions, r SamplingRange, strict bool, logger Logger) error {
+	params := []struct {
+		name string
+		val  *float64
+		max  float64
+	}{
+		{"temperature", &options.Temperature, r.MaxTemperature},
+		{"top_p", &options.TopP, r.MaxTopP},
+	}
+	for _, p := range params {
+		v := *p.val
+		if v >= 0 && v <= p.max {
+			continue
+		}
+		if strict {
+			return &ParamRangeError{Param: p.name, Value: v, Min: 0, Max: p.max}
+		}
+		*p.val = min(max(v, 0), p.max)
+		if logger != nil {
+			logger.Info("clamped sampling parameter", "param", p.name, "value", v, "clamped", *p.val, "model", options.Model)
+		}
+	}
+	return nil
+}
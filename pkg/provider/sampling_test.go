@@ -0,0 +1,44 @@
+package provider
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestClampSampling(t *testing.T) {
+	r := SamplingRange{MaxTemperature: 2, MaxTopP: 1}
+	tests := []struct {
+		name     string
+		in       ChatOptions
+		wantTemp float64
+		wantTopP float64
+	}{
+		{"in range", ChatOptions{Temperature: 0.7, TopP: 0.9}, 0.7, 0.9},
+		{"temperature above max", ChatOptions{Temperature: 3.0}, 2, 0},
+		{"negative temperature", ChatOptions{Temperature: -1}, 0, 0},
+		{"top_p above max", ChatOptions{Temperature: 1, TopP: 1.5}, 1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.in
+			if err := ClampSampling(&opts, r, false, nil); err != nil {
+				t.Fatalf("ClampSampling() error = %v", err)
+			}
+			if opts.Temperature != tt.wantTemp || opts.TopP != tt.wantTopP {
+				t.Errorf("ClampSampling() = (%v, %v), want (%v, %v)", opts.Temperature, opts.TopP, tt.wantTemp, tt.wantTopP)
+			}
+		})
+	}
+}
+
+func TestClampSampling_Strict(t *testing.T) {
+	opts := ChatOptions{Temperature: 1, TopP: 1.5}
+	err := ClampSampling(&opts, SamplingRange{MaxTemperature: 2, MaxTopP: 1}, true, nil)
+	var rangeErr *ParamRangeError
+	if !errors.As(err, &rangeErr) || rangeErr.Param != "top_p" || rangeErr.Max != 1 {
+		t.Fatalf("ClampSampling() error = %v, want *ParamRangeError for top_p", err)
+	}
+	if opts.TopP != 1.5 {
+		t.Errorf("TopP = %v, want it left unchanged in strict mode", opts.TopP)
+	}
+}
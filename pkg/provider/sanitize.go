@@ -0,0 +1,94 @@
+package provider
+
+import (
+	"context"
+
+	"giai/pkg/types"
+)
+
+type sanitizing struct {
+	inner  ChatModel
+	logger Logger
+}
+
+// SanitizeOptions wraps inner so that options the target model does not
+// support, according to the capability table, are dropped instead of being
+// rejected by the API. This lets one set of options be reused across models.
+// The target is the model named by the call's options, or inner's default.
+// Models missing from the table are passed every option unchanged. Each
+// dropped option is logged; logger may be nil.
+func SanitizeOptions(inner ChatModel, logger Logger) ChatModel {
+	return &sanitizing{inner: inner, logger: logger}
+}
+
+func (s *sanitizing) Name() string {
+	return s.inner.Name()
+}
+
+// Capabilities reports the inner model's capabilities.
+func (s *sanitizing) Capabilities() Capabilities {
+	return CapabilitiesOf(s.inner)
+}
+
+// Chat implements ChatModel.Chat
+func (s *sanitizing) Chat(ctx context.Context, messages []types.Message, opts ...Option) (*types.ChatResponse, error) {
+	return s.inner.Chat(ctx, messages, s.sanitize(opts)...)
+}
+
+// Stream implements ChatModel.Stream
+func (s *sanitizing) Stream(ctx context.Context, messages []types.Message, opts ...Option) (<-chan ChatChunk, error) {
+	return s.inner.Stream(ctx, messages, s.sanitize(opts)...)
+}
+
+// sanitize returns opts followed by an option clearing whatever the target
+// model cannot accept.
+func (s *sanitizing) sanitize(opts []Option) []Option {
+	set := ResolveOptions(ChatOptions{}, opts...)
+	caps := CapabilitiesOf(s.inner)
+	if set.Model != "" {
+		caps = CapabilitiesForModel(set.Model)
+	}
+	if !caps.Known {
+		return opts
+	}
+
+	var dropped []string
+	var clear []func(*ChatOptions)
+	drop := func(name string, f func(*ChatOptions)) {
+		dropped = append(dropped, name)
+		clear = append(clear, f)
+	}
+	if !caps.Tools && (len(set.Tools) > 0 || set.ToolChoice != nil) {
+		drop("tools", func(o *ChatOptions) { o.Tools, o.ToolChoice, o.ParallelToolCalls = nil, nil, nil })
+	} else if !caps.ParallelToolCalls && set.ParallelToolCalls != nil {
+		drop("parallel_tool_calls", func(o *ChatOptions) { o.ParallelToolCalls = nil })
+	}
+	if f := set.ResponseFormat; f != nil {
+		if !caps.JSONMode || f.Type == ResponseFormatJSONSchema && !caps.StructuredOutputs {
+			drop("response_format", func(o *ChatOptions) { o.ResponseFormat = nil })
+		}
+	}
+	if !caps.Reasoning && set.ReasoningEffort != "" {
+		drop("reasoning_effort", func(o *ChatOptions) { o.ReasoningEffort = "" })
+	}
+	if len(dropped) == 0 {
+		return opts
+	}
+
+	if s.logger != nil {
+		model := set.Model
+		if model == "" {
+			model = s.inner.Name()
+		}
+		s.logger.Info("dropped unsupported options", "options", dropped, "model", model)
+	}
+	out := make([]Option, len(opts), len(opts)+1)
+	copy(out, opts)
+	return append(out, func(o *ChatOptions) {
+		for _, f := range clear {
+			f(o)
+		}
+	})
+}
+
+var _ ChatModel = (*sanitizing)(nil)
@@ -0,0 +1,111 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"giai/pkg/types"
+)
+
+// optionsModel records the options of its last call.
+type optionsModel struct {
+	got ChatOptions
+}
+
+func (m *optionsModel) Name() string { return "options" }
+
+func (m *optionsModel) Chat(ctx context.Context, messages []types.Message, opts ...Option) (*types.ChatResponse, error) {
+	m.got = ResolveOptions(ChatOptions{}, opts...)
+	return &types.ChatResponse{Message: types.AssistantMessage("ok"), FinishReason: "stop"}, nil
+}
+
+func (m *optionsModel) Stream(ctx context.Context, messages []types.Message, opts ...Option) (<-chan ChatChunk, error) {
+	resp, _ := m.Chat(ctx, messages, opts...)
+	return responseToStream(resp), nil
+}
+
+// infoLogger records logged messages.
+type infoLogger struct {
+	msgs []string
+}
+
+func (l *infoLogger) Info(msg string, keysAndValues ...any) { l.msgs = append(l.msgs, msg) }
+
+func TestSanitizeOptions(t *testing.T) {
+	schema := map[string]any{"type": "object"}
+	tests := []struct {
+		name       string
+		opts       []Option
+		wantFormat bool
+		wantEffort string
+		wantLogged bool
+	}{
+		{
+			name:       "Model Lacking JSON Mode",
+			opts:       []Option{WithModel("gpt-4"), WithJSONMode(), WithTemperature(0.2)},
+			wantLogged: true,
+		},
+		{
+			name:       "Model Lacking JSON Schema",
+			opts:       []Option{WithModel("gpt-3.5-turbo"), WithJSONSchema("out", schema, true)},
+			wantLogged: true,
+		},
+		{
+			name:       "Supported",
+			opts:       []Option{WithModel("gpt-4o"), WithJSONSchema("out", schema, true)},
+			wantFormat: true,
+		},
+		{
+			name:       "Unknown Model",
+			opts:       []Option{WithModel("my-local-model"), WithJSONMode(), WithReasoningEffort("low")},
+			wantFormat: true,
+			wantEffort: "low",
+		},
+		{
+			name:       "Reasoning Effort",
+			opts:       []Option{WithModel("gpt-4o"), WithReasoningEffort("high")},
+			wantLogged: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &optionsModel{}
+			logger := &infoLogger{}
+			if _, err := SanitizeOptions(inner, logger).Chat(context.Background(), nil, tt.opts...); err != nil {
+				t.Fatalf("Chat() error = %v", err)
+			}
+			if got := inner.got.ResponseFormat != nil; got != tt.wantFormat {
+				t.Errorf("response_format kept = %v, want %v", got, tt.wantFormat)
+			}
+			if inner.got.ReasoningEffort != tt.wantEffort {
+				t.Errorf("ReasoningEffort = %q, want %q", inner.got.ReasoningEffort, tt.wantEffort)
+			}
+			if inner.got.Model == "" {
+				t.Error("Model was dropped, want it passed through")
+			}
+			if got := len(logger.msgs) > 0; got != tt.wantLogged {
+				t.Errorf("logged = %v, want %v", logger.msgs, tt.wantLogged)
+			}
+		})
+	}
+}
+
+func TestSanitizeOptions_Tools(t *testing.T) {
+	inner := &optionsModel{}
+	tools := WithTools(types.NewToolDefinition("search", "Search.", nil))
+
+	if _, err := SanitizeOptions(inner, nil).Chat(context.Background(), nil, WithModel("o1-mini"), tools, WithToolChoice("required")); err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if len(inner.got.Tools) != 0 || inner.got.ToolChoice != nil {
+		t.Errorf("options = %+v, want tools dropped for a model without tool support", inner.got)
+	}
+
+	if _, err := SanitizeOptions(inner, nil).Chat(context.Background(), nil, WithModel("gpt-4o"), tools); err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if len(inner.got.Tools) != 1 {
+		t.Errorf("Tools = %+v, want them kept", inner.got.Tools)
+	}
+}
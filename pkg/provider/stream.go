@@ -0,0 +1,247 @@
+package provider
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"giai/pkg/types"
+)
+
+// Coalesce batches content deltas that arrive within minInterval of the first
+// buffered delta into a single chunk, smoothing UIs fed by providers that emit
+// tiny fragments. Chunks carrying tool calls, finish reasons, usage or errors
+// flush any buffered content first and are forwarded unchanged.
+func Coalesce(stream <-chan ChatChunk, minInterval time.Duration) <-chan ChatChunk {
+	out := make(chan ChatChunk)
+
+	go func() {
+		defer close(out)
+
+		var (
+			buf     strings.Builder
+			id      string
+			timer   *time.Timer
+			timeout <-chan time.Time
+		)
+
+		flush := func() {
+			if timer != nil {
+				timer.Stop()
+				timer, timeout = nil, nil
+			}
+			if buf.Len() == 0 {
+				return
+			}
+			out <- ChatChunk{Content: buf.String(), ID: id}
+			buf.Reset()
+		}
+
+		for {
+			select {
+			case chunk, ok := <-stream:
+				if !ok {
+					flush()
+					return
+				}
+				if isPlainDelta(chunk) {
+					if buf.Len() == 0 {
+						timer = time.NewTimer(minInterval)
+						timeout = timer.C
+					}
+					buf.WriteString(chunk.Content)
+					id = chunk.ID
+					continue
+				}
+				flush()
+				out <- chunk
+			case <-timeout:
+				timer, timeout = nil, nil
+				flush()
+			}
+		}
+	}()
+
+	return out
+}
+
+// StreamHandlers receive events while StreamAndCollectEvents consumes a
+// stream. Nil handlers are skipped.
+type StreamHandlers struct {
+	// OnDelta receives assistant text deltas. Chunks carrying a tool call
+	// fragment are tool-call activity: their content is collected but not
+	// passed here.
+	OnDelta func(delta string)
+	// OnToolCall receives each raw tool call fragment as it arrives.
+	OnToolCall func(fragment types.ToolCall)
+}
+
+// StreamAndCollect streams a response from m, forwarding each content delta to
+// onDelta (which may be nil), and returns the assembled response: content, tool
+// calls, usage, finish reason, response ID and message metadata. Tool call
+// fragments are merged: a fragment with a new ID starts a call, and fragments
+// without an ID extend the latest call started at their Index, or start one if
+// there is none, so parallel calls whose fragments interleave are reassembled
+// separately.
+// When opts set Stop sequences, they are trimmed from the content.
+// On a stream error the response collected so far, including any finish reason
+// and usage carried by the error chunk, is returned with the error.
+func StreamAndCollect(ctx context.Context, m ChatModel, messages []types.Message, onDelta func(string), opts ...Option) (*types.ChatResponse, error) {
+	return StreamAndCollectEvents(ctx, m, messages, StreamHandlers{OnDelta: onDelta}, opts...)
+}
+
+// StreamAndCollectEvents is StreamAndCollect with separate handlers for text
+// deltas and tool call fragments.
+func StreamAndCollectEvents(ctx context.Context, m ChatModel, messages []types.Message, h StreamHandlers, opts ...Option) (*types.ChatResponse, error) {
+	stream, err := m.Stream(ctx, messages, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if stops := ResolveOptions(ChatOptions{}, opts...).Stop; len(stops) > 0 {
+		stream = TrimStop(stream, stops)
+	}
+
+	resp := &types.ChatResponse{Message: types.Message{Role: types.RoleAssistant}}
+	var content strings.Builder
+	byID := make(map[string]int)
+	byIndex := make(map[int]int) // Latest call started at each index
+	for chunk := range stream {
+		if chunk.ID != "" {
+			resp.ID = chunk.ID
+		}
+		if chunk.FinishReason != "" {
+			resp.FinishReason = chunk.FinishReason
+		}
+		if chunk.Usage != nil {
+			resp.Usage = *chunk.Usage
+		}
+		if len(chunk.Metadata) > 0 {
+			if resp.Message.Metadata == nil {
+				resp.Message.Metadata = make(map[string]any, len(chunk.Metadata))
+			}
+			for k, v := range chunk.Metadata {
+				resp.Message.Metadata[k] = v
+			}
+		}
+		if chunk.Error != nil {
+			resp.Message.Content = content.String()
+			go drain(stream)
+			return resp, chunk.Error
+		}
+		if chunk.Content != "" {
+			content.WriteString(chunk.Content)
+			if h.OnDelta != nil && chunk.ToolCall == nil {
+				h.OnDelta(chunk.Content)
+			}
+		}
+		if tc := chunk.ToolCall; tc != nil {
+			if h.OnToolCall != nil {
+				h.OnToolCall(*tc)
+			}
+			calls := resp.Message.ToolCalls
+			i, ok := byID[tc.ID]
+			if !ok && tc.ID == "" {
+				i, ok = byIndex[tc.Index]
+			}
+			if ok {
+				if calls[i].Function.Name == "" {
+					calls[i].Function.Name = tc.Function.Name
+				}
+				calls[i].Function.Arguments += tc.Function.Arguments
+			} else {
+				if tc.ID != "" {
+					byID[tc.ID] = len(calls)
+				}
+				byIndex[tc.Index] = len(calls)
+				resp.Message.ToolCalls = append(calls, *tc)
+			}
+		}
+	}
+	resp.Message.Content = content.String()
+	return resp, nil
+}
+
+// isPlainDelta reports whether the chunk carries only text content.
+func isPlainDelta(c ChatChunk) bool {
+	return c.Content != "" && c.ToolCall == nil && c.FinishReason == "" && c.Usage == nil && c.Metadata == nil && c.Error == nil
+}
+
+// TrimStop strips stop sequences that providers echo into the streamed content,
+// including sequences split across chunk boundaries. Only as much trailing text
+// as could begin a stop sequence is held back; everything after a matched stop
+// sequence is dropped. Non-content chunks are forwarded once pending text is flushed.
+func TrimStop(stream <-chan ChatChunk, stops []string) <-chan ChatChunk {
+	var active []string
+	for _, s := range stops {
+		if s != "" {
+			active = append(active, s)
+		}
+	}
+	if len(active) == 0 {
+		return stream
+	}
+
+	out := make(chan ChatChunk)
+	go func() {
+		defer close(out)
+
+		var (
+			pending string
+			stopped bool
+		)
+		for chunk := range stream {
+			var emit string
+			if !stopped && chunk.Content != "" {
+				pending += chunk.Content
+				if idx := indexStop(pending, active); idx >= 0 {
+					emit, pending, stopped = pending[:idx], "", true
+				} else {
+					keep := stopPrefixLen(pending, active)
+					emit, pending = pending[:len(pending)-keep], pending[len(pending)-keep:]
+				}
+			}
+
+			if isPlainDelta(chunk) {
+				if emit != "" {
+					out <- ChatChunk{Content: emit, ID: chunk.ID}
+				}
+				continue
+			}
+
+			// A boundary chunk: held-back text can no longer become a stop sequence.
+			emit, pending = emit+pending, ""
+			chunk.Content = emit
+			out <- chunk
+		}
+		if pending != "" {
+			out <- ChatChunk{Content: pending}
+		}
+	}()
+	return out
+}
+
+// indexStop returns the earliest position of any stop sequence in s, or -1.
+func indexStop(s string, stops []string) int {
+	best := -1
+	for _, stop := range stops {
+		if i := strings.Index(s, stop); i >= 0 && (best < 0 || i < best) {
+			best = i
+		}
+	}
+	return best
+}
+
+// stopPrefixLen returns the length of the longest suffix of s that is a proper
+// prefix of some stop sequence.
+func stopPrefixLen(s string, stops []string) int {
+	longest := 0
+	for _, stop := range stops {
+		for n := min(len(stop)-1, len(s)); n > longest; n-- {
+			if strings.HasSuffix(s, stop[:n]) {
+				longest = n
+				break
+			}
+		}
+	}
+	return longest
+}
@@ -0,0 +1,241 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"giai/pkg/types"
+)
+
+func TestCoalesce_MergesWithinWindow(t *testing.T) {
+	in := make(chan ChatChunk)
+	out := Coalesce(in, 50*time.Millisecond)
+
+	go func() {
+		defer close(in)
+		for _, s := range []string{"H", "e", "l", "l", "o"} {
+			in <- ChatChunk{Content: s}
+		}
+		in <- ChatChunk{FinishReason: "stop"}
+	}()
+
+	var chunks []ChatChunk
+	for c := range out {
+		chunks = append(chunks, c)
+	}
+
+	if len(chunks) != 2 {
+		t.Fatalf("got %d chunks %+v, want 2", len(chunks), chunks)
+	}
+	if chunks[0].Content != "Hello" {
+		t.Errorf("chunks[0].Content = %q, want %q", chunks[0].Content, "Hello")
+	}
+	if chunks[1].FinishReason != "stop" {
+		t.Errorf("chunks[1].FinishReason = %q, want stop", chunks[1].FinishReason)
+	}
+}
+
+func TestCoalesce_FlushesAfterWindow(t *testing.T) {
+	in := make(chan ChatChunk)
+	out := Coalesce(in, 10*time.Millisecond)
+
+	go func() {
+		defer close(in)
+		in <- ChatChunk{Content: "first"}
+		time.Sleep(50 * time.Millisecond)
+		in <- ChatChunk{Content: "second"}
+	}()
+
+	var contents []string
+	for c := range out {
+		contents = append(contents, c.Content)
+	}
+
+	if len(contents) != 2 || contents[0] != "first" || contents[1] != "second" {
+		t.Errorf("contents = %q, want [first second]", contents)
+	}
+}
+
+func TestTrimStop_SplitAcrossChunks(t *testing.T) {
+	in := make(chan ChatChunk, 4)
+	in <- ChatChunk{Content: "Answer: 42 EN"}
+	in <- ChatChunk{Content: "D and more"}
+	in <- ChatChunk{FinishReason: "stop"}
+	close(in)
+
+	var content string
+	var finish string
+	for c := range TrimStop(in, []string{"END"}) {
+		content += c.Content
+		if c.FinishReason != "" {
+			finish = c.FinishReason
+		}
+	}
+
+	if content != "Answer: 42 " {
+		t.Errorf("content = %q, want %q", content, "Answer: 42 ")
+	}
+	if finish != "stop" {
+		t.Errorf("FinishReason = %q, want stop", finish)
+	}
+}
+
+func TestTrimStop_FlushesFalsePrefix(t *testing.T) {
+	in := make(chan ChatChunk, 2)
+	in <- ChatChunk{Content: "the EN"}
+	in <- ChatChunk{Content: "GINE"}
+	close(in)
+
+	var content string
+	for c := range TrimStop(in, []string{"END"}) {
+		content += c.Content
+	}
+	if content != "the ENGINE" {
+		t.Errorf("content = %q, want %q", content, "the ENGINE")
+	}
+}
+
+// chunkModel streams a fixed sequence of chunks over an unbuffered channel
+// and closes done once all were taken.
+type chunkModel struct {
+	chunks []ChatChunk
+	done   chan struct{}
+}
+
+func (m *chunkModel) Name() string { return "chunks" }
+
+func (m *chunkModel) Chat(ctx context.Context, messages []types.Message, opts ...Option) (*types.ChatResponse, error) {
+	return nil, errors.New("chunks: chat not supported")
+}
+
+func (m *chunkModel) Stream(ctx context.Context, messages []types.Message, opts ...Option) (<-chan ChatChunk, error) {
+	m.done = make(chan struct{})
+	ch := make(chan ChatChunk)
+	go func() {
+		defer close(m.done)
+		defer close(ch)
+		for _, c := range m.chunks {
+			ch <- c
+		}
+	}()
+	return ch, nil
+}
+
+func TestStreamAndCollect(t *testing.T) {
+	fragment := func(id, name, args string) *types.ToolCall {
+		tc := types.NewToolCall(id, name, args)
+		return &tc
+	}
+	m := &chunkModel{chunks: []ChatChunk{
+		{Content: "Let me "},
+		{Content: "check."},
+		{ToolCall: fragment("call_1", "weather", `{"ci`)},
+		{ToolCall: fragment("", "", `ty":"Paris"}`)},
+		{ToolCall: fragment("call_2", "time", ``)},
+		{ToolCall: fragment("", "", `{"tz":"CET"}`)},
+		{FinishReason: "tool_calls", Usage: &types.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}},
+	}}
+
+	var deltas []string
+	resp, err := StreamAndCollect(context.Background(), m, nil, func(d string) { deltas = append(deltas, d) })
+	if err != nil {
+		t.Fatalf("StreamAndCollect() error = %v", err)
+	}
+
+	if resp.Message.Role != types.RoleAssistant || resp.Message.Content != "Let me check." {
+		t.Errorf("Message = %+v, want assistant content %q", resp.Message, "Let me check.")
+	}
+	if strings.Join(deltas, "|") != "Let me |check." {
+		t.Errorf("deltas = %q, want each content chunk forwarded", deltas)
+	}
+	want := []types.ToolCall{
+		types.NewToolCall("call_1", "weather", `{"city":"Paris"}`),
+		types.NewToolCall("call_2", "time", `{"tz":"CET"}`),
+	}
+	if !reflect.DeepEqual(resp.Message.ToolCalls, want) {
+		t.Errorf("ToolCalls = %+v, want %+v", resp.Message.ToolCalls, want)
+	}
+	if resp.FinishReason != "tool_calls" || resp.Usage.TotalTokens != 15 {
+		t.Errorf("FinishReason = %q, Usage = %+v", resp.FinishReason, resp.Usage)
+	}
+}
+
+func TestStreamAndCollect_InterleavedParallelToolCalls(t *testing.T) {
+	fragment := func(index int, id, name, args string) *types.ToolCall {
+		tc := types.NewToolCall(id, name, args)
+		tc.Index = index
+		return &tc
+	}
+	m := &chunkModel{chunks: []ChatChunk{
+		{ToolCall: fragment(0, "call_1", "weather", ``)},
+		{ToolCall: fragment(1, "call_2", "time", ``)},
+		{ToolCall: fragment(0, "", "", `{"city":`)},
+		{ToolCall: fragment(1, "", "", `{"tz":`)},
+		{ToolCall: fragment(0, "", "", `"Paris"}`)},
+		{ToolCall: fragment(1, "", "", `"CET"}`)},
+		{FinishReason: "tool_calls"},
+	}}
+
+	resp, err := StreamAndCollect(context.Background(), m, nil, nil)
+	if err != nil {
+		t.Fatalf("StreamAndCollect() error = %v", err)
+	}
+	weather := types.NewToolCall("call_1", "weather", `{"city":"Paris"}`)
+	clock := types.NewToolCall("call_2", "time", `{"tz":"CET"}`)
+	clock.Index = 1
+	if want := []types.ToolCall{weather, clock}; !reflect.DeepEqual(resp.Message.ToolCalls, want) {
+		t.Errorf("ToolCalls = %+v, want %+v", resp.Message.ToolCalls, want)
+	}
+}
+
+func TestStreamAndCollect_ErrorReturnsPartial(t *testing.T) {
+	streamErr := errors.New("connection reset")
+	m := &chunkModel{chunks: []ChatChunk{{Content: "partial"}, {Error: streamErr, FinishReason: "length"}}}
+
+	resp, err := StreamAndCollect(context.Background(), m, nil, nil)
+	if !errors.Is(err, streamErr) {
+		t.Fatalf("StreamAndCollect() error = %v, want %v", err, streamErr)
+	}
+	if resp == nil || resp.Message.Content != "partial" || resp.FinishReason != "length" {
+		t.Errorf("resp = %+v, want the partial content and finish reason", resp)
+	}
+}
+
+func TestStreamAndCollectEvents(t *testing.T) {
+	call := types.NewToolCall("call_1", "search", `{"q":`)
+	m := &chunkModel{chunks: []ChatChunk{
+		{Content: "Searching.", ID: "resp_1"},
+		{ToolCall: &call, ID: "resp_1"},
+		{Content: `"go"}`, ToolCall: &types.ToolCall{Function: struct {
+			Name      string `json:"name"`
+			Arguments string `json:"arguments"`
+		}{Arguments: `"go"}`}}},
+		{FinishReason: "tool_calls"},
+	}}
+
+	var deltas []string
+	var fragments int
+	resp, err := StreamAndCollectEvents(context.Background(), m, nil, StreamHandlers{
+		OnDelta:    func(d string) { deltas = append(deltas, d) },
+		OnToolCall: func(types.ToolCall) { fragments++ },
+	})
+	if err != nil {
+		t.Fatalf("StreamAndCollectEvents() error = %v", err)
+	}
+	if len(deltas) != 1 || deltas[0] != "Searching." {
+		t.Errorf("deltas = %q, want only the assistant text", deltas)
+	}
+	if fragments != 2 {
+		t.Errorf("tool call fragments = %d, want 2", fragments)
+	}
+	if resp.ID != "resp_1" {
+		t.Errorf("ID = %q, want %q", resp.ID, "resp_1")
+	}
+	if got := resp.Message.ToolCalls; len(got) != 1 || got[0].Function.Arguments != `{"q":"go"}` {
+		t.Errorf("ToolCalls = %+v, want one merged call", got)
+	}
+}
@@ -0,0 +1,133 @@
+package provider
+
+import (
+	"hash/fnv"
+	"strings"
+	"sync"
+
+	"github.com/pkoukk/tiktoken-go"
+	tiktokenloader "github.com/pkoukk/tiktoken-go-loader"
+
+	"giai/pkg/types"
+)
+
+// Tokenizer converts text into model tokens.
+// It is the single tokenization entry point shared by memory windowing,
+// context budgeting, and token-counting tools.
+type Tokenizer interface {
+	// Encode returns the token IDs for text.
+	Encode(text string) []int
+	// Count returns the number of tokens in text.
+	Count(text string) int
+	// CountMessages estimates the prompt tokens consumed by a chat transcript,
+	// including per-message framing overhead.
+	CountMessages(messages []types.Message) int
+}
+
+// Per-message framing overhead used by OpenAI chat models
+// (see the "How to count tokens with tiktoken" cookbook).
+const (
+	tokensPerMessage = 3
+	tokensPerName    = 1
+	tokensPerReply   = 3
+)
+
+func init() {
+	// Use the embedded BPE ranks so tokenization never hits the network.
+	tiktoken.SetBpeLoader(tiktokenloader.NewOfflineLoader())
+}
+
+var tokenizerCache sync.Map // model name -> Tokenizer
+
+// TokenizerForModel returns a tiktoken-backed tokenizer for models with a known
+// encoding, falling back to WordTokenizer for everything else.
+// Tokenizers are cached per model since building the BPE tables is expensive.
+func TokenizerForModel(model string) Tokenizer {
+	if cached, ok := tokenizerCache.Load(model); ok {
+		return cached.(Tokenizer)
+	}
+
+	var tk Tokenizer = WordTokenizer{}
+	if strings.TrimSpace(model) != "" {
+		if enc, err := tiktoken.EncodingForModel(model); err == nil {
+			tk = &tiktokenTokenizer{enc: enc}
+		}
+	}
+
+	actual, _ := tokenizerCache.LoadOrStore(model, tk)
+	return actual.(Tokenizer)
+}
+
+// tiktokenTokenizer wraps a tiktoken encoding.
+type tiktokenTokenizer struct {
+	enc *tiktoken.Tiktoken
+}
+
+func (t *tiktokenTokenizer) Encode(text string) []int {
+	return t.enc.EncodeOrdinary(text)
+}
+
+func (t *tiktokenTokenizer) Count(text string) int {
+	return len(t.enc.EncodeOrdinary(text))
+}
+
+func (t *tiktokenTokenizer) CountMessages(messages []types.Message) int {
+	return countMessages(t, messages)
+}
+
+// WordTokenizer is a cheap approximation that treats each whitespace-separated
+// word as one token. Useful for models without a known encoding and for tests.
+type WordTokenizer struct{}
+
+func (WordTokenizer) Encode(text string) []int {
+	words := strings.Fields(text)
+	ids := make([]int, len(words))
+	for i, w := range words {
+		h := fnv.New32a()
+		h.Write([]byte(w))
+		ids[i] = int(h.Sum32())
+	}
+	return ids
+}
+
+func (WordTokenizer) Count(text string) int {
+	return len(strings.Fields(text))
+}
+
+func (w WordTokenizer) CountMessages(messages []types.Message) int {
+	return countMessages(w, messages)
+}
+
+func countMessages(t Tokenizer, messages []types.Message) int {
+	total := 0
+	for _, msg := range messages {
+		total += tokensPerMessage
+		total += t.Count(string(msg.Role))
+		total += t.Count(msg.Content)
+		if msg.Name != "" {
+			total += tokensPerName + t.Count(msg.Name)
+		}
+		for _, tc := range msg.ToolCalls {
+			total += t.Count(tc.Function.Name) + t.Count(tc.Function.Arguments)
+		}
+	}
+	return total + tokensPerReply
+}
+
+// TokenCounter counts the prompt tokens a transcript costs with a given model,
+// e.g. to check it fits the context window before sending it.
+type TokenCounter interface {
+	CountMessages(messages []types.Message, model string) (int, error)
+}
+
+// TiktokenCounter counts tokens with the tiktoken encoding of OpenAI models,
+// falling back to WordTokenizer's estimate for models without one.
+type TiktokenCounter struct{}
+
+func (TiktokenCounter) CountMessages(messages []types.Message, model string) (int, error) {
+	return TokenizerForModel(model).CountMessages(messages), nil
+}
+
+var _ Tokenizer = (*tiktokenTokenizer)(nil)
+var _ Tokenizer = WordTokenizer{}
+var _ TokenCounter = TiktokenCounter{}
@@ -0,0 +1,63 @@
+package provider
+
+import (
+	"reflect"
+	"testing"
+
+	"giai/pkg/types"
+)
+
+func TestTokenizerForModel(t *testing.T) {
+	tests := []struct {
+		model      string
+		text       string
+		wantTokens []int
+		wantCount  int
+	}{
+		{model: "gpt-4", text: "hello world", wantTokens: []int{15339, 1917}, wantCount: 2},
+		{model: "gpt-3.5-turbo", text: "The quick brown fox jumps over the lazy dog.", wantCount: 10},
+		{model: "gpt-4o", text: "hello world", wantTokens: []int{24912, 2375}, wantCount: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			tk := TokenizerForModel(tt.model)
+			if _, ok := tk.(WordTokenizer); ok {
+				t.Fatalf("TokenizerForModel(%q) fell back to WordTokenizer", tt.model)
+			}
+			if tt.wantTokens != nil {
+				if got := tk.Encode(tt.text); !reflect.DeepEqual(got, tt.wantTokens) {
+					t.Errorf("Encode() = %v, want %v", got, tt.wantTokens)
+				}
+			}
+			if got := tk.Count(tt.text); got != tt.wantCount {
+				t.Errorf("Count() = %d, want %d", got, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestTokenizer_CountMessages(t *testing.T) {
+	msgs := []types.Message{
+		{Role: types.RoleSystem, Content: "You are a helpful assistant."},
+		{Role: types.RoleUser, Content: "hello world"},
+	}
+
+	// 2 messages * 3 framing + role tokens (1 each) + 6 + 2 content tokens + 3 reply priming.
+	if got := TokenizerForModel("gpt-4").CountMessages(msgs); got != 19 {
+		t.Errorf("CountMessages() = %d, want 19", got)
+	}
+}
+
+func TestTokenizerForModel_Fallback(t *testing.T) {
+	tk := TokenizerForModel("some-local-model")
+	if _, ok := tk.(WordTokenizer); !ok {
+		t.Fatalf("TokenizerForModel() = %T, want WordTokenizer", tk)
+	}
+	if got := tk.Count("one two  three\nfour"); got != 4 {
+		t.Errorf("Count() = %d, want 4", got)
+	}
+	if got := len(tk.Encode("one two")); got != 2 {
+		t.Errorf("len(Encode()) = %d, want 2", got)
+	}
+}
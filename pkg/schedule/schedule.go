@@ -0,0 +1,53 @@
+// Package schedule runs agents periodically, for monitoring and automation.
+package schedule
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"giai/pkg/agent"
+)
+
+// newTicker starts the ticks that drive RunEvery; tests replace it with a fake.
+var newTicker = func(d time.Duration) (<-chan time.Time, func()) {
+	t := time.NewTicker(d)
+	return t.C, t.Stop
+}
+
+// RunEvery runs prompt on a every interval, starting one interval from now,
+// and hands each answer or error to onResult, which may be nil. A tick that
+// arrives while the previous run is still in flight is skipped rather than
+// queued; a slow onResult does not hold up the next run, so calls to it may
+// overlap. RunEvery blocks until ctx is done, then waits for an in-flight run,
+// which sees the cancelled context, before returning.
+func RunEvery(ctx context.Context, a *agent.Agent, prompt string, interval time.Duration, onResult func(string, error)) {
+	ticks, stop := newTicker(interval)
+	defer stop()
+
+	var (
+		wg       sync.WaitGroup
+		inFlight atomic.Bool
+	)
+	defer wg.Wait()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticks:
+			if !inFlight.CompareAndSwap(false, true) {
+				continue // Previous run still going.
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				out, err := a.Run(ctx, prompt)
+				inFlight.Store(false)
+				if onResult != nil {
+					onResult(out, err)
+				}
+			}()
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package schedule
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"giai/pkg/agent"
+	"giai/pkg/provider"
+	"giai/pkg/types"
+)
+
+// gatedModel answers once release receives, announcing each call on started.
+type gatedModel struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (m *gatedModel) Name() string { return "gated" }
+
+func (m *gatedModel) Chat(ctx context.Context, messages []types.Message, opts ...provider.Option) (*types.ChatResponse, error) {
+	m.started <- struct{}{}
+	select {
+	case <-m.release:
+		return &types.ChatResponse{Message: types.AssistantMessage("checked"), FinishReason: "stop"}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (m *gatedModel) Stream(ctx context.Context, messages []types.Message, opts ...provider.Option) (<-chan provider.ChatChunk, error) {
+	return nil, provider.ErrStreamingNotSupported
+}
+
+// fakeTicks swaps the ticker for a channel the test drives.
+func fakeTicks(t *testing.T) chan time.Time {
+	ticks := make(chan time.Time)
+	orig := newTicker
+	newTicker = func(time.Duration) (<-chan time.Time, func()) { return ticks, func() {} }
+	t.Cleanup(func() { newTicker = orig })
+	return ticks
+}
+
+func TestRunEvery(t *testing.T) {
+	ticks := fakeTicks(t)
+	model := &gatedModel{started: make(chan struct{}), release: make(chan struct{})}
+	ag, err := agent.New(agent.Config{Provider: model})
+	if err != nil {
+		t.Fatalf("agent.New() error = %v", err)
+	}
+
+	results := make(chan string)
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		RunEvery(ctx, ag, "check the queue", time.Minute, func(out string, err error) {
+			if err != nil {
+				t.Errorf("run error = %v", err)
+			}
+			results <- out
+		})
+	}()
+
+	const runs = 3
+	for i := 0; i < runs; i++ {
+		ticks <- time.Now()
+		<-model.started
+		// Ticks while the run is in flight are skipped. Each send returns once
+		// the loop has taken the tick, so both are handled before release.
+		ticks <- time.Now()
+		ticks <- time.Now()
+		model.release <- struct{}{}
+		if out := <-results; out != "checked" {
+			t.Errorf("run %d = %q, want %q", i, out, "checked")
+		}
+	}
+
+	cancel()
+	wg.Wait()
+	// A skipped tick that had started a run would still be blocked in Chat.
+	select {
+	case <-model.started:
+		t.Error("an overlapping tick started a run")
+	default:
+	}
+	if n := len(ag.History()); n != 2*runs {
+		t.Errorf("history has %d messages, want %d from %d runs", n, 2*runs, runs)
+	}
+}
+
+func TestRunEvery_CancelStopsInFlightRun(t *testing.T) {
+	ticks := fakeTicks(t)
+	model := &gatedModel{started: make(chan struct{}), release: make(chan struct{})}
+	ag, _ := agent.New(agent.Config{Provider: model})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	done := make(chan struct{})
+	go func() {
+		RunEvery(ctx, ag, "check", time.Minute, func(_ string, err error) { errs <- err })
+		close(done)
+	}()
+
+	ticks <- time.Now()
+	<-model.started
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("RunEvery did not return after cancellation")
+	}
+	if err := <-errs; err == nil {
+		t.Error("in-flight run error = nil, want the cancellation")
+	}
+}
@@ -0,0 +1,365 @@
+// Package schema validates JSON values against a practical subset of JSON Schema:
+// type, enum, const, properties, required, additionalProperties, items,
+// minimum/maximum, minLength/maxLength, pattern, minItems/maxItems and anyOf.
+// Values are expected in encoding/json form (map[string]any, []any, float64, ...);
+// Go integer types are accepted as numbers.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Error is one violation, located by a JSON-pointer-like path ("" is the root).
+type Error struct {
+	Path    string
+	Message string
+}
+
+func (e Error) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// Validate checks value against schema and returns every violation found.
+// A nil result means value conforms.
+func Validate(schema map[string]any, value any) []Error {
+	var errs []Error
+	validate(schema, value, "", &errs)
+	return errs
+}
+
+// Check reports problems in the schema itself: unknown types, malformed
+// keywords and invalid patterns. A nil result means the schema is usable.
+func Check(schema map[string]any) []Error {
+	var errs []Error
+	check(schema, "", &errs)
+	return errs
+}
+
+var knownTypes = map[string]bool{
+	"object": true, "array": true, "string": true, "number": true,
+	"integer": true, "boolean": true, "null": true,
+}
+
+func validate(s map[string]any, v any, path string, errs *[]Error) {
+	add := func(format string, args ...any) {
+		*errs = append(*errs, Error{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if types := stringList(s["type"]); len(types) > 0 {
+		ok := false
+		for _, t := range types {
+			if hasType(v, t) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			add("expected %s, got %s", strings.Join(types, " or "), typeName(v))
+			return // Further keywords would only repeat the mismatch
+		}
+	}
+
+	if enum, ok := s["enum"]; ok {
+		found := false
+		for _, e := range list(enum) {
+			if equal(e, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			add("value %s is not one of %s", render(v), render(enum))
+		}
+	}
+	if c, ok := s["const"]; ok && !equal(c, v) {
+		add("value %s does not equal %s", render(v), render(c))
+	}
+
+	if anyOf := list(s["anyOf"]); len(anyOf) > 0 {
+		matched := false
+		for _, sub := range anyOf {
+			if m, ok := sub.(map[string]any); ok && len(Validate(m, v)) == 0 {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			add("value does not match any of the anyOf schemas")
+		}
+	}
+
+	switch val := v.(type) {
+	case map[string]any:
+		validateObject(s, val, path, errs)
+	case []any:
+		validateArray(s, val, path, errs)
+	case string:
+		n := len([]rune(val))
+		if min, ok := number(s["minLength"]); ok && float64(n) < min {
+			add("string is shorter than %v characters", min)
+		}
+		if max, ok := number(s["maxLength"]); ok && float64(n) > max {
+			add("string is longer than %v characters", max)
+		}
+		if p, ok := s["pattern"].(string); ok {
+			if re, err := regexp.Compile(p); err == nil && !re.MatchString(val) {
+				add("string does not match pattern %q", p)
+			}
+		}
+	default:
+		if n, ok := number(v); ok {
+			if min, ok := number(s["minimum"]); ok && n < min {
+				add("%v is less than the minimum %v", n, min)
+			}
+			if max, ok := number(s["maximum"]); ok && n > max {
+				add("%v is greater than the maximum %v", n, max)
+			}
+		}
+	}
+}
+
+func validateObject(s map[string]any, obj map[string]any, path string, errs *[]Error) {
+	for _, name := range stringList(s["required"]) {
+		if _, ok := obj[name]; !ok {
+			*errs = append(*errs, Error{Path: path, Message: fmt.Sprintf("missing required property %q", name)})
+		}
+	}
+
+	props, _ := s["properties"].(map[string]any)
+	// Sorted for deterministic error order.
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		child := path + "/" + k
+		if sub, ok := props[k].(map[string]any); ok {
+			validate(sub, obj[k], child, errs)
+			continue
+		}
+		switch extra := s["additionalProperties"].(type) {
+		case bool:
+			if !extra {
+				*errs = append(*errs, Error{Path: child, Message: "additional property is not allowed"})
+			}
+		case map[string]any:
+			validate(extra, obj[k], child, errs)
+		}
+	}
+}
+
+func validateArray(s map[string]any, arr []any, path string, errs *[]Error) {
+	if min, ok := number(s["minItems"]); ok && float64(len(arr)) < min {
+		*errs = append(*errs, Error{Path: path, Message: fmt.Sprintf("array has fewer than %v items", min)})
+	}
+	if max, ok := number(s["maxItems"]); ok && float64(len(arr)) > max {
+		*errs = append(*errs, Error{Path: path, Message: fmt.Sprintf("array has more than %v items", max)})
+	}
+	if items, ok := s["items"].(map[string]any); ok {
+		for i, item := range arr {
+			validate(items, item, fmt.Sprintf("%s/%d", path, i), errs)
+		}
+	}
+}
+
+func check(s map[string]any, path string, errs *[]Error) {
+	add := func(format string, args ...any) {
+		*errs = append(*errs, Error{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if t, ok := s["type"]; ok {
+		types := stringList(t)
+		if len(types) == 0 {
+			add("type must be a string or an array of strings")
+		}
+		for _, name := range types {
+			if !knownTypes[name] {
+				add("unknown type %q", name)
+			}
+		}
+	}
+	if r, ok := s["required"]; ok && stringList(r) == nil {
+		add("required must be an array of strings")
+	}
+	if e, ok := s["enum"]; ok && list(e) == nil {
+		add("enum must be an array")
+	}
+	if p, ok := s["pattern"]; ok {
+		str, isStr := p.(string)
+		if !isStr {
+			add("pattern must be a string")
+		} else if _, err := regexp.Compile(str); err != nil {
+			add("invalid pattern: %v", err)
+		}
+	}
+
+	if p, ok := s["properties"]; ok {
+		props, isMap := p.(map[string]any)
+		if !isMap {
+			add("properties must be an object")
+		}
+		for name, sub := range props {
+			checkSub(sub, path+"/properties/"+name, errs)
+		}
+	}
+	if items, ok := s["items"]; ok {
+		checkSub(items, path+"/items", errs)
+	}
+	if extra, ok := s["additionalProperties"]; ok {
+		if _, isBool := extra.(bool); !isBool {
+			checkSub(extra, path+"/additionalProperties", errs)
+		}
+	}
+	for i, sub := range list(s["anyOf"]) {
+		checkSub(sub, fmt.Sprintf("%s/anyOf/%d", path, i), errs)
+	}
+}
+
+func checkSub(sub any, path string, errs *[]Error) {
+	m, ok := sub.(map[string]any)
+	if !ok {
+		*errs = append(*errs, Error{Path: path, Message: "schema must be an object"})
+		return
+	}
+	check(m, path, errs)
+}
+
+func hasType(v any, t string) bool {
+	switch t {
+	case "object":
+		_, ok := v.(map[string]any)
+		return ok
+	case "array":
+		_, ok := v.([]any)
+		return ok
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "null":
+		return v == nil
+	case "number":
+		_, ok := number(v)
+		return ok
+	case "integer":
+		n, ok := number(v)
+		return ok && n == float64(int64(n))
+	}
+	return false
+}
+
+func typeName(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	}
+	if _, ok := number(v); ok {
+		return "number"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// number converts JSON numbers and Go numeric kinds to float64.
+func number(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+// equal compares JSON values, treating numbers of any Go type by value.
+func equal(a, b any) bool {
+	if x, ok := number(a); ok {
+		y, ok := number(b)
+		return ok && x == y
+	}
+	return reflect.DeepEqual(normalize(a), normalize(b))
+}
+
+// normalize converts typed slices (e.g. []string from Go-built schemas) to []any.
+func normalize(v any) any {
+	if l := list(v); l != nil {
+		out := make([]any, len(l))
+		for i, e := range l {
+			out[i] = normalize(e)
+		}
+		return out
+	}
+	return v
+}
+
+// list returns v as []any when it is any kind of slice, else nil.
+func list(v any) []any {
+	if l, ok := v.([]any); ok {
+		return l
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		return nil
+	}
+	out := make([]any, rv.Len())
+	for i := range out {
+		out[i] = rv.Index(i).Interface()
+	}
+	return out
+}
+
+// stringList accepts a string, []string or []any of strings.
+func stringList(v any) []string {
+	if s, ok := v.(string); ok {
+		return []string{s}
+	}
+	l := list(v)
+	if l == nil {
+		return nil
+	}
+	out := make([]string, 0, len(l))
+	for _, e := range l {
+		s, ok := e.(string)
+		if !ok {
+			return nil
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+func render(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(b)
+}
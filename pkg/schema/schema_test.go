@@ -0,0 +1,106 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	s := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name": map[string]any{"type": "string", "minLength": 1},
+			"role": map[string]any{"type": "string", "enum": []string{"admin", "user"}},
+			"age":  map[string]any{"type": "integer", "minimum": 0},
+			"address": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"city": map[string]any{"type": "string"},
+					"zip":  map[string]any{"type": "string", "pattern": "^[0-9]{5}$"},
+				},
+				"required":             []string{"city"},
+				"additionalProperties": false,
+			},
+			"tags": map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "maxItems": 2},
+		},
+		"required": []any{"name", "role"},
+	}
+
+	tests := []struct {
+		name  string
+		value string
+		want  []string
+	}{
+		{
+			name:  "Valid",
+			value: `{"name":"ada","role":"admin","age":36,"address":{"city":"London","zip":"12345"},"tags":["a"]}`,
+		},
+		{
+			name:  "Missing Required",
+			value: `{"name":"ada"}`,
+			want:  []string{`missing required property "role"`},
+		},
+		{
+			name:  "Enum",
+			value: `{"name":"ada","role":"root"}`,
+			want:  []string{`/role: value "root" is not one of ["admin","user"]`},
+		},
+		{
+			name:  "Nested",
+			value: `{"name":"ada","role":"user","address":{"zip":"abc","country":"UK"}}`,
+			want: []string{
+				`/address: missing required property "city"`,
+				`/address/country: additional property is not allowed`,
+				`/address/zip: string does not match pattern "^[0-9]{5}$"`,
+			},
+		},
+		{
+			name:  "Types",
+			value: `{"name":"ada","role":"user","age":1.5,"tags":["a",2,"c"]}`,
+			want: []string{
+				`/age: expected integer, got number`,
+				`/tags: array has more than 2 items`,
+				`/tags/1: expected string, got number`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v any
+			if err := json.Unmarshal([]byte(tt.value), &v); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, e := range Validate(s, v) {
+				got = append(got, e.Error())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  map[string]any
+		wantErr bool
+	}{
+		{name: "Valid", schema: map[string]any{"type": "object", "properties": map[string]any{"a": map[string]any{"type": "string"}}}},
+		{name: "Unknown Type", schema: map[string]any{"type": "text"}, wantErr: true},
+		{name: "Nested Unknown Type", schema: map[string]any{"properties": map[string]any{"a": map[string]any{"type": "str"}}}, wantErr: true},
+		{name: "Bad Pattern", schema: map[string]any{"pattern": "("}, wantErr: true},
+		{name: "Bad Required", schema: map[string]any{"required": 3}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if errs := Check(tt.schema); (len(errs) > 0) != tt.wantErr {
+				t.Errorf("Check() = %v, wantErr %v", errs, tt.wantErr)
+			}
+		})
+	}
+}
@@ -51,6 +51,12 @@ func (f *Func) WithSchema(schema map[string]any) *Func {
 	return f
 }
 
+// WithFieldDescriptions annotates schema fields by path (see EnrichSchema).
+func (f *Func) WithFieldDescriptions(descriptions map[string]string) *Func {
+	f.SchemaVal = EnrichSchema(f.SchemaVal, descriptions)
+	return f
+}
+
 func (f *Func) WithPrompt(prompt string) *Func {
 	f.PromptVal = prompt
 	return f
@@ -71,6 +77,19 @@ func (f *Func) WithRetry(policy *RetryPolicy) *Func {
 	return f
 }
 
+// WithNoRetry disables retries, for tools whose side effects must not repeat.
+func (f *Func) WithNoRetry() *Func {
+	f.RetryPolicyVal = nil
+	return f
+}
+
+// WithRateLimit caps executions at rps per second with the given burst.
+func (f *Func) WithRateLimit(rps float64, burst int) *Func {
+	f.RateLimitVal = rps
+	f.RateBurstVal = burst
+	return f
+}
+
 func (f *Func) WithApproval(required bool) *Func {
 	f.RequiresApprovalVal = required
 	return f
@@ -108,6 +127,12 @@ func (s *Struct[T]) Execute(ctx context.Context, input map[string]any, tc *ToolC
 
 // Fluent setters for Struct.
 
+// WithFieldDescriptions annotates schema fields by path (see EnrichSchema).
+func (s *Struct[T]) WithFieldDescriptions(descriptions map[string]string) *Struct[T] {
+	s.SchemaVal = EnrichSchema(s.SchemaVal, descriptions)
+	return s
+}
+
 func (s *Struct[T]) WithPrompt(prompt string) *Struct[T] {
 	s.PromptVal = prompt
 	return s
@@ -122,3 +147,16 @@ func (s *Struct[T]) WithPriority(p int) *Struct[T] {
 	s.PriorityVal = p
 	return s
 }
+
+// WithRateLimit caps executions at rps per second with the given burst.
+func (s *Struct[T]) WithRateLimit(rps float64, burst int) *Struct[T] {
+	s.RateLimitVal = rps
+	s.RateBurstVal = burst
+	return s
+}
+
+// WithNoRetry disables retries, for tools whose side effects must not repeat.
+func (s *Struct[T]) WithNoRetry() *Struct[T] {
+	s.RetryPolicyVal = nil
+	return s
+}
@@ -18,15 +18,27 @@ type BaseTool struct {
 	PriorityVal       int
 	RequiresApprovalVal bool
 	RetryPolicyVal    *RetryPolicy
+	RateLimitVal      float64 // Executions per second; 0 means unlimited
+	RateBurstVal      int
+
+	// defaultRetry remembers the policy attached by NewBaseTool so the executor can
+	// tell it apart from one the tool chose explicitly.
+	defaultRetry *RetryPolicy
 }
 
+// NewBaseTool returns a BaseTool with a 30s timeout and DefaultRetryPolicy.
+// The default policy retries any error, which is unsafe for side-effecting tools:
+// set RetryPolicyVal to nil (or use WithNoRetry on Func/Struct) to opt out per tool,
+// or set ExecutorConfig.DisableDefaultRetries to ignore the default policy everywhere.
 func NewBaseTool(name, desc string) BaseTool {
+	policy := DefaultRetryPolicy()
 	return BaseTool{
 		NameVal:        name,
 		DescVal:        desc,
 		TimeoutVal:     30 * time.Second,
 		PriorityVal:    0,
-		RetryPolicyVal: DefaultRetryPolicy(),
+		RetryPolicyVal: policy,
+		defaultRetry:   policy,
 	}
 }
 
@@ -40,6 +52,12 @@ func (b *BaseTool) Timeout() time.Duration      { return b.TimeoutVal }
 func (b *BaseTool) Priority() int               { return b.PriorityVal }
 func (b *BaseTool) RequiresApproval() bool      { return b.RequiresApprovalVal }
 func (b *BaseTool) RetryPolicy() *RetryPolicy   { return b.RetryPolicyVal }
+func (b *BaseTool) RateLimit() (float64, int)   { return b.RateLimitVal, b.RateBurstVal }
+
+// usesDefaultRetryPolicy reports whether the retry policy is still the one set by NewBaseTool.
+func (b *BaseTool) usesDefaultRetryPolicy() bool {
+	return b.RetryPolicyVal != nil && b.RetryPolicyVal == b.defaultRetry
+}
 
 // Execute must be implemented by the embedding struct.
 func (b *BaseTool) Execute(ctx context.Context, input map[string]any, tc *ToolContext) (any, error) {
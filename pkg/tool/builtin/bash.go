@@ -24,6 +24,8 @@ func NewBash() *Bash {
 	
 	// Set a default timeout for safety
 	t.TimeoutVal = 2 * time.Minute
+	// Commands may be destructive; never re-run them automatically.
+	t.RetryPolicyVal = nil
 
 	t.SchemaVal = map[string]any{
 		"type": "object",
@@ -67,18 +69,17 @@ func (t *Bash) Execute(ctx context.Context, input map[string]any, tc *tool.ToolC
 	err := cmd.Run()
 	
 	// Prepare output
-	result := map[string]any{
-		"stdout": stdout.String(),
-		"stderr": stderr.String(),
-		"code":   0,
+	result := tool.ProcessResult{
+		Stdout: stdout.String(),
+		Stderr: stderr.String(),
 	}
 
 	if err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok {
-			result["code"] = exitErr.ExitCode()
+			result.ExitCode = exitErr.ExitCode()
 		} else {
-			result["code"] = -1 // Unknown error or signal
-			result["error"] = err.Error()
+			result.ExitCode = -1 // Unknown error or signal
+			result.Error = err.Error()
 		}
 	}
 
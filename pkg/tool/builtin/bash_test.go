@@ -52,13 +52,13 @@ func TestBash_Execute(t *testing.T) {
 			}
 			
 			if !tt.wantErr {
-				res, ok := got.(map[string]any)
+				res, ok := got.(tool.ProcessResult)
 				if !ok {
-					t.Fatalf("Result not a map")
+					t.Fatalf("Result is %T, want tool.ProcessResult", got)
 				}
 				
-				stdout := res["stdout"].(string)
-				code := res["code"].(int)
+				stdout := res.Stdout
+				code := res.ExitCode
 
 				if stdout != tt.wantStdOut {
 					t.Errorf("stdout = %q, want %q", stdout, tt.wantStdOut)
@@ -66,7 +66,7 @@ func TestBash_Execute(t *testing.T) {
 				if code != tt.wantCode {
 					// Note: Some shells might return slightly different codes for not found, 
 					// but 127 is standard for bash.
-					if tt.name == "Invalid Command" && code != 127 && !strings.Contains(res["stderr"].(string), "not found") {
+					if tt.name == "Invalid Command" && code != 127 && !strings.Contains(res.Stderr, "not found") {
 						t.Errorf("code = %d, want %d", code, tt.wantCode)
 					} else if tt.name != "Invalid Command" {
 						t.Errorf("code = %d, want %d", code, tt.wantCode)
@@ -76,3 +76,28 @@ func TestBash_Execute(t *testing.T) {
 		})
 	}
 }
+
+func TestBash_ProcessResult(t *testing.T) {
+	exec := tool.NewExecutor(tool.ExecutorConfig{})
+	res := exec.Execute(context.Background(), &tool.ExecuteRequest{
+		Tool:  NewBash(),
+		Input: map[string]any{"command": "echo out; echo err >&2; exit 3"},
+	})
+	if res.Error != nil {
+		t.Fatalf("Execute() error = %v", res.Error)
+	}
+
+	proc, ok := res.Process()
+	if !ok {
+		t.Fatalf("Process() not ok for output %T", res.Output)
+	}
+	if proc.ExitCode != 3 || proc.Stdout != "out\n" || proc.Stderr != "err\n" {
+		t.Errorf("Process() = %+v", proc)
+	}
+
+	// The JSON sent to the model is unchanged from the former map output.
+	want := tool.FormatOutput(map[string]any{"stdout": "out\n", "stderr": "err\n", "code": 3})
+	if got := tool.FormatOutput(res.Output); got != want {
+		t.Errorf("FormatOutput() = %s, want %s", got, want)
+	}
+}
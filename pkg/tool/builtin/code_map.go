@@ -0,0 +1,231 @@
+package builtin
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"giai/pkg/tool"
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// CodeMap summarizes many files at once so a model can orient itself in a
+// codebase without reading every file: Go files are reduced to their package
+// and top-level declarations, other files to their first lines.
+type CodeMap struct {
+	tool.BaseTool
+	Root string // Optional: restrict access to this directory
+}
+
+// CodeMapFile is the summary of one file.
+type CodeMapFile struct {
+	Path         string   `json:"path"`
+	Package      string   `json:"package,omitempty"`
+	Declarations []string `json:"declarations,omitempty"`
+	Head         string   `json:"head,omitempty"` // First lines, for non-Go or unparsable files
+}
+
+// CodeMapResult keeps output shape stable even when truncating results.
+type CodeMapResult struct {
+	Files      []CodeMapFile `json:"files"`
+	TotalFiles int           `json:"total_files"`
+	Truncated  bool          `json:"truncated,omitempty"`
+	Warning    string        `json:"warning,omitempty"`
+}
+
+const (
+	defaultCodeMapLines = 20
+	maxCodeMapChars     = 50000
+	// maxCodeMapFileBytes bounds how much of each file is read; a Go file cut
+	// short this way fails to parse and falls back to its first lines.
+	maxCodeMapFileBytes = 1 << 20 // 1 MiB
+)
+
+func NewCodeMap() *CodeMap {
+	t := &CodeMap{
+		BaseTool: tool.NewBaseTool(
+			"code_map",
+			"Summarize the files under a directory matching a glob: the package and top-level declarations of Go files, the first lines of other files. Use it for a cheap overview before reading specific files.",
+		),
+	}
+
+	t.TimeoutVal = 30 * time.Second
+
+	t.SchemaVal = map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"root": map[string]any{
+				"type":        "string",
+				"description": "The absolute path of the directory to map.",
+			},
+			"pattern": map[string]any{
+				"type":        "string",
+				"description": "Glob pattern relative to root (e.g., '**/*.go').",
+			},
+			"lines": map[string]any{
+				"type":        "integer",
+				"description": fmt.Sprintf("Lines to show from the top of non-Go files (default %d).", defaultCodeMapLines),
+			},
+		},
+		"required": []string{"root", "pattern"},
+	}
+
+	return t
+}
+
+func (t *CodeMap) Execute(ctx context.Context, input map[string]any, tc *tool.ToolContext) (any, error) {
+	root, ok := input["root"].(string)
+	if !ok {
+		return nil, fmt.Errorf("root must be a string")
+	}
+	pattern, ok := input["pattern"].(string)
+	if !ok || pattern == "" {
+		return nil, fmt.Errorf("pattern must be a non-empty string")
+	}
+	lines := defaultCodeMapLines
+	if n, ok := normalizeInt(input["lines"]); ok && n > 0 {
+		lines = n
+	}
+
+	root, err := safePath(root, t.Root)
+	if err != nil {
+		return nil, err
+	}
+
+	matches, err := doublestar.Glob(os.DirFS(root), pattern, doublestar.WithFilesOnly())
+	if err != nil {
+		return nil, fmt.Errorf("glob failed: %w", err)
+	}
+	// Glob follows symlinks, so matches that resolve outside Root are dropped.
+	var paths []string
+	for _, m := range matches {
+		if path, err := safePath(filepath.Join(root, m), t.Root); err == nil {
+			paths = append(paths, path)
+		}
+	}
+	slices.Sort(paths)
+
+	result := &CodeMapResult{Files: []CodeMapFile{}, TotalFiles: len(paths)}
+	used := 0
+	for _, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		file, err := summarizeFile(path, lines)
+		if err != nil {
+			return nil, err
+		}
+
+		size := len(file.Path) + len(file.Package) + len(file.Head)
+		for _, d := range file.Declarations {
+			size += len(d)
+		}
+		if used+size > maxCodeMapChars {
+			result.Truncated = true
+			result.Warning = fmt.Sprintf("Output limit reached, showing %d of %d files", len(result.Files), len(paths))
+			break
+		}
+		used += size
+		result.Files = append(result.Files, file)
+	}
+
+	return result, nil
+}
+
+// summarizeFile maps a Go file to its declarations and anything else, or a
+// Go file that does not parse, to its first lines.
+func summarizeFile(path string, lines int) (CodeMapFile, error) {
+	in, err := os.Open(path)
+	if err != nil {
+		return CodeMapFile{}, fmt.Errorf("failed to read file: %w", err)
+	}
+	defer in.Close()
+	src, err := io.ReadAll(io.LimitReader(in, maxCodeMapFileBytes))
+	if err != nil {
+		return CodeMapFile{}, fmt.Errorf("failed to read file: %w", err)
+	}
+	file := CodeMapFile{Path: path}
+
+	if strings.HasSuffix(path, ".go") {
+		fset := token.NewFileSet()
+		if f, err := parser.ParseFile(fset, path, src, parser.SkipObjectResolution); err == nil {
+			file.Package = f.Name.Name
+			file.Declarations = declarations(fset, f)
+			return file, nil
+		}
+	}
+
+	var head []string
+	scanner := bufio.NewScanner(bytes.NewReader(src))
+	for len(head) < lines && scanner.Scan() {
+		head = append(head, scanner.Text())
+	}
+	file.Head = strings.Join(head, "\n")
+	return file, nil
+}
+
+// declarations lists the top-level declarations of f: function signatures
+// without bodies, and type, const and var names.
+func declarations(fset *token.FileSet, f *ast.File) []string {
+	var out []string
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			sig := *d
+			sig.Doc, sig.Body = nil, nil
+			var buf bytes.Buffer
+			if err := printer.Fprint(&buf, fset, &sig); err == nil {
+				out = append(out, buf.String())
+			}
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					out = append(out, fmt.Sprintf("type %s %s", s.Name.Name, typeKind(s.Type)))
+				case *ast.ValueSpec:
+					for _, name := range s.Names {
+						out = append(out, d.Tok.String()+" "+name.Name)
+					}
+				}
+			}
+		}
+	}
+	return out
+}
+
+// typeKind names the kind of a type expression, e.g. "struct" or "interface",
+// without its contents.
+func typeKind(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.StructType:
+		return "struct"
+	case *ast.InterfaceType:
+		return "interface"
+	case *ast.FuncType:
+		return "func"
+	case *ast.MapType:
+		return "map"
+	case *ast.ArrayType:
+		return "slice"
+	case *ast.ChanType:
+		return "chan"
+	case *ast.Ident:
+		return e.Name
+	case *ast.SelectorExpr:
+		if x, ok := e.X.(*ast.Ident); ok {
+			return x.Name + "." + e.Sel.Name
+		}
+	}
+	return "type"
+}
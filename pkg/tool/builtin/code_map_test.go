@@ -0,0 +1,131 @@
+package builtin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"giai/pkg/tool"
+)
+
+func TestCodeMap_Execute(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/demo\n\ngo 1.24\n",
+		"main.go": `package main
+
+import "fmt"
+
+// Version is the build version.
+const Version = "1.0"
+
+type Server struct{ addr string }
+
+// Start runs the server.
+func (s *Server) Start(addr string) error {
+	fmt.Println(addr)
+	return nil
+}
+
+func main() {}
+`,
+		"util/strings.go": "package util\n\nfunc Reverse(s string) string { return s }\n",
+		"broken.go":       "package broken\n\nfunc (\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := NewCodeMap().Execute(context.Background(), map[string]any{
+		"root":    tmpDir,
+		"pattern": "**/*.{go,mod}",
+		"lines":   float64(1),
+	}, tool.NewToolContext())
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	res := got.(*CodeMapResult)
+	if res.TotalFiles != 4 || len(res.Files) != 4 || res.Truncated {
+		t.Fatalf("result = %+v, want all 4 files", res)
+	}
+
+	byName := make(map[string]CodeMapFile)
+	for _, f := range res.Files {
+		rel, _ := filepath.Rel(tmpDir, f.Path)
+		byName[filepath.ToSlash(rel)] = f
+	}
+
+	mainFile := byName["main.go"]
+	wantDecls := []string{"const Version", "type Server struct", "func (s *Server) Start(addr string) error", "func main()"}
+	if mainFile.Package != "main" || !reflect.DeepEqual(mainFile.Declarations, wantDecls) {
+		t.Errorf("main.go = %+v, want package main with %q", mainFile, wantDecls)
+	}
+	if util := byName["util/strings.go"]; util.Package != "util" || !reflect.DeepEqual(util.Declarations, []string{"func Reverse(s string) string"}) {
+		t.Errorf("util/strings.go = %+v", util)
+	}
+	if mod := byName["go.mod"]; mod.Head != "module example.com/demo" {
+		t.Errorf("go.mod head = %q, want its first line", mod.Head)
+	}
+	if broken := byName["broken.go"]; broken.Package != "" || broken.Head != "package broken" {
+		t.Errorf("broken.go = %+v, want the first line as a fallback", broken)
+	}
+}
+
+func TestCodeMap_Errors(t *testing.T) {
+	tmpDir := t.TempDir()
+	cm := NewCodeMap()
+	cm.Root = filepath.Join(tmpDir, "jail")
+
+	tests := []struct {
+		name  string
+		input map[string]any
+	}{
+		{"Missing Pattern", map[string]any{"root": cm.Root}},
+		{"Relative Root", map[string]any{"root": "src", "pattern": "*.go"}},
+		{"Outside Root", map[string]any{"root": tmpDir, "pattern": "*.go"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := cm.Execute(context.Background(), tt.input, tool.NewToolContext()); err == nil {
+				t.Error("Execute() expected error")
+			}
+		})
+	}
+}
+
+func TestCodeMap_SkipsSymlinkEscape(t *testing.T) {
+	tmpDir := t.TempDir()
+	root := filepath.Join(tmpDir, "jail")
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	outside := filepath.Join(tmpDir, "secret.go")
+	if err := os.WriteFile(outside, []byte("package secret\n\nconst Token = \"x\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(root, "link.go")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+
+	cm := NewCodeMap()
+	cm.Root = root
+	got, err := cm.Execute(context.Background(), map[string]any{"root": root, "pattern": "*.go"}, tool.NewToolContext())
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	res := got.(*CodeMapResult)
+	if res.TotalFiles != 1 || len(res.Files) != 1 || filepath.Base(res.Files[0].Path) != "main.go" {
+		t.Errorf("result = %+v, want only main.go", res)
+	}
+}
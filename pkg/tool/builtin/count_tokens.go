@@ -0,0 +1,58 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+
+	"giai/pkg/provider"
+	"giai/pkg/tool"
+)
+
+type CountTokens struct {
+	tool.BaseTool
+	DefaultModel string // Model whose tokenizer is used when the input omits one
+}
+
+func NewCountTokens() *CountTokens {
+	t := &CountTokens{
+		BaseTool: tool.NewBaseTool(
+			"count_tokens",
+			"Count the model tokens in a piece of text, e.g. to check whether it fits in the context window.",
+		),
+		DefaultModel: "gpt-4o",
+	}
+
+	t.SchemaVal = map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"text": map[string]any{
+				"type":        "string",
+				"description": "The text to count.",
+			},
+			"model": map[string]any{
+				"type":        "string",
+				"description": "Model whose tokenizer to use (optional).",
+			},
+		},
+		"required": []string{"text"},
+	}
+
+	return t
+}
+
+func (t *CountTokens) Execute(ctx context.Context, input map[string]any, tc *tool.ToolContext) (any, error) {
+	text, ok := input["text"].(string)
+	if !ok {
+		return nil, fmt.Errorf("text must be a string")
+	}
+
+	model, _ := input["model"].(string)
+	if model == "" {
+		model = t.DefaultModel
+	}
+
+	return map[string]any{
+		"model":  model,
+		"tokens": provider.TokenizerForModel(model).Count(text),
+	}, nil
+}
@@ -0,0 +1,57 @@
+package builtin
+
+import (
+	"context"
+	"testing"
+
+	"giai/pkg/tool"
+)
+
+func TestCountTokens_Execute(t *testing.T) {
+	ct := NewCountTokens()
+	ctx := context.Background()
+	tc := tool.NewToolContext()
+
+	tests := []struct {
+		name    string
+		input   map[string]any
+		want    int
+		wantErr bool
+	}{
+		{
+			name:  "Fixed Model",
+			input: map[string]any{"text": "hello world", "model": "gpt-4"},
+			want:  2,
+		},
+		{
+			name:  "Default Model",
+			input: map[string]any{"text": "The quick brown fox jumps over the lazy dog."},
+			want:  10,
+		},
+		{
+			name:  "Unknown Model Falls Back To Words",
+			input: map[string]any{"text": "one two three", "model": "my-local-model"},
+			want:  3,
+		},
+		{
+			name:    "Missing Text",
+			input:   map[string]any{},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ct.Execute(ctx, tt.input, tc)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if tokens := got.(map[string]any)["tokens"]; tokens != tt.want {
+				t.Errorf("tokens = %v, want %d", tokens, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,292 @@
+package builtin
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"giai/pkg/tool"
+)
+
+const (
+	defaultCSVLimit = 50
+	maxCSVLimit     = 500
+	// maxCSVOutputBytes bounds the cell text returned, so a wide file cannot
+	// flood the context even within the row limit.
+	maxCSVOutputBytes = 50000
+)
+
+// CSVQuery selects columns and filters rows of CSV data, returning only the
+// matching slice instead of the whole file. The first record is the header.
+type CSVQuery struct {
+	tool.BaseTool
+	Root string // Optional: restrict file reads to this directory
+}
+
+func NewCSVQuery() *CSVQuery {
+	t := &CSVQuery{
+		BaseTool: tool.NewBaseTool(
+			"csv_query",
+			"Query CSV data: select columns, filter rows and limit the result. The first row is treated as the header.",
+		),
+	}
+
+	t.SchemaVal = map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"csv": map[string]any{
+				"type":        "string",
+				"description": "Inline CSV content. Provide either csv or path.",
+			},
+			"path": map[string]any{
+				"type":        "string",
+				"description": "The absolute path to a CSV file. Provide either csv or path.",
+			},
+			"columns": map[string]any{
+				"type":        "array",
+				"items":       map[string]any{"type": "string"},
+				"description": "Columns to return (optional, default all).",
+			},
+			"where": map[string]any{
+				"type":        "array",
+				"description": "Row filters, all of which must match (optional).",
+				"items": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"column": map[string]any{"type": "string"},
+						"op": map[string]any{
+							"type": "string",
+							"enum": []string{"eq", "ne", "gt", "gte", "lt", "lte", "contains"},
+						},
+						"value": map[string]any{"type": "string"},
+					},
+					"required": []string{"column", "op", "value"},
+				},
+			},
+			"limit": map[string]any{
+				"type":        "integer",
+				"description": fmt.Sprintf("Maximum number of rows to return (optional, default %d, at most %d).", defaultCSVLimit, maxCSVLimit),
+			},
+		},
+	}
+
+	return t
+}
+
+// csvFilter is one parsed "where" predicate.
+type csvFilter struct {
+	col   int
+	op    string
+	value string
+}
+
+func (t *CSVQuery) Execute(ctx context.Context, input map[string]any, tc *tool.ToolContext) (any, error) {
+	src, err := t.open(input)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	r := csv.NewReader(src)
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("csv is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse csv: %w", err)
+	}
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[name] = i
+	}
+
+	columns := header
+	if raw, ok := input["columns"]; ok && raw != nil {
+		list, ok := raw.([]any)
+		if !ok {
+			return nil, fmt.Errorf("columns must be an array of strings")
+		}
+		columns = make([]string, 0, len(list))
+		for _, v := range list {
+			name, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("columns must be an array of strings")
+			}
+			if _, ok := index[name]; !ok {
+				return nil, fmt.Errorf("unknown column %q", name)
+			}
+			columns = append(columns, name)
+		}
+	}
+
+	filters, err := parseCSVFilters(input["where"], index)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := defaultCSVLimit
+	if v, ok := normalizeInt(input["limit"]); ok && v > 0 {
+		limit = min(v, maxCSVLimit)
+	}
+
+	rows := make([]map[string]string, 0)
+	matched, size := 0, 0
+	truncated := false
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse csv: %w", err)
+		}
+		if !matchCSVFilters(record, filters) {
+			continue
+		}
+		matched++
+		if truncated {
+			continue
+		}
+
+		row := make(map[string]string, len(columns))
+		rowSize := 0
+		for _, name := range columns {
+			v := cell(record, index[name])
+			row[name] = v
+			rowSize += len(name) + len(v)
+		}
+		if len(rows) >= limit || size+rowSize > maxCSVOutputBytes {
+			truncated = true
+			continue
+		}
+		size += rowSize
+		rows = append(rows, row)
+	}
+
+	return map[string]any{
+		"columns":   columns,
+		"rows":      rows,
+		"matched":   matched,
+		"truncated": truncated,
+	}, nil
+}
+
+// open returns a reader over the inline csv or the file at path.
+func (t *CSVQuery) open(input map[string]any) (io.ReadCloser, error) {
+	data, hasData := input["csv"].(string)
+	path, hasPath := input["path"].(string)
+	switch {
+	case hasData && hasPath:
+		return nil, fmt.Errorf("provide either csv or path, not both")
+	case hasData:
+		return io.NopCloser(strings.NewReader(data)), nil
+	case hasPath:
+		path, err := safePath(path, t.Root)
+		if err != nil {
+			return nil, err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open file: %w", err)
+		}
+		return f, nil
+	default:
+		return nil, fmt.Errorf("either csv or path is required")
+	}
+}
+
+func parseCSVFilters(raw any, index map[string]int) ([]csvFilter, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	list, ok := raw.([]any)
+	if !ok {
+		return nil, fmt.Errorf("where must be an array")
+	}
+	filters := make([]csvFilter, 0, len(list))
+	for _, item := range list {
+		m, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("where entries must be objects")
+		}
+		name, _ := m["column"].(string)
+		col, ok := index[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+		op, _ := m["op"].(string)
+		switch op {
+		case "eq", "ne", "gt", "gte", "lt", "lte", "contains":
+		default:
+			return nil, fmt.Errorf("unsupported op %q", op)
+		}
+		// Accept numbers from models that ignore the string type.
+		value := fmt.Sprint(m["value"])
+		if s, ok := m["value"].(string); ok {
+			value = s
+		}
+		filters = append(filters, csvFilter{col: col, op: op, value: value})
+	}
+	return filters, nil
+}
+
+func matchCSVFilters(record []string, filters []csvFilter) bool {
+	for _, f := range filters {
+		if !f.match(cell(record, f.col)) {
+			return false
+		}
+	}
+	return true
+}
+
+// match compares numerically when both sides parse as numbers, else as strings.
+func (f csvFilter) match(v string) bool {
+	if f.op == "contains" {
+		return strings.Contains(v, f.value)
+	}
+	var cmp int
+	a, errA := strconv.ParseFloat(strings.TrimSpace(v), 64)
+	b, errB := strconv.ParseFloat(strings.TrimSpace(f.value), 64)
+	switch {
+	case errA == nil && errB == nil:
+		switch {
+		case a < b:
+			cmp = -1
+		case a > b:
+			cmp = 1
+		}
+	default:
+		cmp = strings.Compare(v, f.value)
+	}
+	switch f.op {
+	case "eq":
+		return cmp == 0
+	case "ne":
+		return cmp != 0
+	case "gt":
+		return cmp > 0
+	case "gte":
+		return cmp >= 0
+	case "lt":
+		return cmp < 0
+	case "lte":
+		return cmp <= 0
+	}
+	return false
+}
+
+// cell returns record[i], or "" for short records.
+func cell(record []string, i int) string {
+	if i < len(record) {
+		return record[i]
+	}
+	return ""
+}
@@ -0,0 +1,127 @@
+package builtin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"giai/pkg/tool"
+)
+
+const testCSV = `name,city,age
+alice,Paris,34
+bob,Berlin,27
+carol,Paris,41
+dave,Rome,19
+`
+
+func TestCSVQuery_Execute(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "csv_query_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	path := filepath.Join(tmpDir, "people.csv")
+	if err := os.WriteFile(path, []byte(testCSV), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cq := NewCSVQuery()
+	ctx := context.Background()
+	tc := tool.NewToolContext()
+
+	tests := []struct {
+		name          string
+		input         map[string]any
+		wantColumns   []string
+		wantNames     []string
+		wantTruncated bool
+		wantErr       bool
+	}{
+		{
+			name:        "All Rows",
+			input:       map[string]any{"csv": testCSV},
+			wantColumns: []string{"name", "city", "age"},
+			wantNames:   []string{"alice", "bob", "carol", "dave"},
+		},
+		{
+			name:        "Select Columns",
+			input:       map[string]any{"path": path, "columns": []any{"name", "age"}},
+			wantColumns: []string{"name", "age"},
+			wantNames:   []string{"alice", "bob", "carol", "dave"},
+		},
+		{
+			name:          "Limit",
+			input:         map[string]any{"csv": testCSV, "limit": float64(2)},
+			wantColumns:   []string{"name", "city", "age"},
+			wantNames:     []string{"alice", "bob"},
+			wantTruncated: true,
+		},
+		{
+			name: "Numeric Filter",
+			input: map[string]any{"csv": testCSV, "where": []any{
+				map[string]any{"column": "age", "op": "gte", "value": "30"},
+			}},
+			wantColumns: []string{"name", "city", "age"},
+			wantNames:   []string{"alice", "carol"},
+		},
+		{
+			name: "Combined Filters",
+			input: map[string]any{"csv": testCSV, "columns": []any{"name"}, "where": []any{
+				map[string]any{"column": "city", "op": "eq", "value": "Paris"},
+				map[string]any{"column": "age", "op": "lt", "value": float64(40)},
+			}},
+			wantColumns: []string{"name"},
+			wantNames:   []string{"alice"},
+		},
+		{
+			name:    "Unknown Column",
+			input:   map[string]any{"csv": testCSV, "columns": []any{"email"}},
+			wantErr: true,
+		},
+		{
+			name:    "Missing Source",
+			input:   map[string]any{},
+			wantErr: true,
+		},
+		{
+			name:    "Relative Path",
+			input:   map[string]any{"path": "people.csv"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := cq.Execute(ctx, tt.input, tc)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			res := got.(map[string]any)
+			if cols := res["columns"].([]string); !reflect.DeepEqual(cols, tt.wantColumns) {
+				t.Errorf("columns = %v, want %v", cols, tt.wantColumns)
+			}
+			rows := res["rows"].([]map[string]string)
+			var names []string
+			for _, row := range rows {
+				if len(row) != len(tt.wantColumns) {
+					t.Errorf("row %v has %d fields, want %d", row, len(row), len(tt.wantColumns))
+				}
+				names = append(names, row["name"])
+			}
+			if !reflect.DeepEqual(names, tt.wantNames) {
+				t.Errorf("rows = %v, want names %v", rows, tt.wantNames)
+			}
+			if res["truncated"] != tt.wantTruncated {
+				t.Errorf("truncated = %v, want %v", res["truncated"], tt.wantTruncated)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"giai/pkg/tool"
+)
+
+// DeleteFile removes a file (or, with recursive, a directory tree).
+// It always requires approval given its destructiveness.
+type DeleteFile struct {
+	tool.BaseTool
+	Root string // Optional: restrict deletions to this directory
+}
+
+func NewDeleteFile() *DeleteFile {
+	t := &DeleteFile{
+		BaseTool: tool.NewBaseTool(
+			"delete_file",
+			"Delete a file. Directories are only removed when recursive is set. Requires approval.",
+		),
+	}
+
+	t.RequiresApprovalVal = true
+	t.RetryPolicyVal = nil
+
+	t.SchemaVal = map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"path": map[string]any{
+				"type":        "string",
+				"description": "The absolute path to delete.",
+			},
+			"recursive": map[string]any{
+				"type":        "boolean",
+				"description": "Allow deleting a directory and everything under it.",
+			},
+		},
+		"required": []string{"path"},
+	}
+
+	return t
+}
+
+func (t *DeleteFile) Execute(ctx context.Context, input map[string]any, tc *tool.ToolContext) (any, error) {
+	pathIn, ok := input["path"].(string)
+	if !ok {
+		return nil, fmt.Errorf("path must be a string")
+	}
+	recursive, _ := input["recursive"].(bool)
+
+	path, err := safePath(pathIn, t.Root)
+	if err != nil {
+		return nil, err
+	}
+	if path == "/" || (t.Root != "" && path == filepath.Clean(t.Root)) {
+		return nil, fmt.Errorf("refusing to delete %s", path)
+	}
+
+	info, err := os.Lstat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat path: %w", err)
+	}
+
+	if info.IsDir() {
+		if !recursive {
+			return nil, fmt.Errorf("%s is a directory (set recursive to delete it)", path)
+		}
+		err = os.RemoveAll(path)
+	} else {
+		err = os.Remove(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete: %w", err)
+	}
+
+	return fmt.Sprintf("Deleted %s", path), nil
+}
@@ -0,0 +1,114 @@
+package builtin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"giai/pkg/tool"
+)
+
+func TestDeleteFile_Execute(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "delete_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	file := filepath.Join(tmpDir, "a.txt")
+	dir := filepath.Join(tmpDir, "sub")
+	createFile(t, file)
+	os.Mkdir(dir, 0755)
+	createFile(t, filepath.Join(dir, "b.txt"))
+
+	del := NewDeleteFile()
+	ctx := context.Background()
+	tc := tool.NewToolContext()
+
+	if _, err := del.Execute(ctx, map[string]any{"path": file}, tc); err != nil {
+		t.Fatalf("Execute() delete file error = %v", err)
+	}
+	if _, err := os.Stat(file); !os.IsNotExist(err) {
+		t.Error("file still exists after delete")
+	}
+
+	if _, err := del.Execute(ctx, map[string]any{"path": dir}, tc); err == nil {
+		t.Error("Execute() expected error deleting directory without recursive")
+	}
+
+	if _, err := del.Execute(ctx, map[string]any{"path": dir, "recursive": true}, tc); err != nil {
+		t.Fatalf("Execute() recursive delete error = %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Error("directory still exists after recursive delete")
+	}
+}
+
+func TestDeleteFile_RequiresApproval(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "delete_approval_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	file := filepath.Join(tmpDir, "a.txt")
+	createFile(t, file)
+
+	del := NewDeleteFile()
+	if !del.RequiresApproval() {
+		t.Fatal("RequiresApproval() = false, want true")
+	}
+
+	exec := tool.NewExecutor(tool.ExecutorConfig{})
+	ctx := context.Background()
+
+	res := exec.Execute(ctx, &tool.ExecuteRequest{
+		Tool:    del,
+		Input:   map[string]any{"path": file},
+		Context: tool.NewToolContext(),
+	})
+	if res.Success {
+		t.Fatal("Execute() succeeded without approval")
+	}
+	if _, err := os.Stat(file); err != nil {
+		t.Errorf("file was deleted without approval: %v", err)
+	}
+
+	approved := tool.NewToolContext()
+	approved.Metadata[tool.MetadataApprovedCalls] = []string{"call_1"}
+	res = exec.Execute(ctx, &tool.ExecuteRequest{
+		Tool:    del,
+		Input:   map[string]any{"path": file},
+		Context: approved,
+		CallID:  "call_2",
+	})
+	if res.Success {
+		t.Fatal("Execute() succeeded for a call that was not approved")
+	}
+	res = exec.Execute(ctx, &tool.ExecuteRequest{
+		Tool:    del,
+		Input:   map[string]any{"path": file},
+		Context: approved,
+		CallID:  "call_1",
+	})
+	if !res.Success {
+		t.Fatalf("Execute() with approval error = %v", res.Error)
+	}
+	if _, err := os.Stat(file); !os.IsNotExist(err) {
+		t.Error("file still exists after approved delete")
+	}
+}
+
+func TestDeleteFile_RefusesRoot(t *testing.T) {
+	tmpDir := t.TempDir()
+	del := NewDeleteFile()
+	del.Root = tmpDir + string(filepath.Separator)
+
+	if _, err := del.Execute(context.Background(), map[string]any{"path": tmpDir, "recursive": true}, tool.NewToolContext()); err == nil {
+		t.Error("Execute() expected error deleting the root itself")
+	}
+	if _, err := os.Stat(tmpDir); err != nil {
+		t.Errorf("root was deleted: %v", err)
+	}
+}
@@ -0,0 +1,223 @@
+package builtin
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"giai/pkg/tool"
+)
+
+const (
+	defaultFeedLimit  = 10
+	maxFeedLimit      = 50
+	maxFeedSummaryLen = 500
+)
+
+// FetchFeed downloads an RSS or Atom feed and returns its most recent items.
+type FetchFeed struct {
+	tool.BaseTool
+	// AllowPrivateNetworks permits feeds on loopback and private addresses,
+	// which are refused by default to prevent server-side request forgery.
+	AllowPrivateNetworks bool
+}
+
+func NewFetchFeed() *FetchFeed {
+	t := &FetchFeed{
+		BaseTool: tool.NewBaseTool(
+			"fetch_feed",
+			"Fetch an RSS or Atom feed and return its most recent items (title, link, published date, summary).",
+		),
+	}
+
+	t.SchemaVal = map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"url": map[string]any{
+				"type":        "string",
+				"description": "The http(s) URL of the feed.",
+			},
+			"limit": map[string]any{
+				"type":        "integer",
+				"description": fmt.Sprintf("Maximum number of items to return, newest first (optional, default %d, at most %d).", defaultFeedLimit, maxFeedLimit),
+			},
+		},
+		"required": []string{"url"},
+	}
+
+	return t
+}
+
+// feedDoc covers RSS 2.0 (<rss><channel><item>), RSS 1.0 (<rdf:RDF><item>,
+// with items beside the channel) and Atom (<feed><entry>).
+type feedDoc struct {
+	XMLName xml.Name
+	Channel struct {
+		Title string    `xml:"title"`
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+	Items   []rssItem   `xml:"item"`
+	Title   string      `xml:"title"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type rssItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	PubDate     string `xml:"pubDate"`
+	Date        string `xml:"http://purl.org/dc/elements/1.1/ date"`
+	Description string `xml:"description"`
+}
+
+type atomEntry struct {
+	Title string `xml:"title"`
+	Links []struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr"`
+	} `xml:"link"`
+	Published string `xml:"published"`
+	Updated   string `xml:"updated"`
+	Summary   string `xml:"summary"`
+	Content   string `xml:"content"`
+}
+
+type feedItem struct {
+	title, link, summary string
+	published            string
+	at                   time.Time // Zero when the date did not parse
+}
+
+func (t *FetchFeed) Execute(ctx context.Context, input map[string]any, tc *tool.ToolContext) (any, error) {
+	rawURL, ok := input["url"].(string)
+	if !ok || rawURL == "" {
+		return nil, fmt.Errorf("url must be a non-empty string")
+	}
+	limit := defaultFeedLimit
+	if v, ok := normalizeInt(input["limit"]); ok && v > 0 {
+		limit = min(v, maxFeedLimit)
+	}
+
+	body, err := fetch(ctx, t.client(), rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc feedDoc
+	if err := xml.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse feed: %w", err)
+	}
+
+	var title string
+	var items []feedItem
+	switch doc.XMLName.Local {
+	case "rss", "RDF":
+		title = doc.Channel.Title
+		for _, it := range append(doc.Channel.Items, doc.Items...) {
+			date := it.PubDate
+			if date == "" {
+				date = it.Date
+			}
+			items = append(items, newFeedItem(it.Title, strings.TrimSpace(it.Link), date, it.Description))
+		}
+	case "feed":
+		title = doc.Title
+		for _, e := range doc.Entries {
+			date := e.Published
+			if date == "" {
+				date = e.Updated
+			}
+			summary := e.Summary
+			if summary == "" {
+				summary = e.Content
+			}
+			items = append(items, newFeedItem(e.Title, atomLink(e), date, summary))
+		}
+	default:
+		return nil, fmt.Errorf("not an RSS or Atom feed (root element <%s>)", doc.XMLName.Local)
+	}
+
+	// Newest first; undated items keep their feed order after dated ones.
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].at.After(items[j].at)
+	})
+	if len(items) > limit {
+		items = items[:limit]
+	}
+
+	out := make([]map[string]any, len(items))
+	for i, it := range items {
+		out[i] = map[string]any{
+			"title":     it.title,
+			"link":      it.link,
+			"published": it.published,
+			"summary":   it.summary,
+		}
+	}
+	return map[string]any{
+		"title": strings.TrimSpace(title),
+		"items": out,
+	}, nil
+}
+
+func (t *FetchFeed) client() *http.Client {
+	return newFetchClient(t.AllowPrivateNetworks)
+}
+
+func newFeedItem(title, link, date, summary string) feedItem {
+	it := feedItem{
+		title:     strings.TrimSpace(html.UnescapeString(title)),
+		link:      link,
+		published: strings.TrimSpace(date),
+		summary:   plainText(summary, maxFeedSummaryLen),
+	}
+	if at, ok := parseFeedDate(it.published); ok {
+		it.at = at
+		it.published = at.UTC().Format(time.RFC3339)
+	}
+	return it
+}
+
+// atomLink prefers the alternate link of an Atom entry.
+func atomLink(e atomEntry) string {
+	for _, l := range e.Links {
+		if l.Rel == "" || l.Rel == "alternate" {
+			return l.Href
+		}
+	}
+	if len(e.Links) > 0 {
+		return e.Links[0].Href
+	}
+	return ""
+}
+
+var feedDateLayouts = []string{
+	time.RFC1123Z, time.RFC1123, time.RFC3339, time.RFC822Z, time.RFC822,
+	"Mon, 2 Jan 2006 15:04:05 -0700", "Mon, 2 Jan 2006 15:04:05 MST", "2006-01-02",
+}
+
+func parseFeedDate(s string) (time.Time, bool) {
+	for _, layout := range feedDateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+var htmlTag = regexp.MustCompile(`<[^>]*>`)
+
+// plainText strips HTML tags, collapses whitespace and truncates to max runes.
+func plainText(s string, max int) string {
+	s = html.UnescapeString(htmlTag.ReplaceAllString(s, " "))
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > max {
+		s = string(r[:max]) + "..."
+	}
+	return s
+}
@@ -0,0 +1,189 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"giai/pkg/tool"
+)
+
+const testRSS = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0">
+  <channel>
+    <title>Release Notes</title>
+    <item>
+      <title>v1.0</title>
+      <link>https://example.com/v1.0</link>
+      <pubDate>Mon, 06 Jan 2025 10:00:00 +0000</pubDate>
+      <description>First &lt;b&gt;stable&lt;/b&gt; release.</description>
+    </item>
+    <item>
+      <title>v1.2</title>
+      <link>https://example.com/v1.2</link>
+      <pubDate>Wed, 05 Mar 2025 10:00:00 +0000</pubDate>
+      <description>Adds feeds.</description>
+    </item>
+    <item>
+      <title>v1.1</title>
+      <link>https://example.com/v1.1</link>
+      <pubDate>Sat, 01 Feb 2025 10:00:00 +0000</pubDate>
+      <description>Bug fixes.</description>
+    </item>
+  </channel>
+</rss>`
+
+const testAtom = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Blog</title>
+  <entry>
+    <title>Hello</title>
+    <link rel="alternate" href="https://example.com/hello"/>
+    <updated>2025-03-01T12:00:00Z</updated>
+    <summary>First post.</summary>
+  </entry>
+</feed>`
+
+const testRDF = `<?xml version="1.0" encoding="UTF-8"?>
+<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/" xmlns:dc="http://purl.org/dc/elements/1.1/">
+  <channel rdf:about="https://example.com/">
+    <title>Changelog</title>
+    <items>
+      <rdf:Seq>
+        <rdf:li rdf:resource="https://example.com/a"/>
+        <rdf:li rdf:resource="https://example.com/b"/>
+      </rdf:Seq>
+    </items>
+  </channel>
+  <item rdf:about="https://example.com/a">
+    <title>Older</title>
+    <link>https://example.com/a</link>
+    <dc:date>2025-01-10T09:00:00Z</dc:date>
+  </item>
+  <item rdf:about="https://example.com/b">
+    <title>Newer</title>
+    <link>https://example.com/b</link>
+    <dc:date>2025-02-10T09:00:00Z</dc:date>
+  </item>
+</rdf:RDF>`
+
+func TestFetchFeed_Execute(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rss":
+			w.Header().Set("Content-Type", "application/rss+xml")
+			fmt.Fprint(w, testRSS)
+		case "/rdf":
+			w.Header().Set("Content-Type", "application/rdf+xml")
+			fmt.Fprint(w, testRDF)
+		case "/atom":
+			w.Header().Set("Content-Type", "application/atom+xml")
+			fmt.Fprint(w, testAtom)
+		case "/html":
+			fmt.Fprint(w, "<html><body>not a feed</body></html>")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	ff := NewFetchFeed()
+	ff.AllowPrivateNetworks = true
+	ctx := context.Background()
+	tc := tool.NewToolContext()
+
+	tests := []struct {
+		name      string
+		input     map[string]any
+		wantTitle string
+		wantItems []string
+		wantErr   bool
+	}{
+		{
+			name:      "RSS Newest First",
+			input:     map[string]any{"url": srv.URL + "/rss"},
+			wantTitle: "Release Notes",
+			wantItems: []string{"v1.2", "v1.1", "v1.0"},
+		},
+		{
+			name:      "RSS Limit",
+			input:     map[string]any{"url": srv.URL + "/rss", "limit": float64(2)},
+			wantTitle: "Release Notes",
+			wantItems: []string{"v1.2", "v1.1"},
+		},
+		{
+			name:      "RDF",
+			input:     map[string]any{"url": srv.URL + "/rdf"},
+			wantTitle: "Changelog",
+			wantItems: []string{"Newer", "Older"},
+		},
+		{
+			name:      "Atom",
+			input:     map[string]any{"url": srv.URL + "/atom"},
+			wantTitle: "Blog",
+			wantItems: []string{"Hello"},
+		},
+		{name: "Not A Feed", input: map[string]any{"url": srv.URL + "/html"}, wantErr: true},
+		{name: "Not Found", input: map[string]any{"url": srv.URL + "/missing"}, wantErr: true},
+		{name: "Bad Scheme", input: map[string]any{"url": "file:///etc/passwd"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ff.Execute(ctx, tt.input, tc)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			res := got.(map[string]any)
+			if res["title"] != tt.wantTitle {
+				t.Errorf("title = %q, want %q", res["title"], tt.wantTitle)
+			}
+			items := res["items"].([]map[string]any)
+			if len(items) != len(tt.wantItems) {
+				t.Fatalf("got %d items %v, want %v", len(items), items, tt.wantItems)
+			}
+			for i, want := range tt.wantItems {
+				if items[i]["title"] != want {
+					t.Errorf("item %d title = %q, want %q", i, items[i]["title"], want)
+				}
+			}
+		})
+	}
+}
+
+func TestFetchFeed_Details(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, testRSS)
+	}))
+	defer srv.Close()
+
+	ff := NewFetchFeed()
+	ff.AllowPrivateNetworks = true
+	got, err := ff.Execute(context.Background(), map[string]any{"url": srv.URL, "limit": float64(10)}, tool.NewToolContext())
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	oldest := got.(map[string]any)["items"].([]map[string]any)[2]
+	if oldest["link"] != "https://example.com/v1.0" || oldest["published"] != "2025-01-06T10:00:00Z" {
+		t.Errorf("item = %v, want link and RFC 3339 date", oldest)
+	}
+	if oldest["summary"] != "First stable release." {
+		t.Errorf("summary = %q, want HTML stripped", oldest["summary"])
+	}
+}
+
+func TestFetchFeed_BlocksPrivateNetworks(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, testRSS)
+	}))
+	defer srv.Close()
+
+	if _, err := NewFetchFeed().Execute(context.Background(), map[string]any{"url": srv.URL}, tool.NewToolContext()); err == nil {
+		t.Error("Execute() expected error fetching a loopback address")
+	}
+}
@@ -0,0 +1,117 @@
+package builtin
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"time"
+
+	"giai/pkg/tool"
+)
+
+type FileInfo struct {
+	tool.BaseTool
+	Root string // Optional: restrict access to this directory
+}
+
+func NewFileInfo() *FileInfo {
+	t := &FileInfo{
+		BaseTool: tool.NewBaseTool(
+			"file_info",
+			"Get a file's size, modification time and mode, and optionally its md5 or sha256 checksum.",
+		),
+	}
+
+	t.SchemaVal = map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"path": map[string]any{
+				"type":        "string",
+				"description": "The absolute path to the file.",
+			},
+			"hash": map[string]any{
+				"type":        "string",
+				"enum":        []string{"md5", "sha256"},
+				"description": "Checksum algorithm to compute (optional).",
+			},
+		},
+		"required": []string{"path"},
+	}
+
+	return t
+}
+
+func (t *FileInfo) Execute(ctx context.Context, input map[string]any, tc *tool.ToolContext) (any, error) {
+	path, ok := input["path"].(string)
+	if !ok {
+		return nil, fmt.Errorf("path must be a string")
+	}
+
+	path, err := safePath(path, t.Root)
+	if err != nil {
+		return nil, err
+	}
+
+	var h hash.Hash
+	algo, _ := input["hash"].(string)
+	switch algo {
+	case "":
+	case "md5":
+		h = md5.New()
+	case "sha256":
+		h = sha256.New()
+	default:
+		return nil, fmt.Errorf("unsupported hash %q: use md5 or sha256", algo)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	result := map[string]any{
+		"path":     path,
+		"size":     info.Size(),
+		"mod_time": info.ModTime().UTC().Format(time.RFC3339),
+		"mode":     info.Mode().String(),
+		"is_dir":   info.IsDir(),
+	}
+
+	if h != nil {
+		if info.IsDir() {
+			return nil, fmt.Errorf("cannot hash a directory: %s", path)
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open file: %w", err)
+		}
+		defer f.Close()
+
+		// Stream the file so large files are never held in memory.
+		if _, err := io.Copy(h, &ctxReader{ctx: ctx, r: f}); err != nil {
+			return nil, fmt.Errorf("failed to hash file: %w", err)
+		}
+		result["hash"] = hex.EncodeToString(h.Sum(nil))
+		result["hash_algorithm"] = algo
+	}
+
+	return result, nil
+}
+
+// ctxReader aborts reads once ctx is done, so long hashes respect the tool timeout.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c *ctxReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
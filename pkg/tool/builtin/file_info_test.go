@@ -0,0 +1,83 @@
+package builtin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"giai/pkg/tool"
+)
+
+func TestFileInfo_Execute(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "file_info_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	path := filepath.Join(tmpDir, "hello.txt")
+	if err := os.WriteFile(path, []byte("hello world"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	fi := NewFileInfo()
+	ctx := context.Background()
+	tc := tool.NewToolContext()
+
+	tests := []struct {
+		name     string
+		input    map[string]any
+		wantHash string
+		wantErr  bool
+	}{
+		{
+			name:  "Metadata Only",
+			input: map[string]any{"path": path},
+		},
+		{
+			name:     "MD5",
+			input:    map[string]any{"path": path, "hash": "md5"},
+			wantHash: "5eb63bbbe01eeed093cb22bb8f5acdc3",
+		},
+		{
+			name:     "SHA256",
+			input:    map[string]any{"path": path, "hash": "sha256"},
+			wantHash: "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9",
+		},
+		{
+			name:    "Unsupported Hash",
+			input:   map[string]any{"path": path, "hash": "crc32"},
+			wantErr: true,
+		},
+		{
+			name:    "Relative Path",
+			input:   map[string]any{"path": "hello.txt"},
+			wantErr: true,
+		},
+		{
+			name:    "Missing File",
+			input:   map[string]any{"path": filepath.Join(tmpDir, "missing.txt")},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := fi.Execute(ctx, tt.input, tc)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			res := got.(map[string]any)
+			if res["size"] != int64(11) {
+				t.Errorf("size = %v, want 11", res["size"])
+			}
+			if hash, _ := res["hash"].(string); hash != tt.wantHash {
+				t.Errorf("hash = %q, want %q", hash, tt.wantHash)
+			}
+		})
+	}
+}
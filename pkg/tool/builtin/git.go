@@ -0,0 +1,228 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"giai/pkg/tool"
+)
+
+const maxDiffChars = 50000
+
+// ErrNotGitRepo is returned when the configured directory is not inside a git work tree.
+var ErrNotGitRepo = errors.New("not a git repository")
+
+// runGit runs git in dir and returns stdout. A missing repository maps to ErrNotGitRepo.
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if strings.Contains(strings.ToLower(msg), "not a git repository") {
+			return "", fmt.Errorf("%w: %s", ErrNotGitRepo, dir)
+		}
+		return "", fmt.Errorf("git %s failed: %v: %s", args[0], err, msg)
+	}
+	return stdout.String(), nil
+}
+
+// gitToolFactory builds a git tool from config key "work_dir" (the repository path).
+func gitToolFactory(build func(workDir string) tool.Tool) tool.ToolFactory {
+	return func(config map[string]any) (tool.Tool, error) {
+		dir, _ := config["work_dir"].(string)
+		if dir == "" {
+			return nil, fmt.Errorf("config \"work_dir\" is required")
+		}
+		return build(dir), nil
+	}
+}
+
+type GitStatus struct {
+	tool.BaseTool
+	WorkDir string // Repository the tool operates on
+}
+
+func NewGitStatus(workDir string) *GitStatus {
+	t := &GitStatus{
+		BaseTool: tool.NewBaseTool(
+			"git_status",
+			"Show the current branch and the staged, unstaged and untracked files of the git repository.",
+		),
+		WorkDir: workDir,
+	}
+
+	t.SchemaVal = map[string]any{
+		"type":       "object",
+		"properties": map[string]any{},
+	}
+
+	return t
+}
+
+// NewGitStatusFactory returns a factory configured with "work_dir".
+func NewGitStatusFactory() tool.ToolFactory {
+	return gitToolFactory(func(dir string) tool.Tool { return NewGitStatus(dir) })
+}
+
+func (t *GitStatus) Execute(ctx context.Context, input map[string]any, tc *tool.ToolContext) (any, error) {
+	out, err := runGit(ctx, t.WorkDir, "status", "--porcelain=v1", "-z", "--branch")
+	if err != nil {
+		return nil, err
+	}
+	return parseGitStatus(out), nil
+}
+
+// parseGitStatus parses `git status --porcelain=v1 -z --branch` output.
+func parseGitStatus(out string) map[string]any {
+	var (
+		branch    string
+		staged    = []map[string]string{}
+		changed   = []map[string]string{}
+		untracked = []string{}
+	)
+
+	entries := strings.Split(out, "\x00")
+	for i := 0; i < len(entries); i++ {
+		e := entries[i]
+		if len(e) < 3 {
+			continue
+		}
+		if strings.HasPrefix(e, "## ") {
+			branch = parseBranchHeader(e[3:])
+			continue
+		}
+
+		x, y, path := e[0], e[1], e[3:]
+		if x == 'R' || x == 'C' {
+			i++ // Renames and copies are followed by the original path.
+		}
+
+		switch {
+		case x == '?' && y == '?':
+			untracked = append(untracked, path)
+			continue
+		case x == '!' && y == '!':
+			continue
+		}
+		if x != ' ' {
+			staged = append(staged, map[string]string{"path": path, "status": statusName(x)})
+		}
+		if y != ' ' {
+			changed = append(changed, map[string]string{"path": path, "status": statusName(y)})
+		}
+	}
+
+	return map[string]any{
+		"branch":    branch,
+		"staged":    staged,
+		"changed":   changed,
+		"untracked": untracked,
+		"clean":     len(staged) == 0 && len(changed) == 0 && len(untracked) == 0,
+	}
+}
+
+// parseBranchHeader extracts the branch from "main...origin/main [ahead 1]"
+// or "No commits yet on main".
+func parseBranchHeader(h string) string {
+	if rest, ok := strings.CutPrefix(h, "No commits yet on "); ok {
+		return rest
+	}
+	if i := strings.Index(h, "..."); i >= 0 {
+		return h[:i]
+	}
+	if i := strings.IndexByte(h, ' '); i >= 0 {
+		return h[:i]
+	}
+	return h
+}
+
+func statusName(c byte) string {
+	switch c {
+	case 'M':
+		return "modified"
+	case 'A':
+		return "added"
+	case 'D':
+		return "deleted"
+	case 'R':
+		return "renamed"
+	case 'C':
+		return "copied"
+	case 'T':
+		return "type_changed"
+	case 'U':
+		return "unmerged"
+	}
+	return string(c)
+}
+
+type GitDiff struct {
+	tool.BaseTool
+	WorkDir string // Repository the tool operates on
+}
+
+func NewGitDiff(workDir string) *GitDiff {
+	t := &GitDiff{
+		BaseTool: tool.NewBaseTool(
+			"git_diff",
+			"Show the git diff of the working tree (or of staged changes) for a path or the whole repository.",
+		),
+		WorkDir: workDir,
+	}
+
+	t.SchemaVal = map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"path": map[string]any{
+				"type":        "string",
+				"description": "File or directory to diff, relative to the repository root (optional).",
+			},
+			"staged": map[string]any{
+				"type":        "boolean",
+				"description": "Diff staged changes instead of unstaged ones (optional).",
+			},
+		},
+	}
+
+	return t
+}
+
+// NewGitDiffFactory returns a factory configured with "work_dir".
+func NewGitDiffFactory() tool.ToolFactory {
+	return gitToolFactory(func(dir string) tool.Tool { return NewGitDiff(dir) })
+}
+
+func (t *GitDiff) Execute(ctx context.Context, input map[string]any, tc *tool.ToolContext) (any, error) {
+	args := []string{"diff", "--no-color"}
+	if staged, _ := input["staged"].(bool); staged {
+		args = append(args, "--cached")
+	}
+	if path, _ := input["path"].(string); path != "" {
+		args = append(args, "--", path)
+	}
+
+	diff, err := runGit(ctx, t.WorkDir, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	truncated := false
+	if len(diff) > maxDiffChars {
+		diff = diff[:maxDiffChars] + fmt.Sprintf("\n... (truncated, %d chars omitted)", len(diff)-maxDiffChars)
+		truncated = true
+	}
+
+	return map[string]any{
+		"diff":      diff,
+		"truncated": truncated,
+	}, nil
+}
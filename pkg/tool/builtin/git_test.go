@@ -0,0 +1,114 @@
+package builtin
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"giai/pkg/tool"
+)
+
+// initGitRepo creates a repository with one committed file.
+func initGitRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found, skipping test")
+	}
+
+	dir, err := os.MkdirTemp("", "git_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	git("init", "-q", "-b", "main")
+	os.WriteFile(filepath.Join(dir, "tracked.txt"), []byte("one\n"), 0644)
+	git("add", "tracked.txt")
+	git("commit", "-q", "-m", "initial")
+	return dir
+}
+
+func TestGitStatus_Execute(t *testing.T) {
+	dir := initGitRepo(t)
+	ctx := context.Background()
+	tc := tool.NewToolContext()
+
+	gs := NewGitStatus(dir)
+	got, err := gs.Execute(ctx, map[string]any{}, tc)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if res := got.(map[string]any); res["clean"] != true || res["branch"] != "main" {
+		t.Errorf("Execute() = %v, want clean repo on main", res)
+	}
+
+	os.WriteFile(filepath.Join(dir, "tracked.txt"), []byte("two\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "staged.txt"), []byte("new\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "untracked.txt"), []byte("?\n"), 0644)
+	add := exec.Command("git", "add", "staged.txt")
+	add.Dir = dir
+	if err := add.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err = gs.Execute(ctx, map[string]any{}, tc)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	res := got.(map[string]any)
+	staged := res["staged"].([]map[string]string)
+	changed := res["changed"].([]map[string]string)
+	untracked := res["untracked"].([]string)
+
+	if len(staged) != 1 || staged[0]["path"] != "staged.txt" || staged[0]["status"] != "added" {
+		t.Errorf("staged = %v, want [staged.txt added]", staged)
+	}
+	if len(changed) != 1 || changed[0]["path"] != "tracked.txt" || changed[0]["status"] != "modified" {
+		t.Errorf("changed = %v, want [tracked.txt modified]", changed)
+	}
+	if len(untracked) != 1 || untracked[0] != "untracked.txt" {
+		t.Errorf("untracked = %v, want [untracked.txt]", untracked)
+	}
+}
+
+func TestGitDiff_Execute(t *testing.T) {
+	dir := initGitRepo(t)
+	os.WriteFile(filepath.Join(dir, "tracked.txt"), []byte("two\n"), 0644)
+
+	got, err := NewGitDiff(dir).Execute(context.Background(), map[string]any{"path": "tracked.txt"}, tool.NewToolContext())
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	diff := got.(map[string]any)["diff"].(string)
+	if !strings.Contains(diff, "-one") || !strings.Contains(diff, "+two") {
+		t.Errorf("diff = %q, want change from one to two", diff)
+	}
+}
+
+func TestGit_NotARepo(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found, skipping test")
+	}
+	dir, err := os.MkdirTemp("", "git_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	t.Setenv("GIT_CEILING_DIRECTORIES", filepath.Dir(dir))
+
+	_, err = NewGitStatus(dir).Execute(context.Background(), map[string]any{}, tool.NewToolContext())
+	if !errors.Is(err, ErrNotGitRepo) {
+		t.Errorf("Execute() error = %v, want ErrNotGitRepo", err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"time"
 
 	"giai/pkg/tool"
@@ -108,6 +109,11 @@ func (t *Glob) Execute(ctx context.Context, input map[string]any, tc *tool.ToolC
 		}
 	}
 
+	// Overlapping alternatives such as {*.go,main.*} can match a file twice,
+	// and doublestar does not promise an order; sort and dedup for stable output.
+	slices.Sort(finalMatches)
+	finalMatches = slices.Compact(finalMatches)
+
 	// Limit results to avoid context overflow
 	const maxResults = 1000
 	result := &GlobResult{
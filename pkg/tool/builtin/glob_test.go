@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 
 	"giai/pkg/tool"
@@ -101,3 +102,37 @@ func createFile(t *testing.T, path string) {
 		t.Fatal(err)
 	}
 }
+
+func TestGlob_SortedAndDeduped(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "glob_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	for _, name := range []string{"main.go", "b.go", "a.go", "main.txt"} {
+		createFile(t, filepath.Join(tmpDir, name))
+	}
+
+	// main.go matches both alternatives.
+	out, err := NewGlob().Execute(context.Background(), map[string]any{
+		"pattern":  "{main.*,*.go}",
+		"root_dir": tmpDir,
+	}, tool.NewToolContext())
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	res := out.(*GlobResult)
+
+	abs, _ := filepath.Abs(tmpDir)
+	var want []string
+	for _, name := range []string{"a.go", "b.go", "main.go", "main.txt"} {
+		want = append(want, filepath.Join(abs, name))
+	}
+	if !reflect.DeepEqual(res.Matches, want) {
+		t.Errorf("Matches = %v, want %v", res.Matches, want)
+	}
+	if res.TotalMatches != len(want) {
+		t.Errorf("TotalMatches = %d, want %d", res.TotalMatches, len(want))
+	}
+}
@@ -0,0 +1,82 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"giai/pkg/tool"
+)
+
+// MoveFile renames or moves a file within the file system.
+type MoveFile struct {
+	tool.BaseTool
+	Root string // Optional: restrict both paths to this directory
+}
+
+func NewMoveFile() *MoveFile {
+	t := &MoveFile{
+		BaseTool: tool.NewBaseTool(
+			"move_file",
+			"Move or rename a file. Refuses to overwrite an existing destination unless force is set.",
+		),
+	}
+
+	// Moving is not idempotent; a retry after a partial success would fail confusingly.
+	t.RetryPolicyVal = nil
+
+	t.SchemaVal = map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"src": map[string]any{
+				"type":        "string",
+				"description": "The absolute path of the file to move.",
+			},
+			"dest": map[string]any{
+				"type":        "string",
+				"description": "The absolute destination path.",
+			},
+			"force": map[string]any{
+				"type":        "boolean",
+				"description": "Overwrite the destination if it already exists.",
+			},
+		},
+		"required": []string{"src", "dest"},
+	}
+
+	return t
+}
+
+func (t *MoveFile) Execute(ctx context.Context, input map[string]any, tc *tool.ToolContext) (any, error) {
+	srcIn, ok := input["src"].(string)
+	if !ok {
+		return nil, fmt.Errorf("src must be a string")
+	}
+	destIn, ok := input["dest"].(string)
+	if !ok {
+		return nil, fmt.Errorf("dest must be a string")
+	}
+	force, _ := input["force"].(bool)
+
+	src, err := safePath(srcIn, t.Root)
+	if err != nil {
+		return nil, err
+	}
+	dest, err := safePath(destIn, t.Root)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Stat(src); err != nil {
+		return nil, fmt.Errorf("failed to stat source: %w", err)
+	}
+	if _, err := os.Stat(dest); err == nil && !force {
+		return nil, fmt.Errorf("destination already exists: %s (set force to overwrite)", dest)
+	}
+
+	if err := os.Rename(src, dest); err != nil {
+		return nil, fmt.Errorf("failed to move file: %w", err)
+	}
+
+	return fmt.Sprintf("Moved %s to %s", src, dest), nil
+}
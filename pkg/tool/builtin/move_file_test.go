@@ -0,0 +1,75 @@
+package builtin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"giai/pkg/tool"
+)
+
+func TestMoveFile_Execute(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "move_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	src := filepath.Join(tmpDir, "a.txt")
+	dest := filepath.Join(tmpDir, "b.txt")
+	existing := filepath.Join(tmpDir, "c.txt")
+	createFile(t, src)
+	createFile(t, existing)
+
+	mv := NewMoveFile()
+	ctx := context.Background()
+	tc := tool.NewToolContext()
+
+	if _, err := mv.Execute(ctx, map[string]any{"src": src, "dest": dest}, tc); err != nil {
+		t.Fatalf("Execute() move error = %v", err)
+	}
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Errorf("source still exists after move")
+	}
+	if _, err := os.Stat(dest); err != nil {
+		t.Errorf("destination missing after move: %v", err)
+	}
+
+	// Overwrite protection
+	if _, err := mv.Execute(ctx, map[string]any{"src": dest, "dest": existing}, tc); err == nil {
+		t.Error("Execute() expected error when destination exists")
+	}
+	if _, err := os.Stat(dest); err != nil {
+		t.Errorf("source was touched despite overwrite protection: %v", err)
+	}
+
+	// Forced overwrite
+	if _, err := mv.Execute(ctx, map[string]any{"src": dest, "dest": existing, "force": true}, tc); err != nil {
+		t.Fatalf("Execute() forced move error = %v", err)
+	}
+
+	// Relative paths are rejected
+	if _, err := mv.Execute(ctx, map[string]any{"src": "a.txt", "dest": dest}, tc); err == nil {
+		t.Error("Execute() expected error for relative path")
+	}
+}
+
+func TestMoveFile_Root(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "move_root_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	src := filepath.Join(tmpDir, "a.txt")
+	createFile(t, src)
+
+	mv := NewMoveFile()
+	mv.Root = tmpDir
+
+	_, err = mv.Execute(context.Background(), map[string]any{"src": src, "dest": filepath.Join(tmpDir, "..", "escaped.txt")}, tool.NewToolContext())
+	if err == nil {
+		t.Error("Execute() expected error for destination outside root")
+	}
+}
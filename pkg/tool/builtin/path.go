@@ -0,0 +1,60 @@
+package builtin
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// safePath validates a user-supplied path: it must be absolute and, when root
+// is non-empty, resolve to a location inside root (a simple jail). Symlinks
+// are resolved before the check, so a link inside root cannot point out of it.
+// It returns the cleaned path.
+func safePath(path, root string) (string, error) {
+	if path == "" {
+		return "", fmt.Errorf("path is required")
+	}
+	if !filepath.IsAbs(path) {
+		return "", fmt.Errorf("path must be absolute: %s", path)
+	}
+	cleaned := filepath.Clean(path)
+
+	if root == "" {
+		return cleaned, nil
+	}
+	realRoot, err := resolveExisting(filepath.Clean(root))
+	if err != nil {
+		return "", fmt.Errorf("resolve root %s: %w", root, err)
+	}
+	realPath, err := resolveExisting(cleaned)
+	if err != nil {
+		return "", fmt.Errorf("resolve path %s: %w", path, err)
+	}
+	rel, err := filepath.Rel(realRoot, realPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %s is outside the allowed root %s", path, root)
+	}
+	return cleaned, nil
+}
+
+// resolveExisting evaluates the symlinks in the longest existing prefix of
+// path and appends the rest unchanged, so paths that do not exist yet (e.g. a
+// move destination) can still be checked.
+func resolveExisting(path string) (string, error) {
+	rest := ""
+	for p := path; ; p = filepath.Dir(p) {
+		resolved, err := filepath.EvalSymlinks(p)
+		if err == nil {
+			return filepath.Join(resolved, rest), nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+		if filepath.Dir(p) == p {
+			return path, nil
+		}
+		rest = filepath.Join(filepath.Base(p), rest)
+	}
+}
@@ -0,0 +1,44 @@
+package builtin
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSafePath(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	createFile(t, filepath.Join(root, "a.txt"))
+	if err := os.Symlink(outside, filepath.Join(root, "escape")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(root, "a.txt"), filepath.Join(root, "alias")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr bool
+	}{
+		{"Inside", filepath.Join(root, "a.txt"), false},
+		{"Not Yet Created", filepath.Join(root, "new", "b.txt"), false},
+		{"Symlink Inside", filepath.Join(root, "alias"), false},
+		{"Relative", "a.txt", true},
+		{"Dot Dot", filepath.Join(root, "..", "x"), true},
+		{"Symlink Escape", filepath.Join(root, "escape", "secret.txt"), true},
+		{"Outside", filepath.Join(outside, "x"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := safePath(tt.path, root)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("safePath(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			}
+			if err == nil && got != filepath.Clean(tt.path) {
+				t.Errorf("safePath(%q) = %q, want the cleaned path", tt.path, got)
+			}
+		})
+	}
+}
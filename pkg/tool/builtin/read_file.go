@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"path/filepath"
 	_ "strings"
 
 	"giai/pkg/tool"
@@ -12,6 +11,7 @@ import (
 
 type ReadFile struct {
 	tool.BaseTool
+	Root string // Optional: restrict reads to this directory
 }
 
 func NewReadFile() *ReadFile {
@@ -42,9 +42,9 @@ func (t *ReadFile) Execute(ctx context.Context, input map[string]any, tc *tool.T
 		return nil, fmt.Errorf("path must be a string")
 	}
 
-	// Basic security check (expand as needed)
-	if !filepath.IsAbs(path) {
-		return nil, fmt.Errorf("path must be absolute: %s", path)
+	path, err := safePath(path, t.Root)
+	if err != nil {
+		return nil, err
 	}
 
 	data, err := os.ReadFile(path)
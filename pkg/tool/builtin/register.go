@@ -5,10 +5,24 @@ import (
 )
 
 // RegisterAll registers all builtin tools to the provided registry.
-// It uses RegisterInstance for stateless tools.
+// It uses RegisterInstance for stateless tools and RegisterFactory for tools
+// that need per-instance configuration (e.g. a database handle or repository path).
 func RegisterAll(r *tool.Registry) {
 	r.RegisterInstance(NewReadFile())
 	r.RegisterInstance(NewBash())
 	r.RegisterInstance(NewGlob())
 	r.RegisterInstance(NewGrep())
+	r.RegisterInstance(NewCodeMap())
+	r.RegisterInstance(NewMoveFile())
+	r.RegisterInstance(NewDeleteFile())
+	r.RegisterInstance(NewFileInfo())
+	r.RegisterInstance(NewCountTokens())
+	r.RegisterInstance(NewCSVQuery())
+	r.RegisterInstance(NewValidateJSONSchema())
+	r.RegisterInstance(NewFetchFeed())
+	r.RegisterInstance(NewRenderTemplate())
+	r.RegisterFactory("sql_query", NewSQLQueryFactory())
+	r.RegisterFactory("git_status", NewGitStatusFactory())
+	r.RegisterFactory("git_diff", NewGitDiffFactory())
+	r.RegisterFactory("shell", NewShellFactory())
 }
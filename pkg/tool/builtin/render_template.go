@@ -0,0 +1,63 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+
+	"giai/pkg/prompt"
+	"giai/pkg/tool"
+)
+
+// RenderTemplate renders a template with the prompt engine's strict renderer,
+// so the model can produce parameterized output (config files, code)
+// deterministically instead of writing it token by token.
+type RenderTemplate struct {
+	tool.BaseTool
+}
+
+func NewRenderTemplate() *RenderTemplate {
+	t := &RenderTemplate{
+		BaseTool: tool.NewBaseTool(
+			"render_template",
+			"Render a template with variables. Syntax: {{name}} or {{a.b}} inserts a variable, "+
+				"{{#if x}}...{{else}}...{{/if}} is a conditional, {{#each list}}...{{/each}} loops with {{this}} and {{@index}}. "+
+				"Missing variables are an error.",
+		),
+	}
+
+	t.SchemaVal = map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"template": map[string]any{
+				"type":        "string",
+				"description": "The template text.",
+			},
+			"vars": map[string]any{
+				"type":        "object",
+				"description": "Variables referenced by the template (optional).",
+			},
+		},
+		"required": []string{"template"},
+	}
+
+	return t
+}
+
+func (t *RenderTemplate) Execute(ctx context.Context, input map[string]any, tc *tool.ToolContext) (any, error) {
+	text, ok := input["template"].(string)
+	if !ok {
+		return nil, fmt.Errorf("template must be a string")
+	}
+	vars := map[string]any{}
+	if v, ok := input["vars"]; ok && v != nil {
+		if vars, ok = v.(map[string]any); !ok {
+			return nil, fmt.Errorf("vars must be an object")
+		}
+	}
+
+	out, err := prompt.NewTemplate(text).RenderStrict(vars)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render template: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,76 @@
+package builtin
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"giai/pkg/tool"
+)
+
+func TestRenderTemplate_Execute(t *testing.T) {
+	rt := NewRenderTemplate()
+	ctx := context.Background()
+	tc := tool.NewToolContext()
+
+	config := "service: {{name}}\n" +
+		"{{#if tls}}tls: true\n{{else}}tls: false\n{{/if}}" +
+		"ports:\n{{#each ports}}  - {{this}}\n{{/each}}"
+
+	tests := []struct {
+		name    string
+		input   map[string]any
+		want    string
+		wantErr string
+	}{
+		{
+			name: "Loop And Conditional",
+			input: map[string]any{
+				"template": config,
+				"vars":     map[string]any{"name": "api", "tls": true, "ports": []any{80.0, 443.0}},
+			},
+			want: "service: api\ntls: true\nports:\n  - 80\n  - 443\n",
+		},
+		{
+			name: "Else Branch",
+			input: map[string]any{
+				"template": config,
+				"vars":     map[string]any{"name": "api", "ports": []any{}},
+			},
+			want: "service: api\ntls: false\nports:\n",
+		},
+		{
+			name:    "Missing Key",
+			input:   map[string]any{"template": config, "vars": map[string]any{"ports": []any{}}},
+			wantErr: `missing variable "name"`,
+		},
+		{
+			name:  "No Vars",
+			input: map[string]any{"template": "plain text"},
+			want:  "plain text",
+		},
+		{
+			name:    "Bad Vars",
+			input:   map[string]any{"template": "x", "vars": "nope"},
+			wantErr: "vars must be an object",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rt.Execute(ctx, tt.input, tc)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Execute() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Execute() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
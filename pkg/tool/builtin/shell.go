@@ -0,0 +1,220 @@
+package builtin
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"giai/pkg/tool"
+)
+
+const defaultShellCommandTimeout = 30 * time.Second
+
+// Shell runs commands in a persistent bash session, so state such as the
+// working directory, environment variables and shell functions carries over
+// between calls. Commands are serialized; stdout and stderr are combined and
+// returned as the Stdout of a tool.ProcessResult.
+//
+// A command that outlives its timeout kills the session, which is started
+// afresh on the next call. Call Close to end the session.
+type Shell struct {
+	tool.BaseTool
+	WorkDir        string        // Initial working directory; empty for the current one
+	CommandTimeout time.Duration // Per-command limit; 0 uses the default
+
+	mu     sync.Mutex
+	proc   *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+	marker string // Prefix of the line reporting a command's exit status
+}
+
+func NewShell(workDir string) *Shell {
+	t := &Shell{
+		BaseTool: tool.NewBaseTool(
+			"shell",
+			"Run a command in a persistent bash session. The working directory, environment variables "+
+				"and functions persist between calls. Returns the combined stdout and stderr and the exit code.",
+		),
+		WorkDir:        workDir,
+		CommandTimeout: defaultShellCommandTimeout,
+	}
+
+	t.TimeoutVal = 2 * time.Minute
+	// Commands may be destructive and change session state; never re-run them automatically.
+	t.RetryPolicyVal = nil
+
+	t.SchemaVal = map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"command": map[string]any{
+				"type":        "string",
+				"description": "The command to run in the session.",
+			},
+			"timeout": map[string]any{
+				"type":        "integer",
+				"description": fmt.Sprintf("Seconds to wait for the command (optional, default %d). On timeout the session is restarted.", int(defaultShellCommandTimeout/time.Second)),
+			},
+		},
+		"required": []string{"command"},
+	}
+
+	return t
+}
+
+// NewShellFactory returns a factory creating one session per tool. The
+// optional config keys are "work_dir" and "timeout" (seconds per command).
+func NewShellFactory() tool.ToolFactory {
+	return func(config map[string]any) (tool.Tool, error) {
+		dir, _ := config["work_dir"].(string)
+		t := NewShell(dir)
+		if v, ok := normalizeInt(config["timeout"]); ok {
+			if v <= 0 {
+				return nil, fmt.Errorf("config \"timeout\" must be positive")
+			}
+			t.CommandTimeout = time.Duration(v) * time.Second
+		}
+		return t, nil
+	}
+}
+
+func (t *Shell) Execute(ctx context.Context, input map[string]any, tc *tool.ToolContext) (any, error) {
+	command, ok := input["command"].(string)
+	if !ok || strings.TrimSpace(command) == "" {
+		return nil, fmt.Errorf("command must be a non-empty string")
+	}
+	timeout := t.CommandTimeout
+	if timeout <= 0 {
+		timeout = defaultShellCommandTimeout
+	}
+	if v, ok := normalizeInt(input["timeout"]); ok && v > 0 {
+		timeout = time.Duration(v) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.start(); err != nil {
+		return nil, err
+	}
+
+	// Braces run the command in the session itself, so cd and exports stick.
+	// Stdin is detached so a command reading it cannot swallow the status line.
+	script := fmt.Sprintf("{ %s\n} </dev/null 2>&1\nprintf '\\n%s%%d\\n' \"$?\"\n", command, t.marker)
+
+	type reply struct {
+		output string
+		code   int
+		err    error
+	}
+	stdin, stdout, marker := t.stdin, t.stdout, t.marker
+	done := make(chan reply, 1)
+	go func() {
+		if _, err := io.WriteString(stdin, script); err != nil {
+			done <- reply{err: err}
+			return
+		}
+		var out strings.Builder
+		for {
+			line, err := stdout.ReadString('\n')
+			if rest, ok := strings.CutPrefix(line, marker); ok && err == nil {
+				code, _ := strconv.Atoi(strings.TrimSpace(rest))
+				// Drop the newline the status line is prefixed with.
+				done <- reply{output: strings.TrimSuffix(out.String(), "\n"), code: code}
+				return
+			}
+			out.WriteString(line)
+			if err != nil {
+				done <- reply{output: out.String(), err: err}
+				return
+			}
+		}
+	}()
+
+	var r reply
+	select {
+	case r = <-done:
+	case <-ctx.Done():
+		// The command is still running and owns the session; discard it.
+		t.stop()
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("command timed out after %s; the shell session was restarted", timeout)
+		}
+		return nil, ctx.Err()
+	}
+	if r.err != nil {
+		t.stop()
+		if r.err == io.EOF {
+			return nil, fmt.Errorf("shell session exited; it will be restarted on the next call (output: %q)", r.output)
+		}
+		return nil, fmt.Errorf("shell session: %w", r.err)
+	}
+
+	return tool.ProcessResult{Stdout: r.output, ExitCode: r.code}, nil
+}
+
+// Close ends the shell session if it is running.
+func (t *Shell) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stop()
+	return nil
+}
+
+// start launches the session if it is not already running. Callers must hold t.mu.
+func (t *Shell) start() error {
+	if t.proc != nil {
+		return nil
+	}
+	cmd := exec.Command("bash", "--noprofile", "--norc")
+	cmd.Dir = t.WorkDir
+	setProcessGroup(cmd)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("start shell: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("start shell: %w", err)
+	}
+	cmd.Stderr = cmd.Stdout
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("start shell: %w", err)
+	}
+
+	var nonce [8]byte
+	rand.Read(nonce[:])
+	t.proc, t.stdin, t.stdout = cmd, stdin, bufio.NewReader(stdout)
+	t.marker = "__shell_status_" + hex.EncodeToString(nonce[:]) + "__ "
+	return nil
+}
+
+// stop kills the session and releases its pipes. Callers must hold t.mu.
+func (t *Shell) stop() {
+	if t.proc == nil {
+		return
+	}
+	t.stdin.Close()
+	if t.proc.Process != nil {
+		// Killing bash alone would leave a hung command running, holding the
+		// output pipe open so Wait never returns.
+		killProcessGroup(t.proc)
+	}
+	t.proc.Wait()
+	t.proc, t.stdin, t.stdout = nil, nil, nil
+}
+
+var (
+	_ tool.EnhancedTool = (*Shell)(nil)
+	_ tool.Closeable    = (*Shell)(nil)
+)
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package builtin
+
+import "os/exec"
+
+// setProcessGroup is a no-op where process groups are unavailable.
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// killProcessGroup kills cmd alone where process groups are unavailable.
+func killProcessGroup(cmd *exec.Cmd) {
+	cmd.Process.Kill()
+}
@@ -0,0 +1,95 @@
+package builtin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"giai/pkg/tool"
+)
+
+func TestShell_CdPersists(t *testing.T) {
+	dir, err := os.MkdirTemp("", "shell_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	dir, _ = filepath.EvalSymlinks(dir)
+
+	sh := NewShell("")
+	defer sh.Close()
+
+	if _, err := sh.Execute(context.Background(), map[string]any{"command": "cd " + dir}, nil); err != nil {
+		t.Fatalf("Execute(cd) error = %v", err)
+	}
+	out, err := sh.Execute(context.Background(), map[string]any{"command": "pwd"}, nil)
+	if err != nil {
+		t.Fatalf("Execute(pwd) error = %v", err)
+	}
+	res := out.(tool.ProcessResult)
+	if got := strings.TrimSpace(res.Stdout); got != dir {
+		t.Errorf("pwd = %q, want %q", got, dir)
+	}
+	if res.ExitCode != 0 {
+		t.Errorf("ExitCode = %d, want 0", res.ExitCode)
+	}
+}
+
+func TestShell_ExitCodeAndStderr(t *testing.T) {
+	sh := NewShell("")
+	defer sh.Close()
+
+	out, err := sh.Execute(context.Background(), map[string]any{"command": "echo oops >&2; false"}, nil)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if res := out.(tool.ProcessResult); res.Stdout != "oops\n" || res.ExitCode != 1 {
+		t.Errorf("Execute() = %+v, want output %q and code 1", res, "oops\n")
+	}
+}
+
+func TestShell_HungCommandTimesOut(t *testing.T) {
+	sh := NewShell("")
+	sh.CommandTimeout = 200 * time.Millisecond
+	defer sh.Close()
+
+	if _, err := sh.Execute(context.Background(), map[string]any{"command": "export MARK=set"}, nil); err != nil {
+		t.Fatalf("Execute(export) error = %v", err)
+	}
+
+	start := time.Now()
+	_, err := sh.Execute(context.Background(), map[string]any{"command": "sleep 30"}, nil)
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("Execute(sleep) error = %v, want timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Execute(sleep) took %v, want it cut off near the timeout", elapsed)
+	}
+
+	// The session is restarted, so earlier state is gone but commands work again.
+	out, err := sh.Execute(context.Background(), map[string]any{"command": "echo \"[$MARK]\""}, nil)
+	if err != nil {
+		t.Fatalf("Execute() after timeout error = %v", err)
+	}
+	if got := out.(tool.ProcessResult).Stdout; got != "[]\n" {
+		t.Errorf("output after restart = %q, want %q", got, "[]\n")
+	}
+}
+
+func TestShell_TimeoutKillsChildren(t *testing.T) {
+	sh := NewShell(t.TempDir())
+	sh.CommandTimeout = 200 * time.Millisecond
+	defer sh.Close()
+
+	late := filepath.Join(sh.WorkDir, "late")
+	if _, err := sh.Execute(context.Background(), map[string]any{"command": "(sleep 0.5; touch " + late + ")"}, nil); err == nil {
+		t.Fatal("Execute() expected timeout")
+	}
+	time.Sleep(time.Second)
+	if _, err := os.Stat(late); err == nil {
+		t.Error("the timed-out command kept running after the session was killed")
+	}
+}
@@ -0,0 +1,215 @@
+package builtin
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"giai/pkg/tool"
+)
+
+const defaultSQLMaxRows = 100
+
+// SQLQuery runs SQL against a database handle. It is read-only unless
+// constructed with WithWrites: queries then run in a read-only transaction
+// that is always rolled back, so nothing they change is kept.
+type SQLQuery struct {
+	tool.BaseTool
+	db          *sql.DB
+	maxRows     int
+	allowWrites bool
+}
+
+// SQLQueryOption configures an SQLQuery tool.
+type SQLQueryOption func(*SQLQuery)
+
+// WithWrites allows statements other than SELECT (INSERT, UPDATE, DDL, ...).
+func WithWrites() SQLQueryOption {
+	return func(t *SQLQuery) {
+		t.allowWrites = true
+	}
+}
+
+// WithMaxRows caps the rows returned per query (default 100).
+func WithMaxRows(n int) SQLQueryOption {
+	return func(t *SQLQuery) {
+		if n > 0 {
+			t.maxRows = n
+		}
+	}
+}
+
+func NewSQLQuery(db *sql.DB, opts ...SQLQueryOption) *SQLQuery {
+	t := &SQLQuery{
+		BaseTool: tool.NewBaseTool(
+			"sql_query",
+			"Run a SQL query against the database and return the resulting rows.",
+		),
+		db:      db,
+		maxRows: defaultSQLMaxRows,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	if t.allowWrites {
+		// Writes are not idempotent; never repeat them automatically.
+		t.RetryPolicyVal = nil
+	}
+
+	t.SchemaVal = map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"query": map[string]any{
+				"type":        "string",
+				"description": "The SQL statement to execute. Use placeholders for values.",
+			},
+			"args": map[string]any{
+				"type":        "array",
+				"description": "Positional arguments for the query placeholders (optional).",
+				"items":       map[string]any{"type": []string{"string", "number", "boolean", "null"}},
+			},
+			"max_rows": map[string]any{
+				"type":        "integer",
+				"description": fmt.Sprintf("Maximum number of rows to return (optional, at most %d).", t.maxRows),
+			},
+		},
+		"required": []string{"query"},
+	}
+
+	return t
+}
+
+// NewSQLQueryFactory returns a tool.ToolFactory that builds SQLQuery tools from
+// config keys "db" (*sql.DB, required), "max_rows" (int) and "allow_writes" (bool).
+func NewSQLQueryFactory() tool.ToolFactory {
+	return func(config map[string]any) (tool.Tool, error) {
+		db, ok := config["db"].(*sql.DB)
+		if !ok || db == nil {
+			return nil, fmt.Errorf("sql_query: config \"db\" must be a *sql.DB")
+		}
+		var opts []SQLQueryOption
+		if n, ok := config["max_rows"].(int); ok {
+			opts = append(opts, WithMaxRows(n))
+		}
+		if w, _ := config["allow_writes"].(bool); w {
+			opts = append(opts, WithWrites())
+		}
+		return NewSQLQuery(db, opts...), nil
+	}
+}
+
+func (t *SQLQuery) Execute(ctx context.Context, input map[string]any, tc *tool.ToolContext) (any, error) {
+	query, ok := input["query"].(string)
+	if !ok || strings.TrimSpace(query) == "" {
+		return nil, fmt.Errorf("query must be a non-empty string")
+	}
+
+	var args []any
+	if raw, ok := input["args"]; ok && raw != nil {
+		list, ok := raw.([]any)
+		if !ok {
+			return nil, fmt.Errorf("args must be an array")
+		}
+		args = list
+	}
+
+	limit := t.maxRows
+	if v, ok := normalizeInt(input["max_rows"]); ok && v > 0 && v < limit {
+		limit = v
+	}
+
+	if !isSelect(query) {
+		if !t.allowWrites {
+			return nil, fmt.Errorf("only SELECT statements are allowed")
+		}
+		res, err := t.db.ExecContext(ctx, query, args...)
+		if err != nil {
+			return nil, fmt.Errorf("exec failed: %w", err)
+		}
+		affected, _ := res.RowsAffected()
+		return map[string]any{"rows_affected": affected}, nil
+	}
+
+	var q interface {
+		QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	} = t.db
+	if !t.allowWrites {
+		// A SELECT can still write, e.g. through a data-modifying CTE or a
+		// function with side effects. The database enforces read-only where
+		// it can, and the rollback discards anything that got through.
+		tx, err := t.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+		if err != nil {
+			return nil, fmt.Errorf("begin read-only transaction: %w", err)
+		}
+		defer tx.Rollback()
+		q = tx
+	}
+
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+
+	result := make([]map[string]any, 0)
+	truncated := false
+	for rows.Next() {
+		if len(result) >= limit {
+			truncated = true
+			break
+		}
+		values := make([]any, len(columns))
+		ptrs := make([]any, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		row := make(map[string]any, len(columns))
+		for i, col := range columns {
+			if b, ok := values[i].([]byte); ok {
+				row[col] = string(b)
+			} else {
+				row[col] = values[i]
+			}
+		}
+		result = append(result, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+
+	return map[string]any{
+		"columns":   columns,
+		"rows":      result,
+		"truncated": truncated,
+	}, nil
+}
+
+// isSelect reports whether query is a single statement starting with SELECT,
+// WITH, EXPLAIN or VALUES. It only routes the statement and gives a clear
+// error for plain writes; the read-only transaction is what enforces it.
+func isSelect(query string) bool {
+	q := strings.TrimSuffix(strings.TrimSpace(query), ";")
+	if strings.Contains(q, ";") {
+		return false // Multiple statements
+	}
+	words := strings.FieldsFunc(strings.ToUpper(q), func(r rune) bool {
+		return !(r >= 'A' && r <= 'Z' || r == '_')
+	})
+	if len(words) == 0 {
+		return false
+	}
+	switch words[0] {
+	case "SELECT", "WITH", "EXPLAIN", "VALUES":
+		return true
+	}
+	return false
+}
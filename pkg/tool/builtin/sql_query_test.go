@@ -0,0 +1,154 @@
+package builtin
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	_ "modernc.org/sqlite"
+
+	"giai/pkg/tool"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	// A single connection keeps the in-memory database alive and shared.
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	stmts := []string{
+		`CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)`,
+		`INSERT INTO users (name) VALUES ('alice'), ('bob'), ('carol')`,
+	}
+	for _, s := range stmts {
+		if _, err := db.Exec(s); err != nil {
+			t.Fatalf("setup %q: %v", s, err)
+		}
+	}
+	return db
+}
+
+func TestSQLQuery_Execute(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	tc := tool.NewToolContext()
+
+	tests := []struct {
+		name          string
+		tool          *SQLQuery
+		input         map[string]any
+		wantRows      int
+		wantTruncated bool
+		wantErr       bool
+	}{
+		{
+			name:     "Select",
+			tool:     NewSQLQuery(db),
+			input:    map[string]any{"query": "SELECT id, name FROM users ORDER BY id"},
+			wantRows: 3,
+		},
+		{
+			name:     "Select With Args",
+			tool:     NewSQLQuery(db),
+			input:    map[string]any{"query": "SELECT name FROM users WHERE name = ?", "args": []any{"bob"}},
+			wantRows: 1,
+		},
+		{
+			name:          "Row Cap",
+			tool:          NewSQLQuery(db),
+			input:         map[string]any{"query": "SELECT * FROM users", "max_rows": float64(2)},
+			wantRows:      2,
+			wantTruncated: true,
+		},
+		{
+			name:          "Tool Row Cap",
+			tool:          NewSQLQuery(db, WithMaxRows(1)),
+			input:         map[string]any{"query": "SELECT * FROM users", "max_rows": float64(50)},
+			wantRows:      1,
+			wantTruncated: true,
+		},
+		{
+			name:    "Write Rejected",
+			tool:    NewSQLQuery(db),
+			input:   map[string]any{"query": "DELETE FROM users"},
+			wantErr: true,
+		},
+		{
+			name:     "Keyword In Literal",
+			tool:     NewSQLQuery(db),
+			input:    map[string]any{"query": "SELECT name FROM users WHERE name != 'DELETE'"},
+			wantRows: 3,
+		},
+		{
+			// Gets past the statement check; the rollback undoes it.
+			name:  "Write Behind WITH",
+			tool:  NewSQLQuery(db),
+			input: map[string]any{"query": "WITH doomed AS (SELECT id FROM users) DELETE FROM users WHERE id IN (SELECT id FROM doomed)"},
+		},
+		{
+			name:    "Multiple Statements Rejected",
+			tool:    NewSQLQuery(db),
+			input:   map[string]any{"query": "SELECT 1; DROP TABLE users"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.tool.Execute(ctx, tt.input, tc)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			res := got.(map[string]any)
+			rows := res["rows"].([]map[string]any)
+			if len(rows) != tt.wantRows {
+				t.Errorf("len(rows) = %d, want %d", len(rows), tt.wantRows)
+			}
+			if res["truncated"] != tt.wantTruncated {
+				t.Errorf("truncated = %v, want %v", res["truncated"], tt.wantTruncated)
+			}
+		})
+	}
+
+	var count int
+	db.QueryRow("SELECT COUNT(*) FROM users").Scan(&count)
+	if count != 3 {
+		t.Errorf("users count = %d after read-only queries, want 3", count)
+	}
+}
+
+func TestSQLQuery_WithWrites(t *testing.T) {
+	db := openTestDB(t)
+
+	sq := NewSQLQuery(db, WithWrites())
+	got, err := sq.Execute(context.Background(), map[string]any{"query": "DELETE FROM users WHERE name = ?", "args": []any{"bob"}}, tool.NewToolContext())
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if affected := got.(map[string]any)["rows_affected"]; affected != int64(1) {
+		t.Errorf("rows_affected = %v, want 1", affected)
+	}
+}
+
+func TestSQLQuery_Factory(t *testing.T) {
+	r := tool.NewRegistry()
+	RegisterAll(r)
+
+	if _, err := r.Create("sql_query", nil); err == nil {
+		t.Error("Create() without db expected error")
+	}
+	got, err := r.Create("sql_query", map[string]any{"db": openTestDB(t)})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if got.Name() != "sql_query" {
+		t.Errorf("Name() = %q, want sql_query", got.Name())
+	}
+}
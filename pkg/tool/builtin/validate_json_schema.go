@@ -0,0 +1,94 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"giai/pkg/schema"
+	"giai/pkg/tool"
+)
+
+// ValidateJSONSchema checks a candidate JSON Schema and validates a sample value
+// against it, so an agent can self-check schemas it generates.
+type ValidateJSONSchema struct {
+	tool.BaseTool
+}
+
+func NewValidateJSONSchema() *ValidateJSONSchema {
+	t := &ValidateJSONSchema{
+		BaseTool: tool.NewBaseTool(
+			"validate_json_schema",
+			"Check a JSON Schema for mistakes and validate a sample value against it, returning every violation.",
+		),
+	}
+
+	t.SchemaVal = map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"schema": map[string]any{
+				"type":        "object",
+				"description": "The JSON Schema to check (an object, or a string containing one).",
+			},
+			"value": map[string]any{
+				"description": "The sample value to validate (any JSON value, or a string containing JSON when value_is_json is true).",
+			},
+			"value_is_json": map[string]any{
+				"type":        "boolean",
+				"description": "Parse value as a JSON document instead of using it as-is (optional).",
+			},
+		},
+		"required": []string{"schema", "value"},
+	}
+
+	return t
+}
+
+func (t *ValidateJSONSchema) Execute(ctx context.Context, input map[string]any, tc *tool.ToolContext) (any, error) {
+	var s map[string]any
+	switch v := input["schema"].(type) {
+	case map[string]any:
+		s = v
+	case string:
+		if err := json.Unmarshal([]byte(v), &s); err != nil {
+			return nil, fmt.Errorf("schema is not a JSON object: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("schema must be an object")
+	}
+
+	value := input["value"]
+	if parse, _ := input["value_is_json"].(bool); parse {
+		raw, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("value must be a string when value_is_json is true")
+		}
+		if err := json.Unmarshal([]byte(raw), &value); err != nil {
+			return nil, fmt.Errorf("value is not valid JSON: %w", err)
+		}
+	}
+
+	schemaErrs := errorStrings(schema.Check(s))
+	if len(schemaErrs) > 0 {
+		return map[string]any{
+			"valid":         false,
+			"schema_errors": schemaErrs,
+			"errors":        []string{},
+		}, nil
+	}
+
+	errs := errorStrings(schema.Validate(s, value))
+	return map[string]any{
+		"valid":         len(errs) == 0,
+		"schema_errors": []string{},
+		"errors":        errs,
+	}, nil
+}
+
+func errorStrings(errs []schema.Error) []string {
+	out := make([]string, len(errs))
+	for i, e := range errs {
+		out[i] = e.Error()
+	}
+	return out
+}
@@ -0,0 +1,85 @@
+package builtin
+
+import (
+	"context"
+	"testing"
+
+	"giai/pkg/tool"
+)
+
+func TestValidateJSONSchema_Execute(t *testing.T) {
+	vs := NewValidateJSONSchema()
+	ctx := context.Background()
+	tc := tool.NewToolContext()
+
+	person := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name":   map[string]any{"type": "string"},
+			"status": map[string]any{"type": "string", "enum": []any{"active", "disabled"}},
+			"address": map[string]any{
+				"type":       "object",
+				"properties": map[string]any{"city": map[string]any{"type": "string"}},
+				"required":   []any{"city"},
+			},
+		},
+		"required": []any{"name"},
+	}
+
+	tests := []struct {
+		name            string
+		input           map[string]any
+		wantValid       bool
+		wantErrors      int
+		wantSchemaError bool
+		wantErr         bool
+	}{
+		{
+			name:      "Valid Nested Object",
+			input:     map[string]any{"schema": person, "value": map[string]any{"name": "ada", "status": "active", "address": map[string]any{"city": "Paris"}}},
+			wantValid: true,
+		},
+		{
+			name:       "Invalid Enum And Nested Field",
+			input:      map[string]any{"schema": person, "value": map[string]any{"name": "ada", "status": "gone", "address": map[string]any{}}},
+			wantErrors: 2,
+		},
+		{
+			name:      "Schema And Value As JSON Strings",
+			input:     map[string]any{"schema": `{"type":"array","items":{"type":"integer"}}`, "value": `[1,2,3]`, "value_is_json": true},
+			wantValid: true,
+		},
+		{
+			name:            "Malformed Schema",
+			input:           map[string]any{"schema": map[string]any{"type": "strng"}, "value": "x"},
+			wantSchemaError: true,
+		},
+		{
+			name:    "Schema Not Object",
+			input:   map[string]any{"schema": "[1]", "value": 1},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := vs.Execute(ctx, tt.input, tc)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			res := got.(map[string]any)
+			if res["valid"] != tt.wantValid {
+				t.Errorf("valid = %v, want %v (result %v)", res["valid"], tt.wantValid, res)
+			}
+			if errs := res["errors"].([]string); len(errs) != tt.wantErrors {
+				t.Errorf("errors = %q, want %d", errs, tt.wantErrors)
+			}
+			if schemaErrs := res["schema_errors"].([]string); (len(schemaErrs) > 0) != tt.wantSchemaError {
+				t.Errorf("schema_errors = %q, want present %v", schemaErrs, tt.wantSchemaError)
+			}
+		})
+	}
+}
@@ -11,6 +11,11 @@ type ToolContext struct {
 	SessionID   string
 	ExecutionID string // Unique ID for this execution
 
+	// IdempotencyKey is stable across retries of the same request, so side-effecting
+	// tools can forward it (e.g. as an Idempotency-Key header) for server-side dedup.
+	// Populated by the Executor, on the copy the tool receives, when empty.
+	IdempotencyKey string
+
 	// Context
 	Context context.Context
 
@@ -22,6 +27,15 @@ type ToolContext struct {
 	Storage Storage
 }
 
+// MetadataRequestID is the Metadata key holding the ID of the provider response
+// that requested the tool call, for correlating tool runs with LLM turns.
+const MetadataRequestID = "request_id"
+
+// MetadataApprovedCalls is the Metadata key listing the tool call IDs a user
+// approved, as a []string. A tool that RequiresApproval runs only when its
+// ExecuteRequest.CallID is listed.
+const MetadataApprovedCalls = "approved_calls"
+
 // Logger interface to avoid heavy dependencies
 type Logger interface {
 	Info(msg string, keysAndValues ...any)
@@ -66,3 +80,9 @@ func WithLogger(l Logger) Option {
 		tc.Logger = l
 	}
 }
+
+func WithStorage(s Storage) Option {
+	return func(tc *ToolContext) {
+		tc.Storage = s
+	}
+}
@@ -2,24 +2,46 @@ package tool
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"math"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
 	"time"
+
+	"golang.org/x/time/rate"
 )
 
 // ExecutorConfig controls how tools are executed.
 type ExecutorConfig struct {
 	MaxConcurrency int
 	DefaultTimeout time.Duration
+	// BatchConcurrency caps the workers used by a single ExecuteBatch call.
+	// Defaults to MaxConcurrency.
+	BatchConcurrency int
+	// DisableDefaultRetries ignores the DefaultRetryPolicy that NewBaseTool attaches,
+	// so only tools that set a retry policy explicitly are retried.
+	DisableDefaultRetries bool
+	// Observer receives events emitted by streaming tools (optional).
+	Observer Observer
+	// Clock times rate-limit waits (see RateLimitedTool). Defaults to real time.
+	Clock Clock
+	// Logger, when set, records each tool run at debug level: its input, with
+	// sensitive arguments masked (see RedactInput), and its outcome.
+	Logger Logger
 }
 
-// Executor runs tools with concurrency limits, timeouts, and retries.
+// Executor runs tools with concurrency limits, rate limits, timeouts, and retries.
 type Executor struct {
 	config    ExecutorConfig
 	semaphore chan struct{}
+
+	limitersMu sync.Mutex
+	limiters   map[string]*rate.Limiter // Keyed by tool name
 }
 
 // NewExecutor builds an Executor with sane defaults.
@@ -30,17 +52,33 @@ func NewExecutor(cfg ExecutorConfig) *Executor {
 	if cfg.DefaultTimeout <= 0 {
 		cfg.DefaultTimeout = 60 * time.Second
 	}
+	if cfg.BatchConcurrency <= 0 || cfg.BatchConcurrency > cfg.MaxConcurrency {
+		cfg.BatchConcurrency = cfg.MaxConcurrency
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = realClock{}
+	}
 	return &Executor{
 		config:    cfg,
 		semaphore: make(chan struct{}, cfg.MaxConcurrency),
+		limiters:  make(map[string]*rate.Limiter),
 	}
 }
 
+// Config returns the configuration the executor runs with, defaults applied.
+// NewExecutor(e.Config()) builds an executor that behaves alike but has its
+// own concurrency slots and rate limiters.
+func (e *Executor) Config() ExecutorConfig {
+	return e.config
+}
+
 // ExecuteRequest describes a single tool invocation.
 type ExecuteRequest struct {
 	Tool    Tool
 	Input   map[string]any
 	Context *ToolContext
+	// CallID is the model's ID for the tool call, which approvals refer to.
+	CallID string
 	// Overrides tool's default timeout if set > 0
 	TimeoutOverride time.Duration
 }
@@ -57,24 +95,27 @@ type ExecuteResult struct {
 	LongRunning bool
 }
 
+// Process returns the output as a *ProcessResult when the tool ran a process.
+func (r *ExecuteResult) Process() (*ProcessResult, bool) {
+	switch out := r.Output.(type) {
+	case ProcessResult:
+		return &out, true
+	case *ProcessResult:
+		return out, out != nil
+	}
+	return nil, false
+}
+
 // Execute runs one tool with observability, timeout, and retry logic.
 func (e *Executor) Execute(ctx context.Context, req *ExecuteRequest) *ExecuteResult {
 	start := time.Now()
 
-	// 1. Acquire concurrency slot
-	select {
-	case e.semaphore <- struct{}{}:
-		defer func() { <-e.semaphore }()
-	case <-ctx.Done():
-		return &ExecuteResult{Success: false, Error: ctx.Err(), StartedAt: start, FinishedAt: time.Now()}
-	}
-
-	// 2. Input Validation
+	// 1. Input Validation
 	if err := ValidateInput(req.Tool, req.Input); err != nil {
 		return &ExecuteResult{Success: false, Error: err, StartedAt: start, FinishedAt: time.Now()}
 	}
 
-	// 3. Determine config (Timeout, Retry)
+	// 2. Determine config (Timeout, Retry)
 	var (
 		timeout          = e.config.DefaultTimeout
 		retryPolicy      *RetryPolicy
@@ -87,6 +128,9 @@ func (e *Executor) Execute(ctx context.Context, req *ExecuteRequest) *ExecuteRes
 			timeout = t
 		}
 		retryPolicy = et.RetryPolicy()
+		if d, ok := et.(interface{ usesDefaultRetryPolicy() bool }); ok && e.config.DisableDefaultRetries && d.usesDefaultRetryPolicy() {
+			retryPolicy = nil
+		}
 		longRunning = et.IsLongRunning()
 		requiresApproval = et.RequiresApproval()
 		// Long running tools often manage their own lifecycle; relax timeout if unset.
@@ -100,7 +144,7 @@ func (e *Executor) Execute(ctx context.Context, req *ExecuteRequest) *ExecuteRes
 		timeout = req.TimeoutOverride
 	}
 
-	if requiresApproval && !approved(req.Context) {
+	if requiresApproval && !approved(req) {
 		err := fmt.Errorf("tool %s requires approval before execution", req.Tool.Name())
 		end := time.Now()
 		return &ExecuteResult{
@@ -113,6 +157,37 @@ func (e *Executor) Execute(ctx context.Context, req *ExecuteRequest) *ExecuteRes
 		}
 	}
 
+	// The key is fixed before the first attempt so every retry sees the same
+	// value. It is set on a copy: the caller's context may be reused for
+	// other requests, which need keys of their own.
+	tc := NewToolContext()
+	if req.Context != nil {
+		callCtx := *req.Context
+		tc = &callCtx
+	}
+	if tc.IdempotencyKey == "" {
+		tc.IdempotencyKey = IdempotencyKey(req.Tool.Name(), req.Input, tc.ExecutionID)
+	}
+
+	if e.config.Logger != nil {
+		e.config.Logger.Debug("executing tool", "tool", req.Tool.Name(), "input", RedactInput(req.Tool.InputSchema(), req.Input))
+	}
+
+	// Invalid or unapproved calls are rejected above without using up the
+	// rate limit. The wait comes before taking a slot, so throttled tools
+	// don't starve others of concurrency.
+	if err := e.waitRateLimit(ctx, req.Tool); err != nil {
+		return &ExecuteResult{Success: false, Error: err, StartedAt: start, FinishedAt: time.Now()}
+	}
+
+	// 3. Acquire concurrency slot
+	select {
+	case e.semaphore <- struct{}{}:
+		defer func() { <-e.semaphore }()
+	case <-ctx.Done():
+		return &ExecuteResult{Success: false, Error: ctx.Err(), StartedAt: start, FinishedAt: time.Now()}
+	}
+
 	// 4. Execution Loop
 	var (
 		output   any
@@ -135,7 +210,11 @@ func (e *Executor) Execute(ctx context.Context, req *ExecuteRequest) *ExecuteRes
 			execCtx, cancel = context.WithTimeout(ctx, timeout)
 		}
 
-		output, execErr = req.Tool.Execute(execCtx, req.Input, req.Context)
+		if st, ok := req.Tool.(StreamingTool); ok {
+			output, execErr = e.runStreaming(execCtx, st, req.Input, tc)
+		} else {
+			output, execErr = req.Tool.Execute(execCtx, req.Input, tc)
+		}
 		if cancel != nil {
 			cancel()
 		}
@@ -155,16 +234,23 @@ func (e *Executor) Execute(ctx context.Context, req *ExecuteRequest) *ExecuteRes
 
 			select {
 			case <-time.After(delay):
-				continue
 			case <-ctx.Done():
 				execErr = ctx.Err()
 				goto Finish
 			}
+			// A retry calls the service again, so it draws on the rate limit too.
+			if err := e.waitRateLimit(ctx, req.Tool); err != nil {
+				execErr = err
+				goto Finish
+			}
 		}
 	}
 
 Finish:
 	end := time.Now()
+	if e.config.Logger != nil {
+		e.config.Logger.Debug("tool finished", "tool", req.Tool.Name(), "attempts", attempts, "duration", end.Sub(start), "error", execErr)
+	}
 	return &ExecuteResult{
 		Success:     execErr == nil,
 		Output:      output,
@@ -178,6 +264,10 @@ Finish:
 }
 
 // ExecuteBatch runs a batch of requests concurrently.
+// Requests are queued by priority (highest first, ties keep their original
+// order) and drained by a bounded worker pool, so under contention
+// higher-priority tools acquire execution slots first.
+// Results are returned in the original request order.
 func (e *Executor) ExecuteBatch(ctx context.Context, requests []*ExecuteRequest) []*ExecuteResult {
 	results := make([]*ExecuteResult, len(requests))
 	if len(requests) == 0 {
@@ -202,14 +292,28 @@ func (e *Executor) ExecuteBatch(ctx context.Context, requests []*ExecuteRequest)
 		return items[i].priority > items[j].priority
 	})
 
+	// The queue is filled in priority order before any worker starts,
+	// so workers always pick the highest-priority pending request.
+	queue := make(chan prioritized, len(items))
+	for _, item := range items {
+		queue <- item
+	}
+	close(queue)
+
+	workers := e.config.BatchConcurrency
+	if workers <= 0 || workers > len(items) {
+		workers = len(items)
+	}
+
 	var wg sync.WaitGroup
-	wg.Add(len(items))
+	wg.Add(workers)
 
-	for _, item := range items {
-		item := item
+	for w := 0; w < workers; w++ {
 		go func() {
 			defer wg.Done()
-			results[item.idx] = e.Execute(ctx, item.req)
+			for item := range queue {
+				results[item.idx] = e.Execute(ctx, item.req)
+			}
 		}()
 	}
 
@@ -217,6 +321,22 @@ func (e *Executor) ExecuteBatch(ctx context.Context, requests []*ExecuteRequest)
 	return results
 }
 
+// IdempotencyKey derives a stable key from the tool name, its input and the execution ID.
+// Map keys are serialized in sorted order, so equal inputs always yield the same key.
+func IdempotencyKey(toolName string, input map[string]any, executionID string) string {
+	h := sha256.New()
+	h.Write([]byte(toolName))
+	h.Write([]byte{0})
+	if raw, err := json.Marshal(input); err == nil {
+		h.Write(raw)
+	} else {
+		fmt.Fprintf(h, "%v", input)
+	}
+	h.Write([]byte{0})
+	h.Write([]byte(executionID))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
 func isRetryable(err error, policy *RetryPolicy) bool {
 	if policy == nil || len(policy.RetryableErrors) == 0 {
 		return true // Default to retry all if policy exists but specifies no filters
@@ -241,12 +361,18 @@ func calculateBackoff(attempt int, policy *RetryPolicy) time.Duration {
 	return time.Duration(backoff)
 }
 
-func approved(tc *ToolContext) bool {
-	if tc == nil {
+// approved reports whether req's call ID is among the approved calls in its
+// context. Approval is per call, so it never extends to calls the model makes
+// after the user decided.
+func approved(req *ExecuteRequest) bool {
+	if req.Context == nil || req.CallID == "" {
 		return false
 	}
-	if v, ok := tc.Metadata["approved"].(bool); ok {
-		return v
+	switch ids := req.Context.Metadata[MetadataApprovedCalls].(type) {
+	case []string:
+		return slices.Contains(ids, req.CallID)
+	case []any:
+		return slices.Contains(ids, any(req.CallID))
 	}
 	return false
 }
@@ -0,0 +1,317 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestExecuteBatch_PriorityOrder(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		order []string
+	)
+	record := func(name string) Callable {
+		return func(ctx context.Context, input map[string]any, tc *ToolContext) (any, error) {
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+			return name, nil
+		}
+	}
+
+	low1 := NewFunc("low1", "", record("low1")).WithPriority(1)
+	low2 := NewFunc("low2", "", record("low2")).WithPriority(1)
+	mid := NewFunc("mid", "", record("mid")).WithPriority(5)
+	high := NewFunc("high", "", record("high")).WithPriority(10)
+
+	exec := NewExecutor(ExecutorConfig{MaxConcurrency: 1})
+	reqs := []*ExecuteRequest{
+		{Tool: low1, Input: map[string]any{"input": "x"}},
+		{Tool: low2, Input: map[string]any{"input": "x"}},
+		{Tool: mid, Input: map[string]any{"input": "x"}},
+		{Tool: high, Input: map[string]any{"input": "x"}},
+	}
+
+	results := exec.ExecuteBatch(context.Background(), reqs)
+
+	wantOrder := []string{"high", "mid", "low1", "low2"}
+	if len(order) != len(wantOrder) {
+		t.Fatalf("started %v, want %v", order, wantOrder)
+	}
+	for i := range wantOrder {
+		if order[i] != wantOrder[i] {
+			t.Fatalf("start order = %v, want %v", order, wantOrder)
+		}
+	}
+
+	// Results keep the original request order.
+	for i, name := range []string{"low1", "low2", "mid", "high"} {
+		if results[i].Output != name {
+			t.Errorf("results[%d].Output = %v, want %s", i, results[i].Output, name)
+		}
+	}
+}
+
+func TestExecute_DisableDefaultRetries(t *testing.T) {
+	failing := func(calls *int) Callable {
+		return func(ctx context.Context, input map[string]any, tc *ToolContext) (any, error) {
+			*calls++
+			return nil, errors.New("transient")
+		}
+	}
+	policy := &RetryPolicy{MaxRetries: 2, BackoffMultiplier: 1}
+
+	tests := []struct {
+		name      string
+		tool      func(calls *int) Tool
+		wantCalls int
+	}{
+		{
+			name:      "Default Policy Ignored",
+			tool:      func(calls *int) Tool { return NewFunc("default", "", failing(calls)) },
+			wantCalls: 1,
+		},
+		{
+			name:      "Explicit Policy Kept",
+			tool:      func(calls *int) Tool { return NewFunc("explicit", "", failing(calls)).WithRetry(policy) },
+			wantCalls: 3,
+		},
+	}
+
+	exec := NewExecutor(ExecutorConfig{DisableDefaultRetries: true})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			res := exec.Execute(context.Background(), &ExecuteRequest{
+				Tool:  tt.tool(&calls),
+				Input: map[string]any{"input": "x"},
+			})
+			if res.Success {
+				t.Fatal("Execute() succeeded, want failure")
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestExecute_IdempotencyKeyStableAcrossRetries(t *testing.T) {
+	var keys []string
+	flaky := NewFunc("flaky", "", func(ctx context.Context, input map[string]any, tc *ToolContext) (any, error) {
+		keys = append(keys, tc.IdempotencyKey)
+		if len(keys) < 3 {
+			return nil, errors.New("transient")
+		}
+		return "ok", nil
+	}).WithRetry(&RetryPolicy{MaxRetries: 2, BackoffMultiplier: 1})
+
+	input := map[string]any{"input": "x"}
+	tc := NewToolContext()
+	tc.ExecutionID = "exec-1"
+
+	res := NewExecutor(ExecutorConfig{}).Execute(context.Background(), &ExecuteRequest{Tool: flaky, Input: input, Context: tc})
+	if !res.Success {
+		t.Fatalf("Execute() error = %v", res.Error)
+	}
+
+	want := IdempotencyKey("flaky", input, "exec-1")
+	if len(keys) != 3 {
+		t.Fatalf("attempts = %d, want 3", len(keys))
+	}
+	for i, k := range keys {
+		if k != want {
+			t.Errorf("attempt %d key = %q, want %q", i+1, k, want)
+		}
+	}
+	if other := IdempotencyKey("flaky", input, "exec-2"); other == want {
+		t.Error("IdempotencyKey() should differ across execution IDs")
+	}
+}
+
+func TestExecute_IdempotencyKeyPerRequest(t *testing.T) {
+	var keys []string
+	record := NewFunc("record", "", func(ctx context.Context, input map[string]any, tc *ToolContext) (any, error) {
+		keys = append(keys, tc.IdempotencyKey)
+		return "ok", nil
+	})
+
+	exec := NewExecutor(ExecutorConfig{})
+	tc := NewToolContext()
+	for _, v := range []string{"a", "b"} {
+		exec.Execute(context.Background(), &ExecuteRequest{Tool: record, Input: map[string]any{"input": v}, Context: tc})
+	}
+	if len(keys) != 2 || keys[0] == keys[1] {
+		t.Errorf("keys = %q, want a distinct key per request sharing a context", keys)
+	}
+	if tc.IdempotencyKey != "" {
+		t.Errorf("caller's IdempotencyKey = %q, want it left unset", tc.IdempotencyKey)
+	}
+}
+
+// fakeClock advances instantly whenever something waits on it.
+type fakeClock struct {
+	mu    sync.Mutex
+	now   time.Time
+	waits []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	c.waits = append(c.waits, d)
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+func TestExecute_RateLimit(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	e := NewExecutor(ExecutorConfig{Clock: clock})
+
+	var ran []time.Time
+	limited := NewFunc("limited", "", func(ctx context.Context, input map[string]any, tc *ToolContext) (any, error) {
+		ran = append(ran, clock.Now())
+		return "ok", nil
+	}).WithRateLimit(1, 1)
+
+	for i := 0; i < 3; i++ {
+		res := e.Execute(context.Background(), &ExecuteRequest{Tool: limited, Input: map[string]any{"input": "x"}})
+		if res.Error != nil {
+			t.Fatalf("call %d error = %v", i+1, res.Error)
+		}
+	}
+
+	for i := 1; i < len(ran); i++ {
+		if gap := ran[i].Sub(ran[i-1]); gap < time.Second {
+			t.Errorf("calls %d and %d ran %v apart, want at least 1s", i, i+1, gap)
+		}
+	}
+	if len(clock.waits) != 2 {
+		t.Errorf("waits = %v, want 2 (the first call uses the burst)", clock.waits)
+	}
+}
+
+func TestExecute_RateLimitAppliesToRetries(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	e := NewExecutor(ExecutorConfig{Clock: clock})
+
+	attempts := 0
+	flaky := NewFunc("flaky", "", func(ctx context.Context, input map[string]any, tc *ToolContext) (any, error) {
+		attempts++
+		if attempts < 3 {
+			return nil, errors.New("transient")
+		}
+		return "ok", nil
+	}).WithRetry(&RetryPolicy{MaxRetries: 2, BackoffMultiplier: 1}).WithRateLimit(1, 1)
+
+	res := e.Execute(context.Background(), &ExecuteRequest{Tool: flaky, Input: map[string]any{"input": "x"}})
+	if !res.Success || res.Attempts != 3 {
+		t.Fatalf("Execute() = %+v, want success on the third attempt", res)
+	}
+	if len(clock.waits) != 2 {
+		t.Errorf("waits = %v, want one per retry", clock.waits)
+	}
+}
+
+func TestExecute_RateLimitSkipsRejectedCalls(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	e := NewExecutor(ExecutorConfig{Clock: clock})
+	limited := NewFunc("limited", "", func(ctx context.Context, input map[string]any, tc *ToolContext) (any, error) {
+		return "ok", nil
+	}).WithRateLimit(1, 1)
+
+	for i := 0; i < 2; i++ {
+		if res := e.Execute(context.Background(), &ExecuteRequest{Tool: limited, Input: map[string]any{}}); res.Error == nil {
+			t.Fatalf("invalid call %d succeeded, want a validation error", i+1)
+		}
+	}
+	if res := e.Execute(context.Background(), &ExecuteRequest{Tool: limited, Input: map[string]any{"input": "x"}}); res.Error != nil {
+		t.Fatalf("valid call error = %v", res.Error)
+	}
+	if len(clock.waits) != 0 {
+		t.Errorf("waits = %v, want none: rejected calls must not use the rate limit", clock.waits)
+	}
+}
+
+func TestExecute_RateLimitCancelled(t *testing.T) {
+	e := NewExecutor(ExecutorConfig{})
+	limited := NewFunc("limited", "", func(ctx context.Context, input map[string]any, tc *ToolContext) (any, error) {
+		return "ok", nil
+	}).WithRateLimit(0.001, 1)
+	input := map[string]any{"input": "x"}
+
+	if res := e.Execute(context.Background(), &ExecuteRequest{Tool: limited, Input: input}); res.Error != nil {
+		t.Fatalf("first call error = %v", res.Error)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	res := e.Execute(ctx, &ExecuteRequest{Tool: limited, Input: input})
+	if !errors.Is(res.Error, context.DeadlineExceeded) {
+		t.Errorf("throttled call error = %v, want context.DeadlineExceeded", res.Error)
+	}
+}
+
+// recordingLogger keeps the key-value pairs of every log call.
+type recordingLogger struct {
+	mu      sync.Mutex
+	entries []map[string]any
+}
+
+func (l *recordingLogger) record(kv []any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entry := map[string]any{}
+	for i := 0; i+1 < len(kv); i += 2 {
+		entry[kv[i].(string)] = kv[i+1]
+	}
+	l.entries = append(l.entries, entry)
+}
+
+func (l *recordingLogger) Info(msg string, kv ...any)  { l.record(kv) }
+func (l *recordingLogger) Error(msg string, kv ...any) { l.record(kv) }
+func (l *recordingLogger) Debug(msg string, kv ...any) { l.record(kv) }
+
+func TestExecute_LogsRedactSensitiveArguments(t *testing.T) {
+	type login struct {
+		User     string `json:"user"`
+		Password string `json:"password" sensitive:"true"`
+	}
+	var got login
+	st := NewStruct("login", "Log in.", func(ctx context.Context, in login, tc *ToolContext) (any, error) {
+		got = in
+		return "ok", nil
+	})
+
+	logger := &recordingLogger{}
+	exec := NewExecutor(ExecutorConfig{Logger: logger})
+	input := map[string]any{"user": "ada", "password": "hunter2"}
+	if res := exec.Execute(context.Background(), &ExecuteRequest{Tool: st, Input: input}); res.Error != nil {
+		t.Fatalf("Execute() error = %v", res.Error)
+	}
+
+	if got.Password != "hunter2" {
+		t.Errorf("Execute received password %q, want the real value", got.Password)
+	}
+	if input["password"] != "hunter2" {
+		t.Error("logging modified the request input")
+	}
+	if len(logger.entries) == 0 {
+		t.Fatal("nothing was logged")
+	}
+	logged, _ := logger.entries[0]["input"].(map[string]any)
+	if logged["password"] != RedactedValue || logged["user"] != "ada" {
+		t.Errorf("logged input = %v, want only the password masked", logged)
+	}
+}
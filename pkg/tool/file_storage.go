@@ -0,0 +1,139 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FileStorage is a Storage persisted as a single JSON file, so state written by
+// long-running tools survives restarts. Values are JSON-encoded on Set; Get
+// returns the stored JSON as json.RawMessage, which LoadState decodes. Byte
+// slices that already hold valid JSON (as written by SaveState) are stored as-is.
+// It is safe for concurrent use within one process.
+type FileStorage struct {
+	path string
+
+	mu   sync.RWMutex
+	data map[string]json.RawMessage
+}
+
+// NewFileStorage opens the store at path, loading existing entries. The file
+// is created on the first Set.
+func NewFileStorage(path string) (*FileStorage, error) {
+	s := &FileStorage{path: path, data: make(map[string]json.RawMessage)}
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read storage file: %w", err)
+	}
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &s.data); err != nil {
+			return nil, fmt.Errorf("failed to decode storage file %s: %w", path, err)
+		}
+	}
+	return s, nil
+}
+
+// Get returns the JSON stored under key or ErrStateNotFound.
+func (s *FileStorage) Get(ctx context.Context, key string) (any, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v, ok := s.data[key]
+	if !ok {
+		return nil, ErrStateNotFound
+	}
+	return v, nil
+}
+
+// Set JSON-encodes value under key and writes the store to disk.
+func (s *FileStorage) Set(ctx context.Context, key string, value any) error {
+	raw, err := encodeValue(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode %q: %w", key, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prev, existed := s.data[key]
+	s.data[key] = raw
+	if err := s.flush(); err != nil {
+		// Keep memory consistent with what is on disk.
+		if existed {
+			s.data[key] = prev
+		} else {
+			delete(s.data, key)
+		}
+		return err
+	}
+	return nil
+}
+
+// Namespace returns a view of the store whose keys are prefixed with ns + "/".
+func (s *FileStorage) Namespace(ns string) Storage {
+	return &namespacedStorage{prefix: ns + "/", inner: s}
+}
+
+// flush atomically replaces the file with the current entries. Callers hold mu.
+func (s *FileStorage) flush() error {
+	raw, err := json.MarshalIndent(s.data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode storage: %w", err)
+	}
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(s.path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write storage file: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write storage file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write storage file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to replace storage file: %w", err)
+	}
+	return nil
+}
+
+func encodeValue(value any) (json.RawMessage, error) {
+	switch v := value.(type) {
+	case json.RawMessage:
+		if json.Valid(v) {
+			return append(json.RawMessage(nil), v...), nil
+		}
+	case []byte:
+		if json.Valid(v) {
+			return append(json.RawMessage(nil), v...), nil
+		}
+	}
+	return json.Marshal(value)
+}
+
+// namespacedStorage prefixes every key before delegating to inner.
+type namespacedStorage struct {
+	prefix string
+	inner  Storage
+}
+
+func (n *namespacedStorage) Get(ctx context.Context, key string) (any, error) {
+	return n.inner.Get(ctx, n.prefix+key)
+}
+
+func (n *namespacedStorage) Set(ctx context.Context, key string, value any) error {
+	return n.inner.Set(ctx, n.prefix+key, value)
+}
+
+var _ Storage = (*FileStorage)(nil)
@@ -0,0 +1,96 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestFileStorage_RoundTrip(t *testing.T) {
+	dir, err := os.MkdirTemp("", "file-storage-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "state", "store.json")
+	ctx := context.Background()
+
+	s, err := NewFileStorage(path)
+	if err != nil {
+		t.Fatalf("NewFileStorage() error = %v", err)
+	}
+	if _, err := s.Get(ctx, "missing"); !errors.Is(err, ErrStateNotFound) {
+		t.Errorf("Get(missing) error = %v, want ErrStateNotFound", err)
+	}
+	if err := s.Set(ctx, "job", map[string]any{"step": 3}); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := s.Namespace("tenant-a").Set(ctx, "job", "other"); err != nil {
+		t.Fatalf("Namespace().Set() error = %v", err)
+	}
+	tc := NewToolContext(WithSessionID("s1"), WithStorage(s))
+	if err := SaveState(tc, "progress", progress{Step: 2, Items: []string{"a"}}); err != nil {
+		t.Fatalf("SaveState() error = %v", err)
+	}
+
+	reopened, err := NewFileStorage(path)
+	if err != nil {
+		t.Fatalf("NewFileStorage() reopen error = %v", err)
+	}
+	v, err := reopened.Get(ctx, "job")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	var job struct{ Step int }
+	if err := json.Unmarshal(v.(json.RawMessage), &job); err != nil || job.Step != 3 {
+		t.Errorf("Get() = %s, want step 3 (err %v)", v, err)
+	}
+	if v, err := reopened.Namespace("tenant-a").Get(ctx, "job"); err != nil || string(v.(json.RawMessage)) != `"other"` {
+		t.Errorf("Namespace().Get() = %s, %v, want \"other\"", v, err)
+	}
+
+	got, err := LoadState[progress](NewToolContext(WithSessionID("s1"), WithStorage(reopened)), "progress")
+	if err != nil {
+		t.Fatalf("LoadState() error = %v", err)
+	}
+	if got.Step != 2 || len(got.Items) != 1 || got.Items[0] != "a" {
+		t.Errorf("LoadState() = %+v, want step 2 with one item", got)
+	}
+}
+
+func TestFileStorage_Concurrent(t *testing.T) {
+	dir, err := os.MkdirTemp("", "file-storage-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "store.json")
+
+	s, err := NewFileStorage(path)
+	if err != nil {
+		t.Fatalf("NewFileStorage() error = %v", err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := s.Set(context.Background(), string(rune('a'+i)), i); err != nil {
+				t.Errorf("Set() error = %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	reopened, err := NewFileStorage(path)
+	if err != nil {
+		t.Fatalf("NewFileStorage() reopen error = %v", err)
+	}
+	if len(reopened.data) != 20 {
+		t.Errorf("reopened store has %d keys, want 20", len(reopened.data))
+	}
+}
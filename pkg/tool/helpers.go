@@ -1,6 +1,7 @@
 package tool
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 
@@ -29,22 +30,36 @@ func Find(tools []Tool, name string) Tool {
 	return nil
 }
 
+// ValidateName checks a tool name against the function-naming rules shared by
+// the major providers: 1-64 characters of letters, digits, underscores and hyphens.
+func ValidateName(name string) error {
+	if name == "" {
+		return fmt.Errorf("tool name is empty")
+	}
+	if len(name) > 64 {
+		return fmt.Errorf("tool name %q is longer than 64 characters", name)
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-') {
+			return fmt.Errorf("tool name %q contains invalid character %q (allowed: a-z, A-Z, 0-9, _ and -)", name, r)
+		}
+	}
+	return nil
+}
+
 // ValidateInput performs a basic required-field check based on the tool schema.
+// A single-string "required" is accepted as a one-element list; any other
+// non-list value, or a list with non-string entries, is reported as a schema
+// error rather than silently disabling the check.
 func ValidateInput(tool Tool, input map[string]any) error {
 	schema := tool.InputSchema()
 	if schema == nil {
 		return nil
 	}
 
-	required, ok := schema["required"].([]string)
-	if !ok {
-		if raw, okAny := schema["required"].([]any); okAny {
-			for _, v := range raw {
-				if s, okStr := v.(string); okStr {
-					required = append(required, s)
-				}
-			}
-		}
+	required, err := requiredFields(schema["required"])
+	if err != nil {
+		return fmt.Errorf("tool %q has an invalid schema: %w", tool.Name(), err)
 	}
 
 	for _, field := range required {
@@ -55,20 +70,38 @@ func ValidateInput(tool Tool, input map[string]any) error {
 	return nil
 }
 
+// requiredFields normalizes a schema's "required" value to a list of names.
+func requiredFields(v any) ([]string, error) {
+	switch req := v.(type) {
+	case nil:
+		return nil, nil
+	case []string:
+		return req, nil
+	case string:
+		return []string{req}, nil
+	case []any:
+		fields := make([]string, 0, len(req))
+		for i, f := range req {
+			s, ok := f.(string)
+			if !ok {
+				return nil, fmt.Errorf("required[%d] is %T, want string", i, f)
+			}
+			fields = append(fields, s)
+		}
+		return fields, nil
+	default:
+		return nil, fmt.Errorf("required is %T, want an array of strings", v)
+	}
+}
+
 // ToDefinition converts a Tool into a types.ToolDefinition for LLM providers.
+// Tool-side markers such as "sensitive" are stripped from the schema.
 func ToDefinition(t Tool) types.ToolDefinition {
-	return types.ToolDefinition{
-		Type: "function",
-		Function: struct {
-			Name        string `json:"name"`
-			Description string `json:"description,omitempty"`
-			Parameters  any    `json:"parameters"`
-		}{
-			Name:        t.Name(),
-			Description: t.Description(),
-			Parameters:  t.InputSchema(),
-		},
+	schema := t.InputSchema()
+	if stripped, changed := stripSensitive(schema); changed {
+		schema = stripped.(map[string]any)
 	}
+	return types.NewToolDefinition(t.Name(), t.Description(), schema)
 }
 
 // ToDefinitions converts a list of Tools to provider tool definitions.
@@ -79,3 +112,50 @@ func ToDefinitions(tools []Tool) []types.ToolDefinition {
 	}
 	return res
 }
+
+// ResultToMessage converts an execution result into the tool message answering
+// call. Failures become "error: <message>" content, flagged with
+// Metadata["error"] = true, so the model can see what went wrong and react.
+// A tool message output, such as types.ToolImageResult, is used as is with
+// its ToolCallID set to call.ID; other outputs are rendered by FormatOutput.
+func ResultToMessage(call types.ToolCall, res *ExecuteResult) types.Message {
+	return ResultToMessageWith(call, res, nil)
+}
+
+// ResultToMessageWith is ResultToMessage with failures worded by formatError.
+// A nil formatError gives the default "error: <message>" content.
+func ResultToMessageWith(call types.ToolCall, res *ExecuteResult, formatError func(call types.ToolCall, err error) string) types.Message {
+	if formatError == nil {
+		formatError = func(_ types.ToolCall, err error) string { return fmt.Sprintf("error: %v", err) }
+	}
+	if res == nil {
+		res = &ExecuteResult{Error: fmt.Errorf("tool %q produced no result", call.Function.Name)}
+	}
+	if res.Error != nil {
+		msg := types.ToolResultMessage(call.ID, formatError(call, res.Error))
+		msg.Metadata = map[string]any{"error": true}
+		return msg
+	}
+	if msg, ok := res.Output.(types.Message); ok && msg.Role == types.RoleTool {
+		msg.ToolCallID = call.ID
+		return msg
+	}
+	return types.ToolResultMessage(call.ID, FormatOutput(res.Output))
+}
+
+// FormatOutput renders a tool output as message content: strings and byte
+// slices verbatim, nil as empty, anything else as JSON.
+func FormatOutput(output any) string {
+	switch v := output.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	}
+	if b, err := json.Marshal(output); err == nil {
+		return string(b)
+	}
+	return fmt.Sprintf("%v", output)
+}
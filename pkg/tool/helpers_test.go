@@ -0,0 +1,148 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"giai/pkg/types"
+)
+
+func TestResultToMessage(t *testing.T) {
+	call := types.NewToolCall("call_1", "lookup", `{"id":7}`)
+	type record struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+
+	tests := []struct {
+		name        string
+		res         *ExecuteResult
+		wantContent string
+		wantError   bool
+	}{
+		{
+			name:        "String Output",
+			res:         &ExecuteResult{Success: true, Output: "found it"},
+			wantContent: "found it",
+		},
+		{
+			name:        "Struct Output",
+			res:         &ExecuteResult{Success: true, Output: record{ID: 7, Name: "ada"}},
+			wantContent: `{"id":7,"name":"ada"}`,
+		},
+		{
+			name:        "Image Output",
+			res:         &ExecuteResult{Success: true, Output: types.ToolImageResult("", "image/png", []byte{1, 2})},
+			wantContent: "[image/png image, 2 bytes]",
+		},
+		{
+			name:        "Nil Output",
+			res:         &ExecuteResult{Success: true},
+			wantContent: "",
+		},
+		{
+			name:        "Error",
+			res:         &ExecuteResult{Error: errors.New("record 7 not found")},
+			wantContent: "error: record 7 not found",
+			wantError:   true,
+		},
+		{
+			name:        "Nil Result",
+			wantContent: `error: tool "lookup" produced no result`,
+			wantError:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := ResultToMessage(call, tt.res)
+			if msg.Role != types.RoleTool || msg.ToolCallID != "call_1" {
+				t.Errorf("Role = %q, ToolCallID = %q, want tool message for call_1", msg.Role, msg.ToolCallID)
+			}
+			if msg.Content != tt.wantContent {
+				t.Errorf("Content = %q, want %q", msg.Content, tt.wantContent)
+			}
+			if got := msg.Metadata["error"] == true; got != tt.wantError {
+				t.Errorf("Metadata = %v, want error flag %v", msg.Metadata, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestValidateInput_Required(t *testing.T) {
+	tests := []struct {
+		name     string
+		required any
+		input    map[string]any
+		wantErr  string
+	}{
+		{name: "String Slice", required: []string{"path"}, input: map[string]any{"path": "a"}},
+		{name: "Any Slice Missing", required: []any{"path"}, input: map[string]any{}, wantErr: "missing required field: path"},
+		{name: "Single String", required: "path", input: map[string]any{"path": "a"}},
+		{name: "Single String Missing", required: "path", input: map[string]any{}, wantErr: "missing required field: path"},
+		{name: "Absent", required: nil, input: map[string]any{}},
+		{name: "Wrong Type", required: true, input: map[string]any{}, wantErr: `tool "t" has an invalid schema: required is bool, want an array of strings`},
+		{name: "Non-String Entry", required: []any{"path", 3.0}, input: map[string]any{"path": "a"}, wantErr: `tool "t" has an invalid schema: required[1] is float64, want string`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBaseTool("t", "test")
+			b.SchemaVal = map[string]any{"type": "object"}
+			if tt.required != nil {
+				b.SchemaVal["required"] = tt.required
+			}
+			err := ValidateInput(&b, tt.input)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateInput() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("ValidateInput() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResultToMessageWith(t *testing.T) {
+	call := types.NewToolCall("call_1", "lookup", `{}`)
+	formatError := func(call types.ToolCall, err error) string {
+		return call.Function.Name + " failed: " + err.Error()
+	}
+
+	msg := ResultToMessageWith(call, &ExecuteResult{Error: errors.New("timeout")}, formatError)
+	if msg.Content != "lookup failed: timeout" || msg.Metadata["error"] != true {
+		t.Errorf("ResultToMessageWith() = %+v, want the formatted, flagged error", msg)
+	}
+	msg = ResultToMessageWith(call, &ExecuteResult{Success: true, Output: "ok"}, formatError)
+	if msg.Content != "ok" || msg.Metadata != nil {
+		t.Errorf("ResultToMessageWith() = %+v, want successes untouched", msg)
+	}
+}
+
+func TestToDefinition_StripsSensitive(t *testing.T) {
+	type login struct {
+		User      string `json:"user"`
+		Password  string `json:"password" sensitive:"true"`
+		Sensitive bool   `json:"sensitive"`
+	}
+	st := NewStruct("login", "Log in.", func(ctx context.Context, in login, tc *ToolContext) (any, error) {
+		return "ok", nil
+	})
+
+	props := ToDefinition(st).Function.Parameters.(map[string]any)["properties"].(map[string]any)
+	if _, ok := props["password"].(map[string]any)["sensitive"]; ok {
+		t.Errorf("password schema = %v, want the sensitive marker stripped", props["password"])
+	}
+	if _, ok := props["sensitive"]; !ok {
+		t.Errorf("properties = %v, want the field named sensitive kept", props)
+	}
+	// The tool keeps the marker for redaction.
+	internal := st.InputSchema()["properties"].(map[string]any)["password"].(map[string]any)
+	if internal["sensitive"] != true {
+		t.Errorf("InputSchema() password = %v, want it still marked sensitive", internal)
+	}
+}
@@ -0,0 +1,81 @@
+package tool
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// RateLimitedTool is implemented by tools that call rate-limited services.
+// The executor gates each such tool with its own token bucket, on top of the
+// global concurrency cap. BaseTool implements it via RateLimitVal/RateBurstVal.
+type RateLimitedTool interface {
+	Tool
+
+	// RateLimit returns the sustained executions per second and the burst size.
+	// A non-positive rps disables limiting.
+	RateLimit() (rps float64, burst int)
+}
+
+// Clock abstracts the passage of time for rate-limit waits, so tests can
+// substitute a fake.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// limiterFor returns the shared limiter for t, or nil when t is not rate limited.
+func (e *Executor) limiterFor(t Tool) *rate.Limiter {
+	rt, ok := t.(RateLimitedTool)
+	if !ok {
+		return nil
+	}
+	rps, burst := rt.RateLimit()
+	if rps <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+
+	e.limitersMu.Lock()
+	defer e.limitersMu.Unlock()
+	lim, ok := e.limiters[t.Name()]
+	if !ok {
+		lim = rate.NewLimiter(rate.Limit(rps), burst)
+		e.limiters[t.Name()] = lim
+	}
+	return lim
+}
+
+// waitRateLimit blocks until t may run, or ctx is done.
+func (e *Executor) waitRateLimit(ctx context.Context, t Tool) error {
+	lim := e.limiterFor(t)
+	if lim == nil {
+		return nil
+	}
+	clock := e.config.Clock
+	now := clock.Now()
+	r := lim.ReserveN(now, 1)
+	if !r.OK() {
+		return fmt.Errorf("tool %s: rate limit reservation failed", t.Name())
+	}
+	delay := r.DelayFrom(now)
+	if delay <= 0 {
+		return nil
+	}
+	select {
+	case <-clock.After(delay):
+		return nil
+	case <-ctx.Done():
+		r.CancelAt(clock.Now())
+		return ctx.Err()
+	}
+}
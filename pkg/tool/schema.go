@@ -1,12 +1,18 @@
 package tool
 
 import (
+	"encoding/json"
+	"maps"
 	"reflect"
+	"slices"
 	"strings"
 )
 
 // GenerateSchema creates a JSON Schema from a Go struct.
-// It supports "json" tag for field names and "description" tag for descriptions.
+// It supports "json" tag for field names, "description" tag for descriptions
+// and sensitive:"true" for fields whose values must be masked in logs. The
+// "sensitive" marker is tool-side only: ToDefinition strips it before the
+// schema reaches a provider.
 func GenerateSchema(v any) map[string]any {
 	t := reflect.TypeOf(v)
 	if t.Kind() == reflect.Ptr {
@@ -52,6 +58,10 @@ func GenerateSchema(v any) map[string]any {
 		if desc != "" {
 			propSchema["description"] = desc
 		}
+		// sensitive:"true" marks secrets such as passwords; see RedactInput.
+		if field.Tag.Get("sensitive") == "true" {
+			propSchema["sensitive"] = true
+		}
 		
 		// Handle nested structs if necessary, but for now keep it simple (primitives)
 		// Expand as needed for complex types
@@ -96,3 +106,161 @@ func getType(t reflect.Type) string {
 	}
 }
 
+// EnrichSchema merges field descriptions into schema's properties and returns schema.
+// Keys are field paths; nested object fields use dots ("address.city"), and paths
+// descend through array items transparently. Paths that don't exist are ignored.
+// The schema is modified in place.
+func EnrichSchema(schema map[string]any, descriptions map[string]string) map[string]any {
+	for path, desc := range descriptions {
+		if prop := lookupProperty(schema, strings.Split(path, ".")); prop != nil {
+			prop["description"] = desc
+		}
+	}
+	return schema
+}
+
+// lookupProperty walks schema properties along path and returns the target property schema.
+func lookupProperty(schema map[string]any, path []string) map[string]any {
+	node := schema
+	for _, name := range path {
+		// Arrays of objects: descend into the item schema.
+		if items, ok := node["items"].(map[string]any); ok {
+			node = items
+		}
+		props, ok := node["properties"].(map[string]any)
+		if !ok {
+			return nil
+		}
+		next, ok := props[name].(map[string]any)
+		if !ok {
+			return nil
+		}
+		node = next
+	}
+	return node
+}
+
+// RedactedValue replaces sensitive argument values in logs and transcripts.
+const RedactedValue = "[REDACTED]"
+
+// RedactInput returns input with every value whose property schema is marked
+// "sensitive" (see GenerateSchema) replaced by RedactedValue, following nested
+// objects and arrays. input is not modified; it is returned as is when nothing
+// is sensitive.
+func RedactInput(schema, input map[string]any) map[string]any {
+	out, _ := redactValue(schema, input)
+	return out.(map[string]any)
+}
+
+// RedactArguments is RedactInput for JSON-encoded tool call arguments.
+// Arguments that are not a JSON object are returned unchanged.
+func RedactArguments(schema map[string]any, args string) string {
+	var input map[string]any
+	if json.Unmarshal([]byte(args), &input) != nil {
+		return args
+	}
+	redacted, changed := redactValue(schema, input)
+	if !changed {
+		return args // Keep the original encoding.
+	}
+	b, err := json.Marshal(redacted)
+	if err != nil {
+		return args
+	}
+	return string(b)
+}
+
+// redactValue masks the sensitive parts of v described by schema and reports
+// whether anything was masked. Only the maps and slices that change are copied.
+func redactValue(schema map[string]any, v any) (any, bool) {
+	switch val := v.(type) {
+	case map[string]any:
+		props, _ := schema["properties"].(map[string]any)
+		var out map[string]any
+		for k, x := range val {
+			prop, _ := props[k].(map[string]any)
+			masked, changed := any(RedactedValue), true
+			if prop["sensitive"] != true {
+				masked, changed = redactValue(prop, x)
+			}
+			if !changed {
+				continue
+			}
+			if out == nil {
+				out = maps.Clone(val)
+			}
+			out[k] = masked
+		}
+		if out == nil {
+			return val, false
+		}
+		return out, true
+	case []any:
+		items, _ := schema["items"].(map[string]any)
+		var out []any
+		for i, x := range val {
+			masked, changed := redactValue(items, x)
+			if !changed {
+				continue
+			}
+			if out == nil {
+				out = slices.Clone(val)
+			}
+			out[i] = masked
+		}
+		if out == nil {
+			return val, false
+		}
+		return out, true
+	}
+	return v, false
+}
+
+// stripSensitive returns schema without the "sensitive" markers RedactInput
+// reads, which are not JSON Schema keywords and are rejected by strict
+// providers. A property named "sensitive" is kept: only boolean markers are
+// removed. Only the maps and slices that change are copied.
+func stripSensitive(v any) (any, bool) {
+	switch val := v.(type) {
+	case map[string]any:
+		var out map[string]any
+		for k, x := range val {
+			if _, marker := x.(bool); marker && k == "sensitive" {
+				if out == nil {
+					out = maps.Clone(val)
+				}
+				delete(out, k)
+				continue
+			}
+			stripped, changed := stripSensitive(x)
+			if !changed {
+				continue
+			}
+			if out == nil {
+				out = maps.Clone(val)
+			}
+			out[k] = stripped
+		}
+		if out == nil {
+			return val, false
+		}
+		return out, true
+	case []any:
+		var out []any
+		for i, x := range val {
+			stripped, changed := stripSensitive(x)
+			if !changed {
+				continue
+			}
+			if out == nil {
+				out = slices.Clone(val)
+			}
+			out[i] = stripped
+		}
+		if out == nil {
+			return val, false
+		}
+		return out, true
+	}
+	return v, false
+}
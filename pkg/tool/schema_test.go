@@ -0,0 +1,95 @@
+package tool
+
+import "testing"
+
+func TestEnrichSchema(t *testing.T) {
+	schema := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name": map[string]any{"type": "string"},
+			"address": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"city": map[string]any{"type": "string"},
+				},
+			},
+			"tags": map[string]any{
+				"type": "array",
+				"items": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"label": map[string]any{"type": "string"},
+					},
+				},
+			},
+		},
+	}
+
+	EnrichSchema(schema, map[string]string{
+		"name":         "Full name",
+		"address.city": "City of residence",
+		"tags.label":   "Tag text",
+		"missing.path": "ignored",
+	})
+
+	props := schema["properties"].(map[string]any)
+	tests := []struct {
+		path string
+		prop map[string]any
+		want string
+	}{
+		{"name", props["name"].(map[string]any), "Full name"},
+		{"address.city", props["address"].(map[string]any)["properties"].(map[string]any)["city"].(map[string]any), "City of residence"},
+		{"tags.label", props["tags"].(map[string]any)["items"].(map[string]any)["properties"].(map[string]any)["label"].(map[string]any), "Tag text"},
+	}
+	for _, tt := range tests {
+		if got := tt.prop["description"]; got != tt.want {
+			t.Errorf("%s description = %v, want %q", tt.path, got, tt.want)
+		}
+	}
+	if _, ok := props["missing"]; ok {
+		t.Error("EnrichSchema() created a property for a missing path")
+	}
+}
+
+func TestFunc_WithFieldDescriptions(t *testing.T) {
+	f := NewFunc("f", "", nil).WithFieldDescriptions(map[string]string{"input": "What to process"})
+	input := f.InputSchema()["properties"].(map[string]any)["input"].(map[string]any)
+	if input["description"] != "What to process" {
+		t.Errorf("description = %v, want %q", input["description"], "What to process")
+	}
+}
+
+func TestRedactArguments(t *testing.T) {
+	type credential struct {
+		Host  string `json:"host"`
+		Token string `json:"token" sensitive:"true"`
+	}
+	schema := GenerateSchema(credential{})
+	if schema["properties"].(map[string]any)["token"].(map[string]any)["sensitive"] != true {
+		t.Fatalf("schema = %v, want token marked sensitive", schema)
+	}
+	// Nest the credential in an array to check redaction follows items.
+	wrapped := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"creds": map[string]any{"type": "array", "items": schema},
+		},
+	}
+
+	tests := []struct {
+		schema map[string]any
+		args   string
+		want   string
+	}{
+		{schema, `{"host":"a","token":"t1"}`, `{"host":"a","token":"[REDACTED]"}`},
+		{wrapped, `{"creds":[{"host":"a","token":"t1"}]}`, `{"creds":[{"host":"a","token":"[REDACTED]"}]}`},
+		{schema, `{"host": "a"}`, `{"host": "a"}`},
+		{schema, `not json`, `not json`},
+	}
+	for _, tt := range tests {
+		if got := RedactArguments(tt.schema, tt.args); got != tt.want {
+			t.Errorf("RedactArguments(%s) = %s, want %s", tt.args, got, tt.want)
+		}
+	}
+}
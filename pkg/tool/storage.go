@@ -0,0 +1,104 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrStateNotFound is returned when a storage key has no value.
+var ErrStateNotFound = errors.New("state not found")
+
+// MemoryStorage is a thread-safe, process-local Storage implementation.
+type MemoryStorage struct {
+	mu   sync.RWMutex
+	data map[string]any
+}
+
+// NewMemoryStorage creates an empty in-memory store.
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{data: make(map[string]any)}
+}
+
+// Get returns the value stored under key or ErrStateNotFound.
+func (s *MemoryStorage) Get(ctx context.Context, key string) (any, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v, ok := s.data[key]
+	if !ok {
+		return nil, ErrStateNotFound
+	}
+	return v, nil
+}
+
+// Set stores value under key, replacing any previous value.
+func (s *MemoryStorage) Set(ctx context.Context, key string, value any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[key] = value
+	return nil
+}
+
+// SaveState JSON-encodes value and stores it in tc.Storage under a key
+// namespaced by the session (or execution when no session is set).
+func SaveState(tc *ToolContext, key string, value any) error {
+	if tc == nil || tc.Storage == nil {
+		return fmt.Errorf("tool context has no storage")
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode state %q: %w", key, err)
+	}
+	return tc.Storage.Set(stateContext(tc), stateKey(tc, key), raw)
+}
+
+// LoadState reads a value saved with SaveState and decodes it into T.
+func LoadState[T any](tc *ToolContext, key string) (T, error) {
+	var out T
+	if tc == nil || tc.Storage == nil {
+		return out, fmt.Errorf("tool context has no storage")
+	}
+	v, err := tc.Storage.Get(stateContext(tc), stateKey(tc, key))
+	if err != nil {
+		return out, err
+	}
+
+	var raw []byte
+	switch val := v.(type) {
+	case []byte:
+		raw = val
+	case json.RawMessage:
+		raw = val
+	case string:
+		raw = []byte(val)
+	default:
+		return out, fmt.Errorf("state %q has unexpected type %T", key, v)
+	}
+
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return out, fmt.Errorf("failed to decode state %q: %w", key, err)
+	}
+	return out, nil
+}
+
+func stateKey(tc *ToolContext, key string) string {
+	switch {
+	case tc.SessionID != "":
+		return "session/" + tc.SessionID + "/" + key
+	case tc.ExecutionID != "":
+		return "execution/" + tc.ExecutionID + "/" + key
+	default:
+		return "global/" + key
+	}
+}
+
+func stateContext(tc *ToolContext) context.Context {
+	if tc.Context != nil {
+		return tc.Context
+	}
+	return context.Background()
+}
+
+var _ Storage = (*MemoryStorage)(nil)
@@ -0,0 +1,56 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type progress struct {
+	Step  int      `json:"step"`
+	Items []string `json:"items"`
+}
+
+func TestSaveLoadState_AcrossInvocations(t *testing.T) {
+	tc := NewToolContext(WithSessionID("s1"), WithStorage(NewMemoryStorage()))
+
+	step := NewFunc("step", "multi-step tool", func(ctx context.Context, input map[string]any, tc *ToolContext) (any, error) {
+		state, err := LoadState[progress](tc, "progress")
+		if err != nil && !errors.Is(err, ErrStateNotFound) {
+			return nil, err
+		}
+		state.Step++
+		state.Items = append(state.Items, input["input"].(string))
+		if err := SaveState(tc, "progress", state); err != nil {
+			return nil, err
+		}
+		return state.Step, nil
+	})
+
+	ctx := context.Background()
+	for _, in := range []string{"a", "b"} {
+		if _, err := step.Execute(ctx, map[string]any{"input": in}, tc); err != nil {
+			t.Fatalf("Execute() error = %v", err)
+		}
+	}
+
+	got, err := LoadState[progress](tc, "progress")
+	if err != nil {
+		t.Fatalf("LoadState() error = %v", err)
+	}
+	if got.Step != 2 || len(got.Items) != 2 || got.Items[1] != "b" {
+		t.Errorf("LoadState() = %+v, want step 2 with items [a b]", got)
+	}
+
+	// A different session must not see the state.
+	other := NewToolContext(WithSessionID("s2"), WithStorage(tc.Storage))
+	if _, err := LoadState[progress](other, "progress"); !errors.Is(err, ErrStateNotFound) {
+		t.Errorf("LoadState() from other session error = %v, want ErrStateNotFound", err)
+	}
+}
+
+func TestSaveState_NoStorage(t *testing.T) {
+	if err := SaveState(NewToolContext(), "k", 1); err == nil {
+		t.Error("SaveState() expected error without storage")
+	}
+}
@@ -0,0 +1,83 @@
+package tool
+
+import (
+	"context"
+	"errors"
+)
+
+// ToolEventType classifies a ToolEvent.
+type ToolEventType string
+
+const (
+	ToolEventProgress ToolEventType = "progress" // Status update, e.g. "scanned 40%"
+	ToolEventPartial  ToolEventType = "partial"  // Incremental output, e.g. new log lines
+	ToolEventResult   ToolEventType = "result"   // Terminal event carrying the final output
+	ToolEventError    ToolEventType = "error"    // Terminal event carrying the failure
+)
+
+// ToolEvent is one update emitted by a StreamingTool.
+type ToolEvent struct {
+	Type     ToolEventType
+	Message  string  // Human-readable status for progress events
+	Progress float64 // Completion fraction in [0, 1], or 0 when unknown
+	Output   any     // Partial output, or the final result for ToolEventResult
+	Err      error   // Set for ToolEventError
+	// RequestID is the ToolContext's Metadata[MetadataRequestID], set by the
+	// executor so observers can correlate events with the requesting LLM turn.
+	RequestID string
+}
+
+// StreamingTool is implemented by tools that report incremental results.
+// The executor prefers ExecuteStream over Execute and forwards every event to
+// its Observer. The channel must end with a ToolEventResult or ToolEventError
+// event and then be closed.
+type StreamingTool interface {
+	Tool
+	ExecuteStream(ctx context.Context, input map[string]any, tc *ToolContext) (<-chan ToolEvent, error)
+}
+
+// Observer receives events from tool executions.
+type Observer interface {
+	OnToolEvent(toolName string, event ToolEvent)
+}
+
+// ObserverFunc adapts a function into an Observer.
+type ObserverFunc func(toolName string, event ToolEvent)
+
+func (f ObserverFunc) OnToolEvent(toolName string, event ToolEvent) {
+	f(toolName, event)
+}
+
+// errNoResult is returned when a streaming tool closes its channel without a terminal event.
+var errNoResult = errors.New("streaming tool ended without a result")
+
+// runStreaming drives a StreamingTool to completion, forwarding events to the observer.
+func (e *Executor) runStreaming(ctx context.Context, st StreamingTool, input map[string]any, tc *ToolContext) (any, error) {
+	events, err := st.ExecuteStream(ctx, input, tc)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				return nil, errNoResult
+			}
+			if e.config.Observer != nil {
+				ev.RequestID, _ = tc.Metadata[MetadataRequestID].(string)
+				e.config.Observer.OnToolEvent(st.Name(), ev)
+			}
+			switch ev.Type {
+			case ToolEventResult:
+				return ev.Output, nil
+			case ToolEventError:
+				if ev.Err == nil {
+					ev.Err = errors.New(ev.Message)
+				}
+				return nil, ev.Err
+			}
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package tool
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+// tailTool emits three progress events and then a result.
+type tailTool struct {
+	BaseTool
+}
+
+func (t *tailTool) ExecuteStream(ctx context.Context, input map[string]any, tc *ToolContext) (<-chan ToolEvent, error) {
+	ch := make(chan ToolEvent)
+	go func() {
+		defer close(ch)
+		for i := 1; i <= 3; i++ {
+			ch <- ToolEvent{Type: ToolEventProgress, Message: fmt.Sprintf("step %d", i), Progress: float64(i) / 3}
+		}
+		ch <- ToolEvent{Type: ToolEventResult, Output: "done"}
+	}()
+	return ch, nil
+}
+
+func TestExecute_StreamingTool(t *testing.T) {
+	var events []ToolEvent
+	exec := NewExecutor(ExecutorConfig{
+		Observer: ObserverFunc(func(name string, ev ToolEvent) {
+			if name != "tail" {
+				t.Errorf("observer tool name = %q, want tail", name)
+			}
+			events = append(events, ev)
+		}),
+	})
+
+	res := exec.Execute(context.Background(), &ExecuteRequest{
+		Tool:  &tailTool{BaseTool: NewBaseTool("tail", "")},
+		Input: map[string]any{},
+	})
+
+	if !res.Success || res.Output != "done" {
+		t.Fatalf("Execute() = %+v, want success with output done", res)
+	}
+	if len(events) != 4 {
+		t.Fatalf("observed %d events, want 4", len(events))
+	}
+	for i := 0; i < 3; i++ {
+		if events[i].Type != ToolEventProgress || events[i].Message != fmt.Sprintf("step %d", i+1) {
+			t.Errorf("events[%d] = %+v, want progress step %d", i, events[i], i+1)
+		}
+	}
+	if events[3].Type != ToolEventResult {
+		t.Errorf("events[3].Type = %q, want result", events[3].Type)
+	}
+}
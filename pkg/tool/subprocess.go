@@ -0,0 +1,161 @@
+package tool
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"sync"
+)
+
+// SubprocessTool runs a tool implemented by an external process speaking a
+// JSON-lines protocol over stdio. Each call writes one request line
+//
+//	{"name": "...", "input": {...}}
+//
+// and reads one response line
+//
+//	{"output": ..., "error": "..."}
+//
+// The process is started lazily and reused across calls; calls are serialized.
+// If the process crashes or a call times out, it is killed and restarted on the next call.
+type SubprocessTool struct {
+	BaseTool
+
+	cmd  string
+	args []string
+
+	mu     sync.Mutex
+	proc   *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+}
+
+type subprocessRequest struct {
+	Name  string         `json:"name"`
+	Input map[string]any `json:"input"`
+}
+
+type subprocessResponse struct {
+	Output any    `json:"output"`
+	Error  string `json:"error,omitempty"`
+}
+
+// NewSubprocessTool creates a tool backed by the given command.
+func NewSubprocessTool(name, description string, schema map[string]any, cmd string, args ...string) *SubprocessTool {
+	t := &SubprocessTool{
+		BaseTool: NewBaseTool(name, description),
+		cmd:      cmd,
+		args:     args,
+	}
+	t.SchemaVal = schema
+	return t
+}
+
+func (t *SubprocessTool) Execute(ctx context.Context, input map[string]any, tc *ToolContext) (any, error) {
+	if timeout := t.Timeout(); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	line, err := json.Marshal(subprocessRequest{Name: t.Name(), Input: input})
+	if err != nil {
+		return nil, fmt.Errorf("encode request: %w", err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.start(); err != nil {
+		return nil, err
+	}
+
+	type reply struct {
+		data []byte
+		err  error
+	}
+	stdin, stdout := t.stdin, t.stdout
+	done := make(chan reply, 1)
+	go func() {
+		if _, err := stdin.Write(append(line, '\n')); err != nil {
+			done <- reply{err: err}
+			return
+		}
+		data, err := stdout.ReadBytes('\n')
+		done <- reply{data: data, err: err}
+	}()
+
+	var r reply
+	select {
+	case r = <-done:
+	case <-ctx.Done():
+		// The protocol state is unknown after an abandoned call, so start fresh next time.
+		t.stop()
+		return nil, ctx.Err()
+	}
+	if r.err != nil {
+		t.stop()
+		return nil, fmt.Errorf("subprocess %s: %w", t.cmd, r.err)
+	}
+
+	var resp subprocessResponse
+	if err := json.Unmarshal(r.data, &resp); err != nil {
+		t.stop()
+		return nil, fmt.Errorf("subprocess %s: invalid response: %w", t.cmd, err)
+	}
+	if resp.Error != "" {
+		return nil, errors.New(resp.Error)
+	}
+	return resp.Output, nil
+}
+
+// Close terminates the subprocess if it is running.
+func (t *SubprocessTool) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stop()
+	return nil
+}
+
+// start launches the process if it is not already running. Callers must hold t.mu.
+func (t *SubprocessTool) start() error {
+	if t.proc != nil {
+		return nil
+	}
+	cmd := exec.Command(t.cmd, t.args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("subprocess %s: %w", t.cmd, err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("subprocess %s: %w", t.cmd, err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("subprocess %s: %w", t.cmd, err)
+	}
+	t.proc, t.stdin, t.stdout = cmd, stdin, bufio.NewReader(stdout)
+	return nil
+}
+
+// stop kills the process and releases its pipes. Callers must hold t.mu.
+func (t *SubprocessTool) stop() {
+	if t.proc == nil {
+		return
+	}
+	t.stdin.Close()
+	if t.proc.Process != nil {
+		t.proc.Process.Kill()
+	}
+	t.proc.Wait()
+	t.proc, t.stdin, t.stdout = nil, nil, nil
+}
+
+var (
+	_ EnhancedTool = (*SubprocessTool)(nil)
+	_ Closeable    = (*SubprocessTool)(nil)
+)
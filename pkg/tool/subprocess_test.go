@@ -0,0 +1,67 @@
+package tool
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeScript(t *testing.T, dir, body string) string {
+	t.Helper()
+	path := filepath.Join(dir, "tool.sh")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSubprocessTool_Echo(t *testing.T) {
+	dir, err := os.MkdirTemp("", "subprocess_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Echo every request back as the output.
+	script := writeScript(t, dir, `while IFS= read -r line; do printf '{"output":%s}\n' "$line"; done`)
+	st := NewSubprocessTool("echo", "echoes input", nil, script)
+	defer st.Close()
+
+	for i := 0; i < 2; i++ {
+		out, err := st.Execute(context.Background(), map[string]any{"msg": "hi"}, nil)
+		if err != nil {
+			t.Fatalf("Execute() error = %v", err)
+		}
+		got, ok := out.(map[string]any)
+		if !ok || got["name"] != "echo" {
+			t.Fatalf("Execute() = %v, want echoed request", out)
+		}
+		if in, _ := got["input"].(map[string]any); in["msg"] != "hi" {
+			t.Errorf("echoed input = %v, want msg=hi", got["input"])
+		}
+	}
+}
+
+func TestSubprocessTool_ErrorAndCrash(t *testing.T) {
+	dir, err := os.MkdirTemp("", "subprocess_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	script := writeScript(t, dir, `read -r line; echo '{"error":"boom"}'; read -r line; exit 1`)
+	st := NewSubprocessTool("flaky", "fails", nil, script)
+	defer st.Close()
+
+	if _, err := st.Execute(context.Background(), map[string]any{}, nil); err == nil || err.Error() != "boom" {
+		t.Errorf("Execute() error = %v, want boom", err)
+	}
+	if _, err := st.Execute(context.Background(), map[string]any{}, nil); err == nil {
+		t.Error("Execute() expected error after process crash")
+	}
+	// The process is restarted on the next call.
+	if _, err := st.Execute(context.Background(), map[string]any{}, nil); err == nil || err.Error() != "boom" {
+		t.Errorf("Execute() after restart error = %v, want boom", err)
+	}
+}
@@ -43,6 +43,23 @@ type EnhancedTool interface {
 	RetryPolicy() *RetryPolicy
 }
 
+// Closeable is implemented by tools that hold resources, such as a running
+// process, which must be released when the tool is no longer needed.
+type Closeable interface {
+	Close() error
+}
+
+// ProcessResult is the output of tools that run a process, such as Bash and Shell.
+// Observers can type-assert ExecuteResult.Output (or use ExecuteResult.Process)
+// to read the exit code. Fields are declared in key order so it encodes
+// exactly like the map[string]any these tools used to return.
+type ProcessResult struct {
+	ExitCode int    `json:"code"`            // -1 when the process did not exit normally
+	Error    string `json:"error,omitempty"` // Why the process failed to run, if it did
+	Stderr   string `json:"stderr"`
+	Stdout   string `json:"stdout"`
+}
+
 // RetryPolicy defines how tool execution should be retried on failure.
 type RetryPolicy struct {
 	MaxRetries        int
@@ -0,0 +1,73 @@
+package types
+
+import (
+	"encoding/base64"
+	"fmt"
+)
+
+// ContentPartType identifies the kind of a ContentPart.
+type ContentPartType string
+
+const (
+	PartText  ContentPartType = "text"
+	PartImage ContentPartType = "image"
+)
+
+// ContentPart is one piece of multimodal message content. Message.Content
+// still holds the text of a message; Parts carries what plain text cannot.
+type ContentPart struct {
+	Type     ContentPartType `json:"type"`
+	Text     string          `json:"text,omitempty"`
+	ImageURL string          `json:"image_url,omitempty"` // http(s) or data: URL
+	MIMEType string          `json:"mime_type,omitempty"` // For Data, e.g. "image/png"
+	Data     []byte          `json:"data,omitempty"`      // Inline image bytes, used when ImageURL is empty
+}
+
+// ImagePart builds an image part from inline bytes.
+func ImagePart(mime string, data []byte) ContentPart {
+	return ContentPart{Type: PartImage, MIMEType: mime, Data: data}
+}
+
+// ImageURLPart builds an image part referencing an http(s) or data: URL.
+func ImageURLPart(url string) ContentPart {
+	return ContentPart{Type: PartImage, ImageURL: url}
+}
+
+// URL returns the part's image URL, encoding inline data as a data: URL.
+func (p ContentPart) URL() string {
+	if p.ImageURL != "" {
+		return p.ImageURL
+	}
+	return "data:" + p.MIMEType + ";base64," + base64.StdEncoding.EncodeToString(p.Data)
+}
+
+// HasImages reports whether the message carries any image parts.
+func (m Message) HasImages() bool {
+	for _, p := range m.Parts {
+		if p.Type == PartImage {
+			return true
+		}
+	}
+	return false
+}
+
+// UserImageMessage builds a user turn asking about the images at imageURLs.
+func UserImageMessage(text string, imageURLs ...string) Message {
+	parts := make([]ContentPart, len(imageURLs))
+	for i, url := range imageURLs {
+		parts[i] = ImageURLPart(url)
+	}
+	return Message{Role: RoleUser, Content: text, Parts: parts}
+}
+
+// ToolImageResult builds a tool reply carrying an image, e.g. a screenshot or
+// chart. Content holds a short text description for models and stores that
+// only see text.
+func ToolImageResult(callID, mime string, data []byte) Message {
+	return Message{
+		Role:       RoleTool,
+		Content:    fmt.Sprintf("[%s image, %d bytes]", mime, len(data)),
+		ToolCallID: callID,
+		Parts:      []ContentPart{ImagePart(mime, data)},
+	}
+}
@@ -1,5 +1,10 @@
 package types
 
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
 // Role identifies who authored a message in the conversation.
 type Role string
 
@@ -18,6 +23,10 @@ type ToolCall struct {
 		Name      string `json:"name"`
 		Arguments string `json:"arguments"` // JSON string arguments
 	} `json:"function"`
+	// Index is the call's position among parallel calls of a streamed
+	// response; fragments after the first carry only the index to say which
+	// call they extend.
+	Index int `json:"index,omitempty"`
 }
 
 // ToolDefinition describes a tool available to the model.
@@ -38,19 +47,96 @@ type Usage struct {
 	TotalTokens      int `json:"total_tokens"`
 }
 
+// Add returns the sum of u and other, for aggregating usage across calls.
+func (u Usage) Add(other Usage) Usage {
+	return Usage{
+		PromptTokens:     u.PromptTokens + other.PromptTokens,
+		CompletionTokens: u.CompletionTokens + other.CompletionTokens,
+		TotalTokens:      u.TotalTokens + other.TotalTokens,
+	}
+}
+
 // Message is a single chat turn.
 // It is designed to be flexible enough to handle various LLM APIs.
 type Message struct {
-	Role       Role        `json:"role"`
-	Content    string      `json:"content"`
-	Name       string      `json:"name,omitempty"`       // Optional: author name
-	ToolCalls  []ToolCall  `json:"tool_calls,omitempty"` // For RoleAssistant: tools the model wants to call
-	ToolCallID string      `json:"tool_call_id,omitempty"` // For RoleTool: the ID of the call this message responds to
+	Role       Role           `json:"role"`
+	Content    string         `json:"content"`
+	Name       string         `json:"name,omitempty"`         // Optional: author name
+	ToolCalls  []ToolCall     `json:"tool_calls,omitempty"`   // For RoleAssistant: tools the model wants to call
+	ToolCallID string         `json:"tool_call_id,omitempty"` // For RoleTool: the ID of the call this message responds to
+	Parts      []ContentPart  `json:"parts,omitempty"`        // Optional: non-text content such as images
+	Metadata   map[string]any `json:"metadata,omitempty"`     // Local annotations (e.g. "partial"); never sent to providers
 }
 
 // ChatResponse represents the full response from a ChatModel.
 type ChatResponse struct {
+	ID           string // Provider response ID, e.g. "chatcmpl-..."; empty when not reported
 	Message      Message
 	FinishReason string // stop, length, tool_calls, content_filter
 	Usage        Usage
+	Provider     string // Name of the model that served the request, set by routing wrappers
+	Model        string // Model ID reported by the provider, e.g. "gpt-4o-2024-08-06"
+	// Raw is the decoded provider response, attached only when requested with
+	// provider.WithIncludeRaw, for fields not modeled here (e.g. system_fingerprint).
+	Raw any `json:"-"`
+}
+
+// UserMessage builds a user turn.
+func UserMessage(content string) Message {
+	return Message{Role: RoleUser, Content: content}
+}
+
+// SystemMessage builds a system instruction.
+func SystemMessage(content string) Message {
+	return Message{Role: RoleSystem, Content: content}
+}
+
+// AssistantMessage builds a plain-text assistant turn.
+func AssistantMessage(content string) Message {
+	return Message{Role: RoleAssistant, Content: content}
+}
+
+// AssistantToolCall builds an assistant turn that requests the given tool calls.
+func AssistantToolCall(calls ...ToolCall) Message {
+	return Message{Role: RoleAssistant, ToolCalls: calls}
+}
+
+// ToolResultMessage builds the tool reply for the call with the given ID.
+func ToolResultMessage(callID, content string) Message {
+	return Message{Role: RoleTool, Content: content, ToolCallID: callID}
+}
+
+// NewToolCall builds a function tool call; argsJSON must be a JSON object string.
+func NewToolCall(id, name, argsJSON string) ToolCall {
+	tc := ToolCall{ID: id, Type: "function"}
+	tc.Function.Name = name
+	tc.Function.Arguments = argsJSON
+	return tc
+}
+
+// NewToolCallID returns a random tool call ID, for providers and wrappers that
+// must mint one. IDs derived from the call itself would repeat whenever the
+// model makes the same call again later in a conversation.
+func NewToolCallID() string {
+	return randomID("call_")
+}
+
+// NewResponseID returns a random response ID, for providers that report none.
+func NewResponseID() string {
+	return randomID("resp_")
+}
+
+func randomID(prefix string) string {
+	var b [12]byte
+	rand.Read(b[:])
+	return prefix + hex.EncodeToString(b[:])
+}
+
+// NewToolDefinition builds a function tool definition with a JSON Schema for its parameters.
+func NewToolDefinition(name, description string, parameters any) ToolDefinition {
+	def := ToolDefinition{Type: "function"}
+	def.Function.Name = name
+	def.Function.Description = description
+	def.Function.Parameters = parameters
+	return def
 }
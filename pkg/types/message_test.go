@@ -0,0 +1,104 @@
+package types
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestMessageConstructors(t *testing.T) {
+	tests := []struct {
+		name     string
+		msg      Message
+		wantRole Role
+		wantText string
+	}{
+		{name: "User", msg: UserMessage("hi"), wantRole: RoleUser, wantText: "hi"},
+		{name: "System", msg: SystemMessage("be nice"), wantRole: RoleSystem, wantText: "be nice"},
+		{name: "Assistant", msg: AssistantMessage("hello"), wantRole: RoleAssistant, wantText: "hello"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.msg.Role != tt.wantRole || tt.msg.Content != tt.wantText {
+				t.Errorf("got (%s, %q), want (%s, %q)", tt.msg.Role, tt.msg.Content, tt.wantRole, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestToolResultMessage(t *testing.T) {
+	msg := ToolResultMessage("call_1", "42")
+	if msg.Role != RoleTool || msg.ToolCallID != "call_1" || msg.Content != "42" {
+		t.Errorf("ToolResultMessage() = %+v", msg)
+	}
+}
+
+func TestUserImageMessage(t *testing.T) {
+	msg := UserImageMessage("What is this?", "https://example.com/a.png", "data:image/png;base64,AAAA")
+	if msg.Role != RoleUser || msg.Content != "What is this?" || len(msg.Parts) != 2 {
+		t.Fatalf("UserImageMessage() = %+v", msg)
+	}
+	if !msg.HasImages() || msg.Parts[1].URL() != "data:image/png;base64,AAAA" {
+		t.Errorf("Parts = %+v", msg.Parts)
+	}
+	if plain := UserImageMessage("hi"); plain.HasImages() {
+		t.Errorf("UserImageMessage() without URLs = %+v, want no images", plain)
+	}
+}
+
+func TestAssistantToolCall(t *testing.T) {
+	msg := AssistantToolCall(
+		NewToolCall("call_1", "get_weather", `{"city":"Shanghai"}`),
+		NewToolCall("call_2", "clock", `{}`),
+	)
+	if msg.Role != RoleAssistant || len(msg.ToolCalls) != 2 {
+		t.Fatalf("AssistantToolCall() = %+v", msg)
+	}
+	if msg.ToolCalls[1].ID != "call_2" {
+		t.Errorf("ToolCalls[1].ID = %q, want call_2", msg.ToolCalls[1].ID)
+	}
+}
+
+func TestNewToolCall(t *testing.T) {
+	tc := NewToolCall("call_1", "get_weather", `{"city":"Shanghai"}`)
+
+	raw, err := json.Marshal(tc)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	want := `{"id":"call_1","type":"function","function":{"name":"get_weather","arguments":"{\"city\":\"Shanghai\"}"}}`
+	if string(raw) != want {
+		t.Errorf("json = %s, want %s", raw, want)
+	}
+}
+
+func TestNewToolDefinition(t *testing.T) {
+	def := NewToolDefinition("clock", "current time", map[string]any{"type": "object"})
+	if def.Type != "function" || def.Function.Name != "clock" || def.Function.Description != "current time" {
+		t.Errorf("NewToolDefinition() = %+v", def)
+	}
+}
+
+func TestToolImageResult(t *testing.T) {
+	msg := ToolImageResult("call_1", "image/png", []byte("abc"))
+	if msg.Role != RoleTool || msg.ToolCallID != "call_1" || !msg.HasImages() {
+		t.Fatalf("ToolImageResult() = %+v, want tool message with an image", msg)
+	}
+	if got, want := msg.Parts[0].URL(), "data:image/png;base64,YWJj"; got != want {
+		t.Errorf("URL() = %q, want %q", got, want)
+	}
+	if got := (ContentPart{Type: PartImage, ImageURL: "https://example.com/a.png"}).URL(); got != "https://example.com/a.png" {
+		t.Errorf("URL() = %q, want the image URL", got)
+	}
+}
+
+func TestNewToolCallID(t *testing.T) {
+	a, b := NewToolCallID(), NewToolCallID()
+	if !strings.HasPrefix(a, "call_") || len(a) != len("call_")+24 {
+		t.Errorf("NewToolCallID() = %q, want call_ and 24 hex digits", a)
+	}
+	if a == b {
+		t.Errorf("NewToolCallID() repeated %q", a)
+	}
+}